@@ -0,0 +1,9 @@
+// api/products/v1/doc.go
+
+// Package productsv1 holds the generated code for the products.v1 gRPC API.
+// Regenerate from the module root after editing products.proto:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//		--go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//		api/products/v1/products.proto
+package productsv1
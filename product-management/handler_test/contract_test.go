@@ -0,0 +1,180 @@
+package product_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"product-management/internal/config"
+	"product-management/internal/domain"
+	"product-management/internal/handler"
+	"product-management/internal/openapi"
+	"product-management/internal/problem"
+	"product-management/internal/repository/memory"
+	"product-management/internal/service"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test kontrak: setiap response handler harus sesuai dengan openapi.json
+func TestHandlersMatchOpenAPIContract(t *testing.T) {
+	productService, err := service.NewProductService(memory.NewMemoryProductRepository(), memory.NewMemoryProductRepository())
+	if err != nil {
+		t.Fatal(err)
+	}
+	productHandler := handler.NewProductHandler(productService)
+
+	validator, err := openapi.NewValidator(openapi.Options{ValidateResponses: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(validator.Middleware())
+	app.Use(withTenant)
+	app.Post("/products", productHandler.CreateProduct)
+	app.Get("/products", productHandler.GetAllProducts)
+	app.Get("/products/:id", productHandler.GetProductByID)
+	app.Put("/products/:id", productHandler.UpdateProduct)
+	app.Delete("/products/:id", productHandler.DeleteProduct)
+	app.Get("/mysql-products", productHandler.GetMySQLProducts)
+	app.Get("/mongodb-products", productHandler.GetMongoDBProducts)
+
+	body := `{"name":"kecap","description":"asus","price":10000,"stock":20}`
+	tests := []struct {
+		method, path, body string
+		wantStatus         int
+	}{
+		{"GET", "/products", "", fiber.StatusOK},
+		{"POST", "/products", body, fiber.StatusCreated},
+		// produk 9 tidak ada di kedua backend, jadi handler menjawab 404
+		{"GET", "/products/9", "", fiber.StatusNotFound},
+		{"PUT", "/products/9", body, fiber.StatusNotFound},
+		{"DELETE", "/products/9", "", fiber.StatusNotFound},
+		{"GET", "/mysql-products", "", fiber.StatusOK},
+		{"GET", "/mongodb-products", "", fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+// failingRepo gagal di setiap operasi dengan err
+type failingRepo struct{ err error }
+
+func (r *failingRepo) Create(context.Context, *domain.Product) error { return r.err }
+func (r *failingRepo) GetAllProducts(context.Context) ([]domain.Product, error) {
+	return nil, r.err
+}
+func (r *failingRepo) GetProductById(context.Context, string) (*domain.Product, error) {
+	return nil, r.err
+}
+func (r *failingRepo) GetProductsByIds(context.Context, []string) ([]domain.Product, error) {
+	return nil, r.err
+}
+func (r *failingRepo) UpdateProduct(context.Context, string, *domain.Product) error { return r.err }
+func (r *failingRepo) DeleteProduct(context.Context, string) error                  { return r.err }
+
+// Test: error domain dipetakan ke status HTTP oleh satu error handler
+func TestDomainErrorsMapToStatusCodes(t *testing.T) {
+	body := `{"name":"kecap","description":"asus","price":10000,"stock":20}`
+	tests := []struct {
+		name, method, path, body string
+		err                      error
+		wantStatus               int
+		wantType, wantDetail     string
+	}{
+		{"not found", "GET", "/products/1", "", domain.ProductNotFound(errors.New("sql: no rows in result set")), fiber.StatusNotFound, problem.TypeNotFound, "Product not found"},
+		{"conflict", "POST", "/products", body, domain.NewError(domain.ErrConflict, "A product with this name already exists", errors.New("Error 1062")), fiber.StatusConflict, problem.TypeConflict, "A product with this name already exists"},
+		{"validation", "POST", "/products", `{"name":"kecap","description":"","price":0,"stock":1}`, nil, fiber.StatusUnprocessableEntity, problem.TypeValidation, "Invalid product"},
+		{"unavailable", "GET", "/products", "", domain.NewError(domain.ErrUnavailable, "MySQL is unavailable", errors.New("dial tcp: connection refused")), fiber.StatusServiceUnavailable, problem.TypeUnavailable, "MySQL is unavailable"},
+		// teks error driver tidak boleh bocor ke client
+		{"unexpected", "GET", "/products", "", errors.New("Error 1146: Table 'products' doesn't exist"), fiber.StatusInternalServerError, problem.TypeBlank, ""},
+		{"malformed body", "POST", "/products", `{`, nil, fiber.StatusBadRequest, problem.TypeBlank, "Invalid input"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &failingRepo{err: tt.err}
+			productService, err := service.NewProductService(repo, repo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			productHandler := handler.NewProductHandler(productService)
+			app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+			app.Post("/products", productHandler.CreateProduct)
+			app.Get("/products", productHandler.GetAllProducts)
+			app.Get("/products/:id", productHandler.GetProductByID)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, problem.ContentType, resp.Header.Get("Content-Type"))
+
+			var got problem.Problem
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, tt.wantType, got.Type)
+			assert.Equal(t, tt.wantStatus, got.Status)
+			assert.NotEmpty(t, got.Title)
+			assert.Equal(t, tt.wantDetail, got.Detail)
+			assert.Equal(t, tt.path, got.Instance)
+			if tt.wantStatus == fiber.StatusUnprocessableEntity {
+				assert.Equal(t, []problem.InvalidParam{
+					{Name: "description", Reason: "is required"},
+					{Name: "price", Reason: "must be greater than 0"},
+				}, got.InvalidParams)
+			}
+		})
+	}
+}
+
+// Test: dengan mode degradasi, backend yang sehat tetap menjawab dan
+// response ditandai sebagai hasil parsial
+func TestPartialProductListIsMarked(t *testing.T) {
+	broken := &failingRepo{err: domain.NewError(domain.ErrUnavailable, "MySQL is unavailable", errors.New("dial tcp: connection refused"))}
+	productService, err := service.NewProductService(broken, seeded(t, mongoCatalog...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	productService.SetListConfig(config.ListConfig{AllowPartial: true}, "mysql", "mongodb")
+	productHandler := handler.NewProductHandler(productService)
+
+	validator, err := openapi.NewValidator(openapi.Options{ValidateResponses: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(validator.Middleware())
+	app.Use(withTenant)
+	app.Get("/products", productHandler.GetAllProducts)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/products", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get(handler.PartialResultHeader))
+	assert.Equal(t, "mysql=failed;count=0, mongodb=ok;count=3", resp.Header.Get(handler.SourcesHeader))
+	var products []domain.Product
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&products))
+	assert.Len(t, products, 3)
+
+	// Tanpa mode degradasi kegagalan MySQL menggagalkan seluruh daftar
+	productService.SetListConfig(config.ListConfig{}, "mysql", "mongodb")
+	resp, err = app.Test(httptest.NewRequest("GET", "/products", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+}
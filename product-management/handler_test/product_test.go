@@ -1,102 +1,55 @@
 package product_test
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"net/http/httptest"
 	"product-management/internal/domain"
+	"product-management/internal/repository/memory"
 	"product-management/internal/service"
+	"product-management/internal/tenant"
 	"testing"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 )
 
-// Mock MySQL Repository
-type mockMySQLRepo struct{}
+// shop is the tenant the tests run as.
+const shop = "shop-a"
 
-func (m *mockMySQLRepo) GetAllProducts() ([]domain.Product, error) {
-	return nil, nil
+// withTenant serves every request as the test tenant.
+func withTenant(c *fiber.Ctx) error {
+	c.SetUserContext(tenant.WithTenant(c.UserContext(), shop))
+	return c.Next()
 }
 
-func (m *mockMySQLRepo) Create(product *domain.Product) error {
-	return nil
-}
-
-func (m *mockMySQLRepo) GetProductById(id string) (*domain.Product, error) {
-	return nil, nil
-}
-
-func (m *mockMySQLRepo) UpdateProduct(id string, product *domain.Product) error {
-	return nil
-}
-
-func (m *mockMySQLRepo) DeleteProduct(id string) error {
-	return nil
-}
-
-// Mock MongoDB Repository
-type mockMongoRepo struct{}
-
-func (m *mockMongoRepo) GetAllProducts() ([]domain.Product, error) {
-	return []domain.Product{
-		{
-			ID:          "66f268c84b7253868ad8312e",
-			Name:        "kecap",
-			Description: "asus",
-			Price:       10000,
-			Stock:       20,
-		},
-		{
-			ID:          "66f269094b7253868ad8312f",
-			Name:        "ritonga",
-			Description: "New product description",
-			Price:       49.99,
-			Stock:       20,
-		},
-		{
-			ID:          "66f28741deae451485368e74",
-			Name:        "kiki",
-			Description: "New product description",
-			Price:       49.99,
-			Stock:       20,
-		},
-	}, nil
-}
-
-func (m *mockMongoRepo) Create(product *domain.Product) error {
-	return nil
-}
-
-func (m *mockMongoRepo) GetProductById(id string) (*domain.Product, error) {
-	if id == "66f268c84b7253868ad8312e" {
-		return &domain.Product{
-			ID:          "66f268c84b7253868ad8312e",
-			Name:        "kecap",
-			Description: "asus",
-			Price:       10000,
-			Stock:       20,
-		}, nil
+// seeded returns a memory repository holding products in the test tenant.
+func seeded(t *testing.T, products ...domain.Product) *memory.MemoryProductRepository {
+	repo := memory.NewMemoryProductRepository()
+	ctx := tenant.WithTenant(context.Background(), shop)
+	for _, p := range products {
+		if err := repo.Create(ctx, &p); err != nil {
+			t.Fatal(err)
+		}
 	}
-	return nil, errors.New("product not found")
-}
-
-func (m *mockMongoRepo) UpdateProduct(id string, product *domain.Product) error {
-	return nil
+	return repo
 }
 
-func (m *mockMongoRepo) DeleteProduct(id string) error {
-	return nil
+// mongoCatalog is what the secondary holds in the tests.
+var mongoCatalog = []domain.Product{
+	{Name: "kecap", Description: "asus", Price: 10000, Stock: 20},
+	{Name: "ritonga", Description: "New product description", Price: 49.99, Stock: 20},
+	{Name: "kiki", Description: "New product description", Price: 49.99, Stock: 20},
 }
 
 // Test untuk mendapatkan semua produk dari MongoDB
 func TestGetMongoDBProducts(t *testing.T) {
-	// Membuat mock repository MySQL dan MongoDB
-	mockMySQLRepository := &mockMySQLRepo{}
-	mockMongoRepository := &mockMongoRepo{}
-
-	// Membuat service dengan repository mock
-	productService := service.NewProductService(mockMySQLRepository, mockMongoRepository)
+	// Membuat service dengan repository memory
+	productService, err := service.NewProductService(memory.NewMemoryProductRepository(), seeded(t, mongoCatalog...))
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	// Membuat permintaan HTTP
 	req, err := http.NewRequest("GET", "/mongodb-products", nil)
@@ -107,7 +60,7 @@ func TestGetMongoDBProducts(t *testing.T) {
 	// Menggunakan httptest untuk membuat response recorder
 	rr := httptest.NewRecorder()
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		products, err := productService.GetMongoDBProducts()
+		products, err := productService.GetMongoDBProducts(tenant.WithTenant(r.Context(), shop))
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -121,7 +74,8 @@ func TestGetMongoDBProducts(t *testing.T) {
 	// Memeriksa apakah status kode yang dikembalikan adalah 200
 	assert.Equal(t, http.StatusOK, rr.Code)
 
-	// Memeriksa apakah hasil JSON sesuai dengan yang diharapkan
-	expected := `[{"_id":"66f268c84b7253868ad8312e","name":"kecap","description":"asus","price":10000,"stock":20},{"_id":"66f269094b7253868ad8312f","name":"ritonga","description":"New product description","price":49.99,"stock":20},{"_id":"66f28741deae451485368e74","name":"kiki","description":"New product description","price":49.99,"stock":20}]`
+	// Memeriksa apakah hasil JSON sesuai dengan yang diharapkan; baris
+	// repository memory membawa ObjectID kosong
+	expected := `[{"_id":"000000000000000000000000","id":"1","tenant_id":"shop-a","name":"kecap","description":"asus","price":10000,"stock":20},{"_id":"000000000000000000000000","id":"2","tenant_id":"shop-a","name":"ritonga","description":"New product description","price":49.99,"stock":20},{"_id":"000000000000000000000000","id":"3","tenant_id":"shop-a","name":"kiki","description":"New product description","price":49.99,"stock":20}]`
 	assert.JSONEq(t, expected, rr.Body.String())
 }
@@ -0,0 +1,147 @@
+// internal/auth/apikey.go
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidAPIKey is returned when a key is unknown or revoked.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// apiKeyPrefix makes keys easy to recognise in logs and secret scanners.
+const apiKeyPrefix = "pm_"
+
+// APIKeyStore resolves hashed API keys to principals.
+type APIKeyStore interface {
+	Lookup(ctx context.Context, keyHash string) (*Principal, error)
+}
+
+// HashAPIKey returns the hex SHA-256 of a plaintext key. Keys are random
+// 256-bit values, so a fast hash is sufficient and allows indexed lookup.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateAPIKey returns a new random plaintext key.
+func GenerateAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(b), nil
+}
+
+const apiKeysTable = `
+CREATE TABLE IF NOT EXISTS api_keys (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	key_hash CHAR(64) NOT NULL,
+	role VARCHAR(20) NOT NULL,
+	tenant_id VARCHAR(64) NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	revoked_at TIMESTAMP NULL,
+	UNIQUE KEY uq_api_keys_key_hash (key_hash)
+)`
+
+// MySQLAPIKeyStore keeps API key hashes in the api_keys table. Only the
+// hash is stored; the plaintext is shown once when the key is created.
+type MySQLAPIKeyStore struct {
+	db *sql.DB
+}
+
+// NewMySQLAPIKeyStore creates a store backed by db.
+func NewMySQLAPIKeyStore(db *sql.DB) *MySQLAPIKeyStore {
+	return &MySQLAPIKeyStore{db: db}
+}
+
+// EnsureSchema creates the api_keys table if it does not exist and adds
+// the tenant_id column to tables created before tenants existed.
+func (s *MySQLAPIKeyStore) EnsureSchema(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, apiKeysTable); err != nil {
+		return err
+	}
+	var n int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = 'api_keys' AND column_name = 'tenant_id'`).Scan(&n)
+	if err != nil || n > 0 {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, "ALTER TABLE api_keys ADD COLUMN tenant_id VARCHAR(64) NULL")
+	return err
+}
+
+const sqliteAPIKeysTable = `
+CREATE TABLE IF NOT EXISTS api_keys (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	key_hash TEXT NOT NULL UNIQUE,
+	role TEXT NOT NULL,
+	tenant_id TEXT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	revoked_at DATETIME NULL
+)`
+
+// SQLiteAPIKeyStore is the API key store of the single-file embedded mode.
+// Only the schema differs from MySQL.
+type SQLiteAPIKeyStore struct {
+	*MySQLAPIKeyStore
+}
+
+// NewSQLiteAPIKeyStore creates a store backed by a SQLite db.
+func NewSQLiteAPIKeyStore(db *sql.DB) *SQLiteAPIKeyStore {
+	return &SQLiteAPIKeyStore{NewMySQLAPIKeyStore(db)}
+}
+
+// EnsureSchema creates the api_keys table if it does not exist.
+func (s *SQLiteAPIKeyStore) EnsureSchema(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, sqliteAPIKeysTable)
+	return err
+}
+
+// Create stores a new key for name with the given role and returns the
+// plaintext key. An empty tenantID lets the key act on any tenant.
+func (s *MySQLAPIKeyStore) Create(ctx context.Context, name string, role Role, tenantID string) (string, error) {
+	key, err := GenerateAPIKey()
+	if err != nil {
+		return "", err
+	}
+	var tenant sql.NullString
+	if tenantID != "" {
+		tenant = sql.NullString{String: tenantID, Valid: true}
+	}
+	_, err = s.db.ExecContext(ctx,
+		"INSERT INTO api_keys (name, key_hash, role, tenant_id) VALUES (?, ?, ?, ?)",
+		name, HashAPIKey(key), string(role), tenant)
+	if err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Lookup implements APIKeyStore.
+func (s *MySQLAPIKeyStore) Lookup(ctx context.Context, keyHash string) (*Principal, error) {
+	var name, role string
+	var tenant sql.NullString
+	err := s.db.QueryRowContext(ctx,
+		"SELECT name, role, tenant_id FROM api_keys WHERE key_hash = ? AND revoked_at IS NULL",
+		keyHash).Scan(&name, &role, &tenant)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := ParseRole(role)
+	if err != nil {
+		return nil, fmt.Errorf("api key %q: %w", name, err)
+	}
+	return &Principal{Subject: "apikey:" + name, Role: parsed, Method: MethodAPIKey, TenantID: tenant.String}, nil
+}
@@ -0,0 +1,157 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"product-management/internal/auth"
+	"product-management/internal/config"
+	"product-management/internal/handler"
+	"product-management/internal/repository/sqlite"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+const secret = "test-secret"
+
+type stubKeyStore map[string]*auth.Principal
+
+func (s stubKeyStore) Lookup(ctx context.Context, keyHash string) (*auth.Principal, error) {
+	if p, ok := s[keyHash]; ok {
+		return p, nil
+	}
+	return nil, auth.ErrInvalidAPIKey
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, role string) string {
+	token := jwt.NewWithClaims(method, auth.Claims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "user-1",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+	return signed
+}
+
+func newApp(t *testing.T, cfg config.AuthConfig, keys auth.APIKeyStore) *fiber.App {
+	verifier, err := auth.NewJWTVerifier(cfg)
+	assert.NoError(t, err)
+
+	app := fiber.New()
+	app.Use(auth.NewAuthenticator(verifier, keys).Middleware())
+	ok := func(c *fiber.Ctx) error {
+		return c.SendString(auth.SubjectFromContext(c.UserContext()))
+	}
+	app.Get("/products", auth.Require(auth.RoleViewer), ok)
+	app.Delete("/products/:id", auth.Require(auth.RoleAdmin), ok)
+	return app
+}
+
+func do(t *testing.T, app *fiber.App, method, path string, headers map[string]string) int {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestRoleEnforcement(t *testing.T) {
+	app := newApp(t, config.AuthConfig{JWTSecret: secret}, nil)
+	viewer := map[string]string{"Authorization": "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(secret), "viewer")}
+	admin := map[string]string{"Authorization": "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(secret), "admin")}
+
+	assert.Equal(t, fiber.StatusUnauthorized, do(t, app, "GET", "/products", nil))
+	assert.Equal(t, fiber.StatusOK, do(t, app, "GET", "/products", viewer))
+	assert.Equal(t, fiber.StatusForbidden, do(t, app, "DELETE", "/products/1", viewer))
+	assert.Equal(t, fiber.StatusOK, do(t, app, "DELETE", "/products/1", admin))
+}
+
+func TestRejectsForgedAndUnknownTokens(t *testing.T) {
+	app := newApp(t, config.AuthConfig{JWTSecret: secret}, nil)
+
+	forged := sign(t, jwt.SigningMethodHS256, []byte("other-secret"), "admin")
+	assert.Equal(t, fiber.StatusUnauthorized, do(t, app, "GET", "/products", map[string]string{"Authorization": "Bearer " + forged}))
+
+	badRole := sign(t, jwt.SigningMethodHS256, []byte(secret), "root")
+	assert.Equal(t, fiber.StatusUnauthorized, do(t, app, "GET", "/products", map[string]string{"Authorization": "Bearer " + badRole}))
+}
+
+func TestRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwt.pub")
+	assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+	app := newApp(t, config.AuthConfig{JWTPublicKeyFile: path}, nil)
+	token := sign(t, jwt.SigningMethodRS256, key, "viewer")
+	assert.Equal(t, fiber.StatusOK, do(t, app, "GET", "/products", map[string]string{"Authorization": "Bearer " + token}))
+
+	// An HS256 token must not be accepted when only RS256 is configured.
+	hs := sign(t, jwt.SigningMethodHS256, []byte(secret), "viewer")
+	assert.Equal(t, fiber.StatusUnauthorized, do(t, app, "GET", "/products", map[string]string{"Authorization": "Bearer " + hs}))
+}
+
+func TestAPIKey(t *testing.T) {
+	key, err := auth.GenerateAPIKey()
+	assert.NoError(t, err)
+	store := stubKeyStore{auth.HashAPIKey(key): {Subject: "apikey:pos", Role: auth.RoleEditor, Method: auth.MethodAPIKey}}
+	app := newApp(t, config.AuthConfig{JWTSecret: secret}, store)
+
+	assert.Equal(t, fiber.StatusOK, do(t, app, "GET", "/products", map[string]string{auth.APIKeyHeader: key}))
+	assert.Equal(t, fiber.StatusForbidden, do(t, app, "DELETE", "/products/1", map[string]string{auth.APIKeyHeader: key}))
+	assert.Equal(t, fiber.StatusUnauthorized, do(t, app, "GET", "/products", map[string]string{auth.APIKeyHeader: "pm_wrong"}))
+}
+
+type failingKeyStore struct{}
+
+func (failingKeyStore) Lookup(ctx context.Context, keyHash string) (*auth.Principal, error) {
+	return nil, errors.New("dial tcp: connection refused")
+}
+
+func TestAPIKeyStoreFailureIsUnavailable(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(auth.NewAuthenticator(nil, failingKeyStore{}).Middleware())
+	app.Get("/products", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	assert.Equal(t, fiber.StatusServiceUnavailable, do(t, app, "GET", "/products", map[string]string{auth.APIKeyHeader: "pm_key"}))
+	assert.Equal(t, fiber.StatusUnauthorized, do(t, app, "GET", "/products", nil))
+}
+
+func TestSQLiteAPIKeyStore(t *testing.T) {
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "produk.db"))
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	store := auth.NewSQLiteAPIKeyStore(db)
+	ctx := context.Background()
+	assert.NoError(t, store.EnsureSchema(ctx))
+	assert.NoError(t, store.EnsureSchema(ctx))
+
+	key, err := store.Create(ctx, "pos", auth.RoleEditor, "shop-1")
+	assert.NoError(t, err)
+
+	principal, err := store.Lookup(ctx, auth.HashAPIKey(key))
+	assert.NoError(t, err)
+	assert.Equal(t, "apikey:pos", principal.Subject)
+	assert.Equal(t, auth.RoleEditor, principal.Role)
+	assert.Equal(t, "shop-1", principal.TenantID)
+
+	_, err = store.Lookup(ctx, auth.HashAPIKey("pm_unknown"))
+	assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+}
@@ -0,0 +1,93 @@
+// internal/auth/jwt.go
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+
+	"product-management/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims are the JWT claims understood by the API. The role claim must hold
+// one of viewer, editor or admin; tenant_id optionally binds the token to a
+// single tenant.
+type Claims struct {
+	Role     string `json:"role"`
+	TenantID string `json:"tenant_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// JWTVerifier validates HS256 and RS256 tokens against locally configured
+// keys. A token is only accepted with the algorithm whose key is configured.
+type JWTVerifier struct {
+	hmacSecret []byte
+	rsaKey     *rsa.PublicKey
+	parser     *jwt.Parser
+}
+
+// NewJWTVerifier creates a verifier from the configuration. At least one of
+// the HMAC secret or RSA public key file must be set.
+func NewJWTVerifier(cfg config.AuthConfig) (*JWTVerifier, error) {
+	v := &JWTVerifier{}
+	var methods []string
+
+	if cfg.JWTSecret != "" {
+		v.hmacSecret = []byte(cfg.JWTSecret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if cfg.JWTPublicKeyFile != "" {
+		pem, err := os.ReadFile(cfg.JWTPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read JWT public key: %w", err)
+		}
+		v.rsaKey, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("parse JWT public key: %w", err)
+		}
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("no JWT key configured: set JWT_SECRET or JWT_PUBLIC_KEY_FILE")
+	}
+
+	opts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+	if cfg.JWTIssuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.JWTIssuer))
+	}
+	if cfg.JWTAudience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.JWTAudience))
+	}
+	v.parser = jwt.NewParser(opts...)
+	return v, nil
+}
+
+// Verify parses the token and returns the principal it describes.
+func (v *JWTVerifier) Verify(token string) (*Principal, error) {
+	var claims Claims
+	_, err := v.parser.ParseWithClaims(token, &claims, v.key)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+	role, err := ParseRole(claims.Role)
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{Subject: claims.Subject, Role: role, Method: MethodJWT, TenantID: claims.TenantID}, nil
+}
+
+func (v *JWTVerifier) key(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return v.hmacSecret, nil
+	case *jwt.SigningMethodRSA:
+		return v.rsaKey, nil
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+}
@@ -0,0 +1,91 @@
+// internal/auth/middleware.go
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"product-management/internal/domain"
+	"product-management/internal/problem"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIKeyHeader carries an API key as an alternative to a bearer token.
+const APIKeyHeader = "X-API-Key"
+
+// Authenticator resolves the caller of a request from a bearer JWT or an
+// API key. Either source may be nil to disable it.
+type Authenticator struct {
+	jwt  *JWTVerifier
+	keys APIKeyStore
+}
+
+// NewAuthenticator creates an Authenticator.
+func NewAuthenticator(jwt *JWTVerifier, keys APIKeyStore) *Authenticator {
+	return &Authenticator{jwt: jwt, keys: keys}
+}
+
+// Middleware authenticates the request and stores the principal in the
+// user context and in c.Locals("principal"). Requests without valid
+// credentials are rejected with 401; those that cannot be checked because
+// the API key store is unavailable fail with 503.
+func (a *Authenticator) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal, err := a.Authenticate(c.UserContext(), c.Get(APIKeyHeader), c.Get(fiber.HeaderAuthorization))
+		if errors.Is(err, domain.ErrUnavailable) {
+			return err
+		}
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "Authentication failed", "error", err)
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="products"`)
+			return problem.Respond(c, fiber.StatusUnauthorized, "Unauthorized")
+		}
+
+		c.Locals("principal", principal)
+		c.SetUserContext(WithPrincipal(c.UserContext(), principal))
+		return c.Next()
+	}
+}
+
+// Authenticate resolves a principal from an API key or, when no key is
+// given, from an Authorization header value. It is shared by the HTTP
+// middleware and the gRPC interceptors. API key store failures are
+// domain.ErrUnavailable, as they say nothing about the credentials.
+func (a *Authenticator) Authenticate(ctx context.Context, apiKey, authorization string) (*Principal, error) {
+	if apiKey != "" {
+		if a.keys == nil {
+			return nil, errors.New("API keys are not enabled")
+		}
+		principal, err := a.keys.Lookup(ctx, HashAPIKey(apiKey))
+		if err != nil && !errors.Is(err, ErrInvalidAPIKey) {
+			return nil, domain.NewError(domain.ErrUnavailable, "API key store is unavailable", err)
+		}
+		return principal, err
+	}
+
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok || token == "" {
+		return nil, errors.New("missing credentials")
+	}
+	if a.jwt == nil {
+		return nil, errors.New("JWT authentication is not enabled")
+	}
+	return a.jwt.Verify(token)
+}
+
+// Require rejects principals below the given role with 403. It must run
+// after Middleware.
+func Require(role Role) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal := PrincipalFromContext(c.UserContext())
+		if principal == nil {
+			return problem.Respond(c, fiber.StatusUnauthorized, "Unauthorized")
+		}
+		if !principal.Role.Allows(role) {
+			return problem.Respond(c, fiber.StatusForbidden, "Insufficient role: "+string(role)+" required")
+		}
+		return c.Next()
+	}
+}
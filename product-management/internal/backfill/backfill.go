@@ -0,0 +1,344 @@
+// internal/backfill/backfill.go
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"product-management/internal/domain"
+	"product-management/internal/tenant"
+	"time"
+)
+
+// Source is a repository products are copied from, in ID order.
+type Source interface {
+	domain.ProductSearcher
+}
+
+// Target is a repository products are copied to and verified against.
+type Target interface {
+	domain.ProductUpserter
+	domain.ProductSearcher
+}
+
+// Options tune a backfill.
+type Options struct {
+	// From and To name the backends; a checkpoint only resumes the same pair.
+	From, To string
+	// BatchSize is how many products are read and upserted at a time.
+	BatchSize int
+	// Rate caps the copy and verification at this many products per
+	// second. Zero does not throttle.
+	Rate float64
+	// CheckpointPath is where progress is saved after every batch. Empty
+	// disables checkpointing.
+	CheckpointPath string
+	// ReportInterval is how often progress is logged.
+	ReportInterval time.Duration
+}
+
+// Checkpoint is the progress of a backfill, saved after every batch.
+type Checkpoint struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// After is the source ID of the last product copied.
+	After     string    `json:"after"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Mismatch is a source product the target does not hold as it is.
+type Mismatch struct {
+	TenantID string
+	Name     string
+	Reason   string
+}
+
+// Report summarizes a backfill.
+type Report struct {
+	// ResumedAfter is the source ID the copy resumed after, if any.
+	ResumedAfter string
+	Copied       int
+	Duration     time.Duration
+	// Verified counts the source products checked against the target.
+	Verified   int
+	Mismatches []Mismatch
+}
+
+// maxMismatches bounds the mismatches kept for the report; all are logged.
+const maxMismatches = 100
+
+// Backfill copies every product of every tenant from a source repository
+// to a target one. Products are matched by tenant and name, so a copy can be
+// repeated or resumed without creating duplicates.
+type Backfill struct {
+	source Source
+	target Target
+	opts   Options
+}
+
+// New creates a Backfill.
+func New(source Source, target Target, opts Options) *Backfill {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	if opts.ReportInterval <= 0 {
+		opts.ReportInterval = 10 * time.Second
+	}
+	return &Backfill{source: source, target: target, opts: opts}
+}
+
+// Run copies the products, resuming from the checkpoint when there is one,
+// and then verifies the target. The checkpoint is removed once the copy is
+// complete. An interrupted run returns the context's error with the
+// progress saved.
+func (b *Backfill) Run(ctx context.Context) (Report, error) {
+	var report Report
+	after, err := b.loadCheckpoint()
+	if err != nil {
+		return report, err
+	}
+	report.ResumedAfter = after
+	if after != "" {
+		slog.InfoContext(ctx, "Resuming backfill from checkpoint", "from", b.opts.From, "to", b.opts.To, "after", after)
+	}
+
+	start := time.Now()
+	report.Copied, err = b.copy(ctx, after)
+	report.Duration = time.Since(start)
+	if err != nil {
+		return report, err
+	}
+	slog.InfoContext(ctx, "Backfill copy complete", "from", b.opts.From, "to", b.opts.To,
+		"copied", report.Copied, "duration", report.Duration.Round(time.Millisecond), "per_second", perSecond(report.Copied, report.Duration))
+	if b.opts.CheckpointPath != "" {
+		if err := os.Remove(b.opts.CheckpointPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return report, fmt.Errorf("remove checkpoint: %w", err)
+		}
+	}
+
+	report.Verified, report.Mismatches, err = b.Verify(ctx)
+	return report, err
+}
+
+// copy upserts source pages into the target, starting after the source ID
+// after, and returns the number of products copied. Pages are read by the
+// last ID copied, so products created or deleted meanwhile shift nothing.
+func (b *Backfill) copy(ctx context.Context, after string) (int, error) {
+	// Background work spans every tenant; upserts keep each product's.
+	all := tenant.WithAllTenants(ctx)
+	pacer := b.pacer()
+	copied := 0
+	lastReport := time.Now()
+	for {
+		page, err := b.source.SearchProducts(all, domain.ProductFilter{}, domain.Page{After: after, Limit: b.opts.BatchSize})
+		if err != nil {
+			return copied, fmt.Errorf("read %s after %q: %w", b.opts.From, after, err)
+		}
+		if len(page) == 0 {
+			return copied, nil
+		}
+		batch := make([]domain.Product, len(page))
+		for i, p := range page {
+			// IDs are generated by each backend; the name identifies the
+			// product within its tenant.
+			batch[i] = domain.Product{ID: p.ID, TenantID: p.TenantID, Name: p.Name, Description: p.Description, Price: p.Price, Stock: p.Stock}
+		}
+		if err := b.target.UpsertProducts(all, batch); err != nil {
+			return copied, fmt.Errorf("write %s after %q: %w", b.opts.To, after, err)
+		}
+		after = page[len(page)-1].Key()
+		copied += len(page)
+		if err := b.saveCheckpoint(after); err != nil {
+			return copied, err
+		}
+
+		if now := time.Now(); now.Sub(lastReport) >= b.opts.ReportInterval {
+			slog.InfoContext(ctx, "Backfill progress", "from", b.opts.From, "to", b.opts.To,
+				"after", after, "copied", copied, "per_second", pacer.achieved(copied))
+			lastReport = now
+		}
+		if err := pacer.wait(ctx, copied); err != nil {
+			return copied, err
+		}
+	}
+}
+
+// Verify checks that the target holds every source product with the same
+// description, price and stock. It returns how many products were checked
+// and the first mismatches found. Each source page is looked up in the
+// target with one query.
+func (b *Backfill) Verify(ctx context.Context) (int, []Mismatch, error) {
+	all := tenant.WithAllTenants(ctx)
+	pacer := b.pacer()
+	var mismatches []Mismatch
+	checked, found := 0, 0
+	for after := ""; ; {
+		page, err := b.source.SearchProducts(all, domain.ProductFilter{}, domain.Page{After: after, Limit: b.opts.BatchSize})
+		if err != nil {
+			return checked, mismatches, fmt.Errorf("read %s after %q: %w", b.opts.From, after, err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		copies, err := b.copies(all, page)
+		if err != nil {
+			return checked, mismatches, err
+		}
+		for _, p := range page {
+			checked++
+			reason := compare(p, copies[key{p.TenantID, p.Name}])
+			if reason == "" {
+				continue
+			}
+			found++
+			slog.WarnContext(ctx, "Backfill mismatch", "tenant_id", p.TenantID, "name", p.Name, "reason", reason)
+			if len(mismatches) < maxMismatches {
+				mismatches = append(mismatches, Mismatch{TenantID: p.TenantID, Name: p.Name, Reason: reason})
+			}
+		}
+		after = page[len(page)-1].Key()
+		if err := pacer.wait(ctx, checked); err != nil {
+			return checked, mismatches, err
+		}
+	}
+	slog.InfoContext(ctx, "Backfill verification complete", "from", b.opts.From, "to", b.opts.To,
+		"checked", checked, "mismatches", found)
+	return checked, mismatches, nil
+}
+
+// key identifies a product across backends.
+type key struct{ tenantID, name string }
+
+// copies looks up the target's products named like those of page, in any
+// tenant, with one query.
+func (b *Backfill) copies(ctx context.Context, page []domain.Product) (map[key]*domain.Product, error) {
+	names := make([]string, len(page))
+	for i, p := range page {
+		names[i] = p.Name
+	}
+	found, err := b.target.SearchProducts(ctx, domain.ProductFilter{Names: names}, domain.Page{})
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", b.opts.To, err)
+	}
+	copies := make(map[key]*domain.Product, len(found))
+	for i := range found {
+		copies[key{found[i].TenantID, found[i].Name}] = &found[i]
+	}
+	return copies, nil
+}
+
+// compare describes how the target's copy c differs from p, or returns ""
+// when it matches.
+func compare(p domain.Product, c *domain.Product) string {
+	switch {
+	case c == nil:
+		return "missing"
+	case c.Description != p.Description:
+		return "description differs"
+	case c.Price != p.Price:
+		return fmt.Sprintf("price is %v, want %v", c.Price, p.Price)
+	case c.Stock != p.Stock:
+		return fmt.Sprintf("stock is %d, want %d", c.Stock, p.Stock)
+	}
+	return ""
+}
+
+// loadCheckpoint returns the source ID to resume after.
+func (b *Backfill) loadCheckpoint() (string, error) {
+	if b.opts.CheckpointPath == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(b.opts.CheckpointPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read checkpoint: %w", err)
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return "", fmt.Errorf("parse checkpoint %s: %w", b.opts.CheckpointPath, err)
+	}
+	if cp.From != b.opts.From || cp.To != b.opts.To {
+		return "", fmt.Errorf("checkpoint %s is for a backfill from %s to %s", b.opts.CheckpointPath, cp.From, cp.To)
+	}
+	return cp.After, nil
+}
+
+// saveCheckpoint replaces the checkpoint file atomically, so a crash leaves
+// either the previous or the new position.
+func (b *Backfill) saveCheckpoint(after string) error {
+	if b.opts.CheckpointPath == "" {
+		return nil
+	}
+	data, err := json.Marshal(Checkpoint{From: b.opts.From, To: b.opts.To, After: after, UpdatedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(b.opts.CheckpointPath), ".checkpoint-*")
+	if err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), b.opts.CheckpointPath); err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// pacer spaces batches so that, on average, no more than Rate products
+// are processed per second since it was created.
+type pacer struct {
+	rate  float64
+	start time.Time
+}
+
+func (b *Backfill) pacer() pacer {
+	return pacer{rate: b.opts.Rate, start: time.Now()}
+}
+
+// wait sleeps until done products are within the rate.
+func (p pacer) wait(ctx context.Context, done int) error {
+	if p.rate <= 0 {
+		return ctx.Err()
+	}
+	due := p.start.Add(time.Duration(float64(done) / p.rate * float64(time.Second)))
+	if d := time.Until(due); d > 0 {
+		return sleep(ctx, d)
+	}
+	return ctx.Err()
+}
+
+// achieved returns the products per second since the start.
+func (p pacer) achieved(done int) float64 {
+	return perSecond(done, time.Since(p.start))
+}
+
+func perSecond(n int, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(int(float64(n)/d.Seconds()*10)) / 10
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,201 @@
+package backfill_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"product-management/internal/backfill"
+	"product-management/internal/domain"
+	"product-management/internal/repository/memory"
+	"product-management/internal/repository/mysql"
+	"product-management/internal/repository/mysql/mysqltest"
+	"product-management/internal/repository/sqlite"
+	"product-management/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seed creates n products spread over two tenants.
+func seed(t *testing.T, repo domain.ProductRepository, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		ctx := tenant.WithTenant(context.Background(), fmt.Sprintf("shop-%d", i%2))
+		require.NoError(t, repo.Create(ctx, &domain.Product{
+			Name: fmt.Sprintf("product-%d", i), Description: "about", Price: float64(i + 1), Stock: i,
+		}))
+	}
+}
+
+func count(t *testing.T, repo domain.ProductRepository) int {
+	t.Helper()
+	all, err := repo.GetAllProducts(tenant.WithAllTenants(context.Background()))
+	require.NoError(t, err)
+	return len(all)
+}
+
+func TestCopiesEveryTenantBetweenBackends(t *testing.T) {
+	source := mysql.NewMySQLProductRepository(mysqltest.New(t))
+	require.NoError(t, source.EnsureSchema(context.Background(), "default"))
+	seed(t, source, 7)
+
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "produk.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	target := sqlite.NewSQLiteProductRepository(db)
+	require.NoError(t, target.EnsureSchema(context.Background(), "default"))
+	// A product the target already has is updated, not duplicated.
+	require.NoError(t, target.Create(tenant.WithTenant(context.Background(), "shop-0"),
+		&domain.Product{Name: "product-0", Description: "stale", Price: 99, Stock: 99}))
+
+	opts := backfill.Options{From: "mysql", To: "sqlite", BatchSize: 3, CheckpointPath: filepath.Join(t.TempDir(), "cp.json")}
+	report, err := backfill.New(source, target, opts).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 7, report.Copied)
+	assert.Equal(t, 7, report.Verified)
+	assert.Empty(t, report.Mismatches)
+	assert.Equal(t, 7, count(t, target))
+	assert.NoFileExists(t, opts.CheckpointPath, "a finished copy leaves no checkpoint")
+
+	shop1, err := target.GetAllProducts(tenant.WithTenant(context.Background(), "shop-1"))
+	require.NoError(t, err)
+	assert.Len(t, shop1, 3)
+
+	// Copying again changes nothing.
+	report, err = backfill.New(source, target, opts).Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, report.Mismatches)
+	assert.Equal(t, 7, count(t, target))
+}
+
+// flakyTarget fails every upsert after the first batches.
+type flakyTarget struct {
+	*memory.MemoryProductRepository
+	batches int
+}
+
+func (f *flakyTarget) UpsertProducts(ctx context.Context, products []domain.Product) error {
+	if f.batches == 0 {
+		return errors.New("connection reset by peer")
+	}
+	f.batches--
+	return f.MemoryProductRepository.UpsertProducts(ctx, products)
+}
+
+func TestResumesFromCheckpoint(t *testing.T) {
+	source := memory.NewMemoryProductRepository()
+	seed(t, source, 10)
+	target := &flakyTarget{MemoryProductRepository: memory.NewMemoryProductRepository(), batches: 2}
+	opts := backfill.Options{From: "memory", To: "flaky", BatchSize: 3, CheckpointPath: filepath.Join(t.TempDir(), "cp.json")}
+
+	report, err := backfill.New(source, target, opts).Run(context.Background())
+	assert.ErrorContains(t, err, "connection reset by peer")
+	assert.Equal(t, 6, report.Copied)
+	data, err := os.ReadFile(opts.CheckpointPath)
+	require.NoError(t, err)
+	var cp backfill.Checkpoint
+	require.NoError(t, json.Unmarshal(data, &cp))
+	assert.Equal(t, "6", cp.After)
+
+	// Deleting a copied product does not shift where the copy resumes.
+	require.NoError(t, source.DeleteProduct(tenant.WithTenant(context.Background(), "shop-0"), "1"))
+	target.batches = 100
+	report, err = backfill.New(source, target, opts).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "6", report.ResumedAfter)
+	assert.Equal(t, 4, report.Copied)
+	assert.Equal(t, 9, report.Verified)
+	assert.Empty(t, report.Mismatches)
+	assert.Equal(t, 10, count(t, target))
+
+	// A checkpoint only resumes the backfill it was written by.
+	require.NoError(t, os.WriteFile(opts.CheckpointPath, data, 0o600))
+	opts.To = "other"
+	_, err = backfill.New(source, target, opts).Run(context.Background())
+	assert.ErrorContains(t, err, "is for a backfill from memory to flaky")
+}
+
+func TestVerifyReportsMismatches(t *testing.T) {
+	source := memory.NewMemoryProductRepository()
+	seed(t, source, 4)
+	target := memory.NewMemoryProductRepository()
+	b := backfill.New(source, target, backfill.Options{From: "memory", To: "memory"})
+	_, err := b.Run(context.Background())
+	require.NoError(t, err)
+
+	ctx := tenant.WithTenant(context.Background(), "shop-1")
+	products, err := target.GetAllProducts(ctx)
+	require.NoError(t, err)
+	changed := products[0]
+	changed.Stock = 42
+	require.NoError(t, target.UpdateProduct(ctx, changed.ID, &changed))
+	require.NoError(t, target.DeleteProduct(ctx, products[1].ID))
+
+	checked, mismatches, err := b.Verify(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 4, checked)
+	assert.Equal(t, []backfill.Mismatch{
+		{TenantID: "shop-1", Name: "product-1", Reason: "stock is 42, want 1"},
+		{TenantID: "shop-1", Name: "product-3", Reason: "missing"},
+	}, mismatches)
+}
+
+// countingTarget counts the searches of a memory target.
+type countingTarget struct {
+	*memory.MemoryProductRepository
+	searches int
+}
+
+func (c *countingTarget) SearchProducts(ctx context.Context, filter domain.ProductFilter, page domain.Page) ([]domain.Product, error) {
+	c.searches++
+	return c.MemoryProductRepository.SearchProducts(ctx, filter, page)
+}
+
+func TestVerifyLooksUpEachPageWithOneQuery(t *testing.T) {
+	source := memory.NewMemoryProductRepository()
+	seed(t, source, 7)
+	target := &countingTarget{MemoryProductRepository: memory.NewMemoryProductRepository()}
+	b := backfill.New(source, target, backfill.Options{From: "memory", To: "memory", BatchSize: 3})
+	_, err := b.Run(context.Background())
+	require.NoError(t, err)
+
+	target.searches = 0
+	checked, mismatches, err := b.Verify(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 7, checked)
+	assert.Empty(t, mismatches)
+	assert.Equal(t, 3, target.searches)
+}
+
+func TestRateThrottlesTheCopy(t *testing.T) {
+	source := memory.NewMemoryProductRepository()
+	seed(t, source, 20)
+	target := memory.NewMemoryProductRepository()
+
+	start := time.Now()
+	report, err := backfill.New(source, target, backfill.Options{BatchSize: 5, Rate: 200}).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 20, report.Copied)
+	// 20 products at 200 per second take at least 100ms to copy, and as
+	// long again to verify.
+	assert.GreaterOrEqual(t, report.Duration, 90*time.Millisecond)
+	assert.GreaterOrEqual(t, time.Since(start), 180*time.Millisecond)
+}
+
+func TestCancelledBackfillKeepsItsCheckpoint(t *testing.T) {
+	source := memory.NewMemoryProductRepository()
+	seed(t, source, 10)
+	opts := backfill.Options{From: "memory", To: "memory", BatchSize: 2, Rate: 20, CheckpointPath: filepath.Join(t.TempDir(), "cp.json")}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	report, err := backfill.New(source, memory.NewMemoryProductRepository(), opts).Run(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, report.Copied, 10)
+	assert.FileExists(t, opts.CheckpointPath)
+}
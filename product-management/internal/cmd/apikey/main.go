@@ -0,0 +1,63 @@
+// internal/cmd/apikey/main.go
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"product-management/internal/auth"
+	"product-management/internal/config"
+	"product-management/internal/repository/sqlite"
+)
+
+// apikey creates an API key in MySQL, or in the SQLite file when
+// EMBEDDED_MODE is set, and prints the plaintext once:
+//
+//	go run ./internal/cmd/apikey -name pos-sync -role editor -tenant shop-1
+func main() {
+	name := flag.String("name", "", "name of the client the key is issued to")
+	roleName := flag.String("role", string(auth.RoleViewer), "role granted to the key: viewer, editor or admin")
+	tenantID := flag.String("tenant", "", "tenant the key is bound to; empty allows any tenant")
+	flag.Parse()
+
+	role, err := auth.ParseRole(*roleName)
+	if err != nil || *name == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	repoConfig := config.LoadRepositoryConfig()
+	var db *sql.DB
+	if repoConfig.Embedded {
+		db, err = sqlite.Open(repoConfig.SQLitePath)
+	} else {
+		db, err = config.ConnectMySQL()
+	}
+	if err != nil {
+		slog.Error("Failed to open database", "error", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	var store interface {
+		Create(ctx context.Context, name string, role auth.Role, tenantID string) (string, error)
+		EnsureSchema(ctx context.Context) error
+	} = auth.NewMySQLAPIKeyStore(db)
+	if repoConfig.Embedded {
+		store = auth.NewSQLiteAPIKeyStore(db)
+	}
+	ctx := context.Background()
+	if err := store.EnsureSchema(ctx); err != nil {
+		slog.Error("Failed to create api_keys table", "error", err)
+		os.Exit(1)
+	}
+	key, err := store.Create(ctx, *name, role, *tenantID)
+	if err != nil {
+		slog.Error("Failed to create API key", "error", err)
+		os.Exit(1)
+	}
+	fmt.Println(key)
+}
@@ -0,0 +1,162 @@
+// internal/cmd/backends.go
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"product-management/internal/config"
+	"product-management/internal/domain"
+	"product-management/internal/health"
+	"product-management/internal/metrics"
+	"product-management/internal/repository/memory"
+	"product-management/internal/repository/mongodb"
+	"product-management/internal/repository/mysql"
+	"product-management/internal/repository/postgres"
+	"product-management/internal/repository/sqlite"
+	"product-management/internal/shutdown"
+
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// productBackend is a repository that can prepare its own schema.
+type productBackend interface {
+	domain.ProductRepository
+	domain.ProductCounter
+	EnsureSchema(ctx context.Context, defaultTenant string) error
+}
+
+// connections opens each database at most once, on first use, and registers
+// its pool for metrics, health checks and shutdown.
+type connections struct {
+	shutdown *shutdown.Manager
+	metrics  *metrics.Metrics
+	checks   []health.Check
+
+	mysql    *sql.DB
+	postgres *sql.DB
+	sqlite   *sql.DB
+	mongo    *mongo.Client
+}
+
+func (c *connections) MySQL() (*sql.DB, error) {
+	if c.mysql != nil {
+		return c.mysql, nil
+	}
+	db, err := config.ConnectMySQL()
+	if err != nil {
+		return nil, fmt.Errorf("open MySQL: %w", err)
+	}
+	c.shutdown.Register(shutdown.PhaseConnections, "mysql", func(context.Context) error {
+		return db.Close()
+	})
+	c.metrics.MustRegister(collectors.NewDBStatsCollector(db, "produk"))
+	c.checks = append(c.checks, health.MySQLCheck(db))
+	c.mysql = db
+	return db, nil
+}
+
+func (c *connections) Postgres() (*sql.DB, error) {
+	if c.postgres != nil {
+		return c.postgres, nil
+	}
+	db, err := config.ConnectPostgres()
+	if err != nil {
+		return nil, fmt.Errorf("open PostgreSQL: %w", err)
+	}
+	c.shutdown.Register(shutdown.PhaseConnections, "postgres", func(context.Context) error {
+		return db.Close()
+	})
+	c.metrics.MustRegister(collectors.NewDBStatsCollector(db, "produk_postgres"))
+	c.checks = append(c.checks, health.PostgresCheck(db))
+	c.postgres = db
+	return db, nil
+}
+
+func (c *connections) SQLite(path string) (*sql.DB, error) {
+	if c.sqlite != nil {
+		return c.sqlite, nil
+	}
+	db, err := sqlite.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open SQLite: %w", err)
+	}
+	c.shutdown.Register(shutdown.PhaseConnections, "sqlite", func(context.Context) error {
+		return db.Close()
+	})
+	c.metrics.MustRegister(collectors.NewDBStatsCollector(db, "produk_sqlite"))
+	c.checks = append(c.checks, health.SQLiteCheck(db))
+	c.sqlite = db
+	return db, nil
+}
+
+func (c *connections) MongoDB() (*mongo.Client, error) {
+	if c.mongo != nil {
+		return c.mongo, nil
+	}
+	client, err := config.ConnectMongoDB()
+	if err != nil {
+		return nil, fmt.Errorf("connect to MongoDB: %w", err)
+	}
+	c.shutdown.Register(shutdown.PhaseConnections, "mongodb", client.Disconnect)
+	c.checks = append(c.checks, health.MongoDBCheck(client))
+	c.mongo = client
+	return client, nil
+}
+
+// backend returns the product repository of the named backend.
+func (c *connections) backend(name string, cfg config.RepositoryConfig) (productBackend, error) {
+	switch name {
+	case config.BackendNone:
+		return emptyRepository{}, nil
+	case config.BackendMemory:
+		return memory.NewMemoryProductRepository(), nil
+	case config.BackendSQLite:
+		db, err := c.SQLite(cfg.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		return sqlite.NewSQLiteProductRepository(db), nil
+	case config.BackendMySQL:
+		db, err := c.MySQL()
+		if err != nil {
+			return nil, err
+		}
+		return mysql.NewMySQLProductRepository(db), nil
+	case config.BackendPostgres:
+		db, err := c.Postgres()
+		if err != nil {
+			return nil, err
+		}
+		return postgres.NewPostgresProductRepository(db), nil
+	case config.BackendMongoDB:
+		client, err := c.MongoDB()
+		if err != nil {
+			return nil, err
+		}
+		return mongodb.NewMongoDBProductRepository(client.Database("productDB").Collection("products")), nil
+	}
+	return nil, fmt.Errorf("unknown product backend %q", name)
+}
+
+// emptyRepository fills an unused slot: writes are dropped and reads find
+// nothing, so the service behaves as if it had a single backend.
+type emptyRepository struct{}
+
+func (emptyRepository) EnsureSchema(context.Context, string) error    { return nil }
+func (emptyRepository) Create(context.Context, *domain.Product) error { return nil }
+func (emptyRepository) GetAllProducts(context.Context) ([]domain.Product, error) {
+	return nil, nil
+}
+func (emptyRepository) GetProductById(context.Context, string) (*domain.Product, error) {
+	return nil, domain.ProductNotFound(nil)
+}
+func (emptyRepository) GetProductsByIds(context.Context, []string) ([]domain.Product, error) {
+	return nil, nil
+}
+func (emptyRepository) UpdateProduct(context.Context, string, *domain.Product) error { return nil }
+func (emptyRepository) DeleteProduct(context.Context, string) error                  { return nil }
+func (emptyRepository) CountProducts(context.Context) (domain.ProductStats, error) {
+	return domain.ProductStats{}, nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"product-management/internal/backfill"
+	"product-management/internal/config"
+	"product-management/internal/domain"
+	"product-management/internal/repository/mongodb"
+	"product-management/internal/repository/mysql"
+	"product-management/internal/repository/postgres"
+	"product-management/internal/repository/sqlite"
+	"syscall"
+	"time"
+)
+
+// backfill copies every product from one backend to another, for example
+// to fill a new MongoDB from MySQL:
+//
+//	go run ./internal/cmd/backfill --from mysql --to mongodb --rate 2000
+//
+// Progress is checkpointed after every batch; running the same command
+// again after a crash or Ctrl-C resumes where it stopped. The copy ends with
+// a verification pass and the exit status is 1 if it finds mismatches.
+func main() {
+	if err := run(); err != nil {
+		slog.Error("Backfill failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// errMismatches fails a backfill whose verification found differences.
+var errMismatches = errors.New("target does not match source")
+
+// run parses the flags and runs the backfill. Returning instead of exiting
+// lets the deferred closes and signal cleanup run on failure too.
+func run() error {
+	from := flag.String("from", config.BackendMySQL, "backend to copy from: mysql, mongodb, postgres or sqlite")
+	to := flag.String("to", config.BackendMongoDB, "backend to copy to: mysql, mongodb, postgres or sqlite")
+	batchSize := flag.Int("batch", 500, "products read and upserted per batch")
+	rate := flag.Float64("rate", 0, "maximum products per second; 0 does not throttle")
+	checkpoint := flag.String("checkpoint", "", "checkpoint file (default backfill-<from>-<to>.json)")
+	restart := flag.Bool("restart", false, "ignore an existing checkpoint and copy from the start")
+	flag.Parse()
+
+	*from, *to = backendName(*from), backendName(*to)
+	if *from == *to {
+		return errors.New("--from and --to must differ")
+	}
+	if *checkpoint == "" {
+		*checkpoint = fmt.Sprintf("backfill-%s-%s.json", *from, *to)
+	}
+	if *restart {
+		if err := os.Remove(*checkpoint); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove checkpoint: %w", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	source, closeSource, err := open(*from)
+	if err != nil {
+		return fmt.Errorf("open source backend: %w", err)
+	}
+	defer closeSource()
+	target, closeTarget, err := open(*to)
+	if err != nil {
+		return fmt.Errorf("open target backend: %w", err)
+	}
+	defer closeTarget()
+
+	// The target may be new; give it the same schema the server would.
+	defaultTenant := config.LoadTenantConfig().Default
+	if defaultTenant == "" {
+		defaultTenant = "default"
+	}
+	if err := target.EnsureSchema(ctx, defaultTenant); err != nil {
+		return fmt.Errorf("prepare target schema: %w", err)
+	}
+
+	report, err := backfill.New(source, target, backfill.Options{
+		From:           *from,
+		To:             *to,
+		BatchSize:      *batchSize,
+		Rate:           *rate,
+		CheckpointPath: *checkpoint,
+		ReportInterval: 5 * time.Second,
+	}).Run(ctx)
+	if err != nil {
+		return fmt.Errorf("stopped; run the command again to resume: %w", err)
+	}
+	if len(report.Mismatches) > 0 {
+		slog.Error("Target does not match source", "checked", report.Verified, "first_mismatches", report.Mismatches)
+		return errMismatches
+	}
+	slog.Info("Backfill complete", "copied", report.Copied, "resumed_after", report.ResumedAfter, "verified", report.Verified)
+	return nil
+}
+
+// backendName accepts "mongo" for the MongoDB backend.
+func backendName(name string) string {
+	if name == "mongo" {
+		return config.BackendMongoDB
+	}
+	return name
+}
+
+// backend is a product repository the backfill can read, write and
+// prepare.
+type backend interface {
+	domain.ProductSearcher
+	domain.ProductUpserter
+	EnsureSchema(ctx context.Context, defaultTenant string) error
+}
+
+// open connects to the named backend with the server's settings.
+func open(name string) (backend, func(), error) {
+	switch name {
+	case config.BackendMySQL:
+		db, err := config.ConnectMySQL()
+		if err != nil {
+			return nil, nil, err
+		}
+		return mysql.NewMySQLProductRepository(db), func() { db.Close() }, nil
+	case config.BackendPostgres:
+		db, err := config.ConnectPostgres()
+		if err != nil {
+			return nil, nil, err
+		}
+		return postgres.NewPostgresProductRepository(db), func() { db.Close() }, nil
+	case config.BackendSQLite:
+		db, err := sqlite.Open(config.LoadRepositoryConfig().SQLitePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return sqlite.NewSQLiteProductRepository(db), func() { db.Close() }, nil
+	case config.BackendMongoDB:
+		client, err := config.ConnectMongoDB()
+		if err != nil {
+			return nil, nil, err
+		}
+		repo := mongodb.NewMongoDBProductRepository(client.Database("productDB").Collection("products"))
+		return repo, func() { client.Disconnect(context.Background()) }, nil
+	}
+	return nil, nil, fmt.Errorf("unknown product backend %q", name)
+}
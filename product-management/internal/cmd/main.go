@@ -3,49 +3,503 @@ package main
 import (
 	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"product-management/internal/auth"
+	"product-management/internal/config"
+	"product-management/internal/cutover"
+	"product-management/internal/domain"
+	"product-management/internal/graphqlapi"
+	"product-management/internal/grpcapi"
 	"product-management/internal/handler"
-	"product-management/internal/repository/mongodb"
-	"product-management/internal/repository/mysql"
+	"product-management/internal/health"
+	"product-management/internal/idempotency"
+	"product-management/internal/idmap"
+	"product-management/internal/logging"
+	"product-management/internal/metrics"
+	"product-management/internal/openapi"
+	"product-management/internal/ratelimit"
+	"product-management/internal/replication"
 	"product-management/internal/service"
+	"product-management/internal/shadow"
+	"product-management/internal/shutdown"
+	"product-management/internal/stream"
+	"product-management/internal/tenant"
+	"product-management/internal/tracing"
+	"product-management/internal/webhook"
+	"syscall"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql" // pastikan driver mysql diimport
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+// fatal logs err and exits; deferred calls do not run.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
 func main() {
-	// MySQL setup
-	db, err := sql.Open("mysql", "root:@tcp(localhost:3306)/produk")
+	// Logging setup
+	logger, err := logging.New(config.LoadLoggingConfig(), os.Stdout)
+	if err != nil {
+		fatal("Invalid logging configuration", err)
+	}
+	slog.SetDefault(logger)
+
+	// Resources are released in phases when the process is asked to stop.
+	shutdownManager := shutdown.New()
+
+	// Tracing setup
+	shutdownTracing, err := tracing.Setup(context.Background(), config.LoadTracingConfig())
 	if err != nil {
-		log.Fatal(err)
+		fatal("Failed to set up tracing", err)
 	}
-	mysqlRepo := mysql.NewMySQLProductRepository(db)
+	shutdownManager.Register(shutdown.PhaseConnections, "tracing", shutdownTracing)
+
+	// Metrics setup
+	appMetrics := metrics.New()
 
-	// MongoDB setup
-	mongoClient, err := mongo.Connect(context.TODO(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	// Product backends fill the two repository slots of the service.
+	repoConfig := config.LoadRepositoryConfig()
+	if err := repoConfig.Validate(); err != nil {
+		fatal("Invalid product backend configuration", err)
+	}
+	conns := &connections{shutdown: shutdownManager, metrics: appMetrics}
+	primaryRepo, err := conns.backend(repoConfig.Primary, repoConfig)
+	if err != nil {
+		fatal("Failed to open primary product backend", err)
+	}
+	secondaryRepo, err := conns.backend(repoConfig.Secondary, repoConfig)
 	if err != nil {
-		log.Fatal(err)
+		fatal("Failed to open secondary product backend", err)
 	}
-	mongoCollection := mongoClient.Database("productDB").Collection("products")
-	mongoRepo := mongodb.NewMongoDBProductRepository(mongoCollection)
+
+	// API keys, webhooks, idempotency keys, the replication queue, the
+	// cutover weights and the IDs of the secondary's copies are stored in
+	// MySQL whatever the product backends are, except in embedded mode where
+	// they share the SQLite file. Demo mode keeps the copies in memory.
+	authConfig := config.LoadAuthConfig()
+	webhookConfig := config.LoadWebhookConfig()
+	idempotencyConfig := config.LoadIdempotencyConfig()
+	replicationConfig := config.LoadReplicationConfig()
+	cutoverConfig := config.LoadCutoverConfig()
+	if repoConfig.Demo {
+		slog.Warn("Demo mode: products are kept in memory; webhooks, API keys and idempotency keys are disabled")
+		authConfig.APIKeys, webhookConfig.Enabled, idempotencyConfig.Enabled = false, false, false
+	}
+	// Without a secondary there is nothing to replicate.
+	replicationConfig.Async = replicationConfig.Async && repoConfig.Secondary != config.BackendNone
+	cutoverConfig.Enabled = cutoverConfig.Enabled && repoConfig.Secondary != config.BackendNone
+	var db *sql.DB
+	copies := repoConfig.Secondary != config.BackendNone && !repoConfig.Demo
+	if authConfig.Enabled && authConfig.APIKeys || webhookConfig.Enabled || idempotencyConfig.Enabled || replicationConfig.Async || cutoverConfig.Enabled || copies {
+		if repoConfig.Embedded {
+			db, err = conns.SQLite(repoConfig.SQLitePath)
+		} else {
+			db, err = conns.MySQL()
+		}
+		if err != nil {
+			fatal("Failed to open the API key, webhook, idempotency, replication, cutover and copy database", err)
+		}
+	}
+
+	// Wait for the databases before accepting traffic. Schemas are prepared
+	// below and a backend still down then stops the start; one that goes
+	// down later only degrades reads, which fall back to the healthy one.
+	healthConfig := config.LoadHealthConfig()
+	checks := health.New(healthConfig.CheckTimeout, conns.checks...)
+	report := checks.WaitForDependencies(context.Background(), healthConfig.StartupAttempts, healthConfig.StartupInterval)
+	switch {
+	case !report.Serviceable():
+		fatal("No database is reachable", fmt.Errorf("dependencies: %+v", report.Dependencies))
+	case !report.Healthy():
+		slog.Warn("Starting in degraded mode", "dependencies", report.Dependencies)
+	}
+
+	// Tenant-scoped schema. Rows written before tenants existed belong to
+	// the default tenant, or to "default" when none is configured.
+	tenantConfig := config.LoadTenantConfig()
+	migrationTenant := tenantConfig.Default
+	if migrationTenant == "" {
+		migrationTenant = "default"
+	}
+	for name, repo := range map[string]productBackend{repoConfig.Primary: primaryRepo, repoConfig.Secondary: secondaryRepo} {
+		if err := repo.EnsureSchema(context.Background(), migrationTenant); err != nil {
+			fatal("Failed to prepare product schema on "+name, err)
+		}
+	}
+
+	// Business gauges query the raw repositories so scrapes do not show up
+	// as repository traffic.
+	scanned := map[string]domain.ProductCounter{
+		repoConfig.Primary:   primaryRepo,
+		repoConfig.Secondary: secondaryRepo,
+	}
+	delete(scanned, config.BackendNone)
+	appMetrics.MustRegister(metrics.NewProductCollector(scanned, 30*time.Second, 5*time.Second))
 
 	// Service and handler setup
-	productService := service.NewProductService(mysqlRepo, mongoRepo)
+	primary := metrics.NewInstrumentedRepository(primaryRepo, repoConfig.Primary, appMetrics)
+	secondary := metrics.NewInstrumentedRepository(secondaryRepo, repoConfig.Secondary, appMetrics)
+	productService, err := service.NewProductService(primary, secondary)
+	if err != nil {
+		fatal("Failed to create product service", err)
+	}
+	productService.SetListConfig(config.LoadListConfig(), repoConfig.Primary, repoConfig.Secondary)
+
+	// Each backend generates its own IDs; the service records which product
+	// of the secondary is the copy of which product of the primary.
+	switch {
+	case copies:
+		var idStore interface {
+			idmap.Store
+			EnsureSchema(ctx context.Context) error
+		} = idmap.NewMySQLStore(db)
+		if repoConfig.Embedded {
+			idStore = idmap.NewSQLiteStore(db)
+		}
+		if err := idStore.EnsureSchema(context.Background()); err != nil {
+			fatal("Failed to prepare product copies table", err)
+		}
+		productService.SetIDMap(idStore)
+	case repoConfig.Secondary != config.BackendNone:
+		productService.SetIDMap(idmap.NewMemoryStore())
+	}
+
+	// Shadow reads compare the secondary's answers with the primary's, such
+	// as before promoting the secondary.
+	if shadowConfig := config.LoadShadowReadConfig(); shadowConfig.Enabled {
+		if secondary, ok := secondaryRepo.(shadow.Secondary); ok && repoConfig.Secondary != config.BackendNone {
+			reader := shadow.New(secondary, shadowConfig, appMetrics)
+			productService.SetShadowReader(reader)
+			shutdownManager.Register(shutdown.PhaseWorkers, "shadow reads", reader.Stop)
+		} else {
+			slog.Warn("Shadow reads need a secondary product backend; disabled")
+		}
+	}
+
+	// Asynchronous replication: writes return once the primary commits and
+	// the secondary catches up from a queue in the database.
+	var replicationHandler *replication.Handler
+	if replicationConfig.Async {
+		var replicationStore interface {
+			replication.Store
+			EnsureSchema(ctx context.Context) error
+		} = replication.NewMySQLStore(db)
+		if repoConfig.Embedded {
+			replicationStore = replication.NewSQLiteStore(db)
+		}
+		if err := replicationStore.EnsureSchema(context.Background()); err != nil {
+			fatal("Failed to prepare replication table", err)
+		}
+		replicator := replication.New(replicationStore, productService, replicationConfig)
+		productService.SetReplicator(replicator)
+		replicator.Start()
+		shutdownManager.Register(shutdown.PhaseWorkers, "replication", replicator.Stop)
+		replicationHandler = replication.NewHandler(replicationStore, replicator)
+	}
+
+	// Cutover: a share of the lookups, set through the admin API, is served
+	// by the secondary and rolled back when it fails too often.
+	var cutoverHandler *cutover.Handler
+	if cutoverConfig.Enabled {
+		var cutoverStore interface {
+			cutover.Store
+			EnsureSchema(ctx context.Context) error
+		} = cutover.NewMySQLStore(db)
+		if repoConfig.Embedded {
+			cutoverStore = cutover.NewSQLiteStore(db)
+		}
+		if err := cutoverStore.EnsureSchema(context.Background()); err != nil {
+			fatal("Failed to prepare cutover table", err)
+		}
+		controller := cutover.New(cutoverStore, cutoverConfig)
+		if err := controller.Load(context.Background()); err != nil {
+			slog.Error("Failed to load cutover weights; reads stay on the primary", "error", err)
+		}
+		productService.SetRouter(controller)
+		controller.Start()
+		shutdownManager.Register(shutdown.PhaseWorkers, "cutover", controller.Stop)
+		cutoverHandler = cutover.NewHandler(controller)
+	}
 	productHandler := handler.NewProductHandler(productService)
 
+	// Live product changes for /products/stream.
+	streamConfig := config.LoadStreamConfig()
+	hub := stream.NewHub(streamConfig.ReplaySize, streamConfig.ClientBuffer)
+	productService.AddPublisher(hub)
+	appMetrics.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "product_management",
+		Name:      "stream_clients",
+		Help:      "Clients connected to the product event stream.",
+	}, func() float64 { return float64(hub.Clients()) }))
+
+	// Webhooks: product events are queued in the database and sent in the
+	// background until shutdown.
+	var webhookHandler *webhook.Handler
+	if webhookConfig.Enabled {
+		var webhookStore interface {
+			webhook.Store
+			EnsureSchema(ctx context.Context) error
+		} = webhook.NewMySQLStore(db)
+		if repoConfig.Embedded {
+			webhookStore = webhook.NewSQLiteStore(db)
+		}
+		if err := webhookStore.EnsureSchema(context.Background()); err != nil {
+			fatal("Failed to prepare webhook tables", err)
+		}
+		dispatcher := webhook.NewDispatcher(webhookStore, webhookConfig)
+		productService.AddPublisher(dispatcher)
+		dispatcher.Start()
+		shutdownManager.Register(shutdown.PhaseWorkers, "webhooks", dispatcher.Stop)
+		webhookHandler = webhook.NewHandler(webhookStore, dispatcher)
+	}
+
+	// Retried mutations with an Idempotency-Key replay the first response.
+	idempotent := func(c *fiber.Ctx) error { return c.Next() }
+	if idempotencyConfig.Enabled {
+		var idempotencyStore interface {
+			idempotency.Store
+			EnsureSchema(ctx context.Context) error
+		} = idempotency.NewMySQLStore(db)
+		if repoConfig.Embedded {
+			idempotencyStore = idempotency.NewSQLiteStore(db)
+		}
+		if err := idempotencyStore.EnsureSchema(context.Background()); err != nil {
+			fatal("Failed to prepare idempotency table", err)
+		}
+		keys := idempotency.New(idempotencyStore, idempotencyConfig)
+		keys.Start()
+		shutdownManager.Register(shutdown.PhaseWorkers, "idempotency", keys.Stop)
+		idempotent = keys.Handler()
+	}
+
 	// Fiber setup
-	app := fiber.New()
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(logging.RequestID())
+	app.Use(appMetrics.Middleware())
+	app.Use(tracing.Middleware())
+	app.Use(logging.AccessLog(logger))
+	// Errors are rendered once, here, and passed on so the middleware above
+	// record them with the status sent.
+	app.Use(handler.RenderErrors())
+	app.Get("/metrics", appMetrics.Handler())
+	app.Get("/livez", checks.Livez)
+	app.Get("/readyz", checks.Readyz)
+	app.Get("/openapi.json", openapi.Handler)
+	app.Get("/docs", openapi.SwaggerUI)
+
+	// Authentication: every route below this point requires a principal.
+	var authenticator *auth.Authenticator
+	if authConfig.Enabled {
+		authenticator, err = newAuthenticator(authConfig, db, repoConfig.Embedded)
+		if err != nil {
+			fatal("Invalid authentication configuration", err)
+		}
+		app.Use(authenticator.Middleware())
+	} else {
+		slog.Warn("Authentication is disabled; all routes are open and callers may pick any tenant")
+		app.Use(func(c *fiber.Ctx) error {
+			c.SetUserContext(auth.WithPrincipal(c.UserContext(), &auth.Principal{Subject: "anonymous", Role: auth.RoleAdmin}))
+			return c.Next()
+		})
+	}
+	app.Use(tenant.Middleware(tenantConfig.Default))
+	openAPIConfig := config.LoadOpenAPIConfig()
+	validator, err := openapi.NewValidator(openapi.Options{ValidateResponses: openAPIConfig.ValidateResponses})
+	if err != nil {
+		fatal("Invalid OpenAPI document", err)
+	}
+	if openAPIConfig.Validate {
+		app.Use(validator.Middleware())
+	}
+	viewer, editor, admin := auth.Require(auth.RoleViewer), auth.Require(auth.RoleEditor), auth.Require(auth.RoleAdmin)
+
+	// Rate limiting runs after authentication so clients are keyed by
+	// subject rather than IP where possible.
+	limiter := newLimiter(config.LoadRateLimitConfig())
+	reads, writes, exports := limiter.Handler(ratelimit.ClassReads), limiter.Handler(ratelimit.ClassWrites), limiter.Handler(ratelimit.ClassExports)
 
 	// CRUD Routes
-	app.Post("/products", productHandler.CreateProduct)
-	app.Get("/products", productHandler.GetAllProducts)
-	app.Get("/products/:id", productHandler.GetProductByID)
-	app.Put("/products/:id", productHandler.UpdateProduct)
-	app.Delete("/products/:id", productHandler.DeleteProduct)
-	app.Get("/mysql-products", productHandler.GetMySQLProducts)
-	app.Get("/mongodb-products", productHandler.GetMongoDBProducts)
-
-	log.Fatal(app.Listen(":3000"))
+	app.Post("/products", editor, writes, idempotent, productHandler.CreateProduct)
+	app.Get("/products", viewer, reads, productHandler.GetAllProducts)
+	app.Get("/products/stream", viewer, reads, stream.Handler(hub, streamConfig.Heartbeat))
+	app.Get("/products/:id", viewer, reads, productHandler.GetProductByID)
+	app.Put("/products/:id", editor, writes, idempotent, productHandler.UpdateProduct)
+	app.Delete("/products/:id", admin, writes, idempotent, productHandler.DeleteProduct)
+	app.Get("/mysql-products", viewer, exports, productHandler.GetMySQLProducts)
+	app.Get("/mongodb-products", viewer, exports, productHandler.GetMongoDBProducts)
+
+	// GraphQL shares the REST middleware; mutations check roles themselves
+	// and are limited as writes.
+	graphqlHandler, err := graphqlapi.New(productService, validator)
+	if err != nil {
+		fatal("Invalid GraphQL schema", err)
+	}
+	app.Post("/graphql", viewer, graphqlapi.RateLimit(reads, writes), graphqlHandler.Serve)
+
+	// Webhook admin API
+	if webhookHandler != nil {
+		app.Post("/webhooks", admin, writes, idempotent, webhookHandler.CreateSubscription)
+		app.Get("/webhooks", admin, reads, webhookHandler.ListSubscriptions)
+		app.Get("/webhooks/:id", admin, reads, webhookHandler.GetSubscription)
+		app.Delete("/webhooks/:id", admin, writes, idempotent, webhookHandler.DeleteSubscription)
+		app.Get("/webhooks/:id/deliveries", admin, reads, webhookHandler.ListDeliveries)
+		app.Post("/webhooks/deliveries/:id/redeliver", admin, writes, idempotent, webhookHandler.Redeliver)
+	}
+
+	// Replication dead-letter admin API
+	if replicationHandler != nil {
+		app.Get("/replication/dead-letters", admin, reads, replicationHandler.ListDeadLetters)
+		app.Post("/replication/dead-letters/:id/retry", admin, writes, idempotent, replicationHandler.RetryDeadLetter)
+		app.Delete("/replication/dead-letters/:id", admin, writes, idempotent, replicationHandler.DiscardDeadLetter)
+	}
+
+	// Cutover admin API
+	if cutoverHandler != nil {
+		app.Get("/cutover", admin, reads, cutoverHandler.GetStatus)
+		app.Put("/cutover/:operation", admin, writes, idempotent, cutoverHandler.SetWeight)
+	}
+
+	serverConfig := config.LoadServerConfig()
+	shutdownManager.SetTimeout(shutdown.PhaseServer, serverConfig.ServerTimeout)
+	shutdownManager.SetTimeout(shutdown.PhaseWorkers, serverConfig.WorkersTimeout)
+	shutdownManager.SetTimeout(shutdown.PhaseConnections, serverConfig.ConnectionsTimeout)
+	// Open streams would otherwise hold the HTTP shutdown until its timeout.
+	shutdownManager.Register(shutdown.PhaseServer, "stream", hub.Close)
+	shutdownManager.Register(shutdown.PhaseServer, "http", func(ctx context.Context) error {
+		checks.StartDraining()
+		drain(ctx, serverConfig.DrainDelay)
+		timeout := serverConfig.ShutdownTimeout
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = time.Until(deadline)
+		}
+		return app.ShutdownWithTimeout(timeout)
+	})
+
+	// gRPC API on its own port, sharing authentication, tenant resolution
+	// and input validation with the REST routes.
+	grpcServer, grpcHealth := grpcapi.New(productService, grpcapi.Options{
+		Authenticator: authenticator,
+		DefaultTenant: tenantConfig.Default,
+		Validator:     validator,
+	})
+	grpcListener, err := net.Listen("tcp", serverConfig.GRPCAddr)
+	if err != nil {
+		fatal("Failed to listen for gRPC", err)
+	}
+	shutdownManager.Register(shutdown.PhaseServer, "grpc", func(ctx context.Context) error {
+		grpcHealth.Shutdown()
+		drain(ctx, serverConfig.DrainDelay)
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			grpcServer.Stop()
+			return ctx.Err()
+		}
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	listenErr := make(chan error, 2)
+	go func() {
+		listenErr <- app.Listen(serverConfig.Addr)
+	}()
+	go func() {
+		slog.Info("gRPC server listening", "addr", grpcListener.Addr().String())
+		listenErr <- grpcServer.Serve(grpcListener)
+	}()
+
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutdown signal received, draining requests",
+			"drain_delay", serverConfig.DrainDelay, "timeout", serverConfig.ShutdownTimeout)
+	case err := <-listenErr:
+		slog.Error("Server stopped", "error", err)
+		exitCode = 1
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverConfig.ShutdownTimeout)
+	defer cancel()
+	if err := shutdownManager.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Shutdown finished with errors", "error", err)
+		exitCode = 1
+	} else {
+		slog.Info("Shutdown complete")
+	}
+	os.Exit(exitCode)
+}
+
+// newAuthenticator wires the JWT verifier and, when enabled, the API key
+// store in MySQL or, in embedded mode, SQLite.
+// drain keeps serving for delay after readiness started failing, or until
+// ctx is done.
+func drain(ctx context.Context, delay time.Duration) {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+func newAuthenticator(cfg config.AuthConfig, db *sql.DB, embedded bool) (*auth.Authenticator, error) {
+	var verifier *auth.JWTVerifier
+	if cfg.JWTSecret != "" || cfg.JWTPublicKeyFile != "" {
+		var err error
+		verifier, err = auth.NewJWTVerifier(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var keys auth.APIKeyStore
+	if cfg.APIKeys {
+		var store interface {
+			auth.APIKeyStore
+			EnsureSchema(ctx context.Context) error
+		} = auth.NewMySQLAPIKeyStore(db)
+		if embedded {
+			store = auth.NewSQLiteAPIKeyStore(db)
+		}
+		if err := store.EnsureSchema(context.Background()); err != nil {
+			return nil, fmt.Errorf("create api_keys table: %w", err)
+		}
+		keys = store
+	}
+
+	if verifier == nil && keys == nil {
+		return nil, errors.New("authentication is enabled but no JWT key or API key store is configured")
+	}
+	return auth.NewAuthenticator(verifier, keys), nil
+}
+
+// newLimiter builds the per-class limiter on the in-memory store.
+func newLimiter(cfg config.RateLimitConfig) *ratelimit.Limiter {
+	if !cfg.Enabled {
+		return ratelimit.New(nil, nil)
+	}
+	limit := func(l config.RateLimit) ratelimit.Limit {
+		return ratelimit.Limit{Requests: l.Requests, Period: l.Period}
+	}
+	return ratelimit.New(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{
+		ratelimit.ClassReads:   limit(cfg.Reads),
+		ratelimit.ClassWrites:  limit(cfg.Writes),
+		ratelimit.ClassExports: limit(cfg.Exports),
+	})
 }
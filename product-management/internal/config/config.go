@@ -4,36 +4,532 @@ package config
 import (
 	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func LoadEnv() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
+// LoadEnv loads variables from a .env file in the working directory.
+func LoadEnv() error {
+	if err := godotenv.Load(); err != nil {
+		return fmt.Errorf("load .env file: %w", err)
 	}
+	return nil
 }
 
 func ConnectMySQL() (*sql.DB, error) {
-	dsn := os.Getenv("MYSQL_DSN")
-	db, err := sql.Open("mysql", dsn)
+	// Timestamps are scanned into time.Time, which needs parseTime.
+	cfg, err := mysql.ParseDSN(getEnv("MYSQL_DSN", "root:@tcp(localhost:3306)/produk"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.ParseTime = true
+	db, err := sql.Open("mysql", cfg.FormatDSN())
 	if err != nil {
 		return nil, err
 	}
 	return db, nil
 }
 
+// ConnectPostgres opens a pool for POSTGRES_DSN, a URL or key=value
+// connection string.
+func ConnectPostgres() (*sql.DB, error) {
+	return sql.Open("pgx", getEnv("POSTGRES_DSN", "postgres://postgres@localhost:5432/produk?sslmode=disable"))
+}
+
 func ConnectMongoDB() (*mongo.Client, error) {
-	clientOptions := options.Client().ApplyURI(os.Getenv("MONGODB_URI"))
+	clientOptions := options.Client().ApplyURI(getEnv("MONGODB_URI", "mongodb://localhost:27017"))
 	client, err := mongo.Connect(context.TODO(), clientOptions)
 	if err != nil {
 		return nil, err
 	}
 	return client, nil
 }
+
+// Product backends that can fill a repository slot.
+const (
+	BackendMySQL    = "mysql"
+	BackendMongoDB  = "mongodb"
+	BackendPostgres = "postgres"
+	BackendSQLite   = "sqlite"
+	BackendMemory   = "memory"
+	// BackendNone leaves the secondary slot empty.
+	BackendNone = "none"
+)
+
+// RepositoryConfig chooses the database behind each repository slot of the
+// product service. Writes go to both slots; reads try Primary first and fall
+// back to Secondary.
+type RepositoryConfig struct {
+	Primary   string
+	Secondary string
+	// Embedded runs everything from the SQLite file at SQLitePath: products
+	// in the primary slot, no secondary, and API keys and webhooks next to
+	// them instead of in MySQL.
+	Embedded   bool
+	SQLitePath string
+	// Demo keeps products in process memory with no secondary, so nothing
+	// survives a restart. Webhooks and API keys need a database and are off.
+	Demo bool
+}
+
+// LoadRepositoryConfig reads the backend selection from the environment.
+// EMBEDDED_MODE and DEMO_MODE override the backend variables.
+func LoadRepositoryConfig() RepositoryConfig {
+	cfg := RepositoryConfig{
+		Primary:    getEnv("PRODUCT_PRIMARY_BACKEND", BackendMySQL),
+		Secondary:  getEnv("PRODUCT_SECONDARY_BACKEND", BackendMongoDB),
+		Embedded:   getEnvBool("EMBEDDED_MODE", false),
+		SQLitePath: getEnv("SQLITE_PATH", "product-management.db"),
+		Demo:       getEnvBool("DEMO_MODE", false),
+	}
+	switch {
+	case cfg.Embedded:
+		cfg.Primary, cfg.Secondary = BackendSQLite, BackendNone
+	case cfg.Demo:
+		cfg.Primary, cfg.Secondary = BackendMemory, BackendNone
+	}
+	return cfg
+}
+
+// Validate reports unknown backends and a backend used for both slots,
+// which would store every product twice in the same database.
+func (c RepositoryConfig) Validate() error {
+	if c.Embedded && c.Demo {
+		return errors.New("EMBEDDED_MODE and DEMO_MODE cannot be combined")
+	}
+	for _, name := range []string{c.Primary, c.Secondary} {
+		switch name {
+		case BackendMySQL, BackendMongoDB, BackendPostgres, BackendSQLite, BackendMemory:
+		case BackendNone:
+			if name == c.Primary {
+				return errors.New("the primary product backend cannot be none")
+			}
+		default:
+			return fmt.Errorf("unknown product backend %q", name)
+		}
+	}
+	if c.Primary == c.Secondary {
+		return fmt.Errorf("product backend %q cannot fill both slots", c.Primary)
+	}
+	return nil
+}
+
+// ListConfig controls how product lists read both repository slots, which
+// are queried concurrently.
+type ListConfig struct {
+	// BackendTimeout bounds the query of each slot. Zero leaves only the
+	// request's own deadline.
+	BackendTimeout time.Duration
+	// AllowPartial answers with the products of the slot that responded
+	// when the other fails, marked as partial, instead of failing the list.
+	AllowPartial bool
+}
+
+// LoadListConfig reads the product list settings from the environment.
+func LoadListConfig() ListConfig {
+	return ListConfig{
+		BackendTimeout: getEnvDuration("PRODUCT_LIST_BACKEND_TIMEOUT", 5*time.Second),
+		AllowPartial:   getEnvBool("PRODUCT_LIST_ALLOW_PARTIAL", false),
+	}
+}
+
+// LoggingConfig controls the slog handler used across the application.
+type LoggingConfig struct {
+	// Level is one of debug, info, warn or error.
+	Level string
+	// Format is "json" or "text".
+	Format string
+}
+
+// LoadLoggingConfig reads the logging settings from the environment.
+func LoadLoggingConfig() LoggingConfig {
+	return LoggingConfig{
+		Level:  getEnv("LOG_LEVEL", "info"),
+		Format: getEnv("LOG_FORMAT", "json"),
+	}
+}
+
+// ServerConfig controls the HTTP and gRPC listeners and their shutdown.
+type ServerConfig struct {
+	Addr string
+	// GRPCAddr is the listener address of the gRPC API.
+	GRPCAddr string
+	// ShutdownTimeout bounds the whole shutdown: draining requests,
+	// flushing workers and closing connections.
+	ShutdownTimeout time.Duration
+	// DrainDelay is how long the servers keep serving after readiness
+	// starts failing, so load balancers stop routing to the instance
+	// before its listeners close.
+	DrainDelay time.Duration
+	// ServerTimeout, WorkersTimeout and ConnectionsTimeout bound each
+	// shutdown phase, so a hung phase leaves time for the next ones.
+	ServerTimeout      time.Duration
+	WorkersTimeout     time.Duration
+	ConnectionsTimeout time.Duration
+}
+
+// LoadServerConfig reads the server settings from the environment.
+func LoadServerConfig() ServerConfig {
+	return ServerConfig{
+		Addr:               getEnv("HTTP_ADDR", ":3000"),
+		GRPCAddr:           getEnv("GRPC_ADDR", ":50051"),
+		ShutdownTimeout:    getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		DrainDelay:         getEnvDuration("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+		ServerTimeout:      getEnvDuration("SHUTDOWN_SERVER_TIMEOUT", 20*time.Second),
+		WorkersTimeout:     getEnvDuration("SHUTDOWN_WORKERS_TIMEOUT", 5*time.Second),
+		ConnectionsTimeout: getEnvDuration("SHUTDOWN_CONNECTIONS_TIMEOUT", 5*time.Second),
+	}
+}
+
+// HealthConfig controls dependency checks for readiness and startup.
+type HealthConfig struct {
+	// CheckTimeout bounds each individual ping.
+	CheckTimeout time.Duration
+	// StartupAttempts is how many times startup checks dependencies
+	// before giving up; StartupInterval is the pause between attempts.
+	StartupAttempts int
+	StartupInterval time.Duration
+}
+
+// LoadHealthConfig reads the health check settings from the environment.
+func LoadHealthConfig() HealthConfig {
+	return HealthConfig{
+		CheckTimeout:    getEnvDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		StartupAttempts: getEnvInt("STARTUP_ATTEMPTS", 10),
+		StartupInterval: getEnvDuration("STARTUP_INTERVAL", 3*time.Second),
+	}
+}
+
+// AuthConfig controls authentication of the product API.
+type AuthConfig struct {
+	// Enabled turns authentication on; disable only for local development.
+	Enabled bool
+	// JWTSecret enables HS256 tokens, JWTPublicKeyFile (PEM) RS256 tokens.
+	JWTSecret        string
+	JWTPublicKeyFile string
+	JWTIssuer        string
+	JWTAudience      string
+	// APIKeys enables hashed API keys stored in MySQL.
+	APIKeys bool
+}
+
+// LoadAuthConfig reads the authentication settings from the environment.
+func LoadAuthConfig() AuthConfig {
+	return AuthConfig{
+		Enabled:          getEnvBool("AUTH_ENABLED", true),
+		JWTSecret:        os.Getenv("JWT_SECRET"),
+		JWTPublicKeyFile: os.Getenv("JWT_PUBLIC_KEY_FILE"),
+		JWTIssuer:        os.Getenv("JWT_ISSUER"),
+		JWTAudience:      os.Getenv("JWT_AUDIENCE"),
+		APIKeys:          getEnvBool("AUTH_API_KEYS", true),
+	}
+}
+
+// TenantConfig controls tenant resolution.
+type TenantConfig struct {
+	// Default is used for requests whose principal has no tenant and that
+	// send no X-Tenant-ID header. It also receives rows migrated from the
+	// single-tenant schema. Leave empty to require a tenant on every request.
+	Default string
+}
+
+// LoadTenantConfig reads the tenant settings from the environment.
+func LoadTenantConfig() TenantConfig {
+	return TenantConfig{Default: os.Getenv("DEFAULT_TENANT")}
+}
+
+// RateLimit allows Requests per Period for each client.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// RateLimitConfig holds the limits per route class. A zero limit disables
+// limiting for that class.
+type RateLimitConfig struct {
+	Enabled bool
+	Reads   RateLimit
+	Writes  RateLimit
+	Exports RateLimit
+}
+
+// LoadRateLimitConfig reads the rate limits from the environment. Limits
+// are written as "<requests>/<period>", e.g. "100/1m".
+func LoadRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Enabled: getEnvBool("RATE_LIMIT_ENABLED", true),
+		Reads:   getEnvRateLimit("RATE_LIMIT_READS", RateLimit{Requests: 300, Period: time.Minute}),
+		Writes:  getEnvRateLimit("RATE_LIMIT_WRITES", RateLimit{Requests: 60, Period: time.Minute}),
+		Exports: getEnvRateLimit("RATE_LIMIT_EXPORTS", RateLimit{Requests: 10, Period: time.Minute}),
+	}
+}
+
+// OpenAPIConfig controls contract validation against the OpenAPI document.
+type OpenAPIConfig struct {
+	// Validate enables the middleware, which rejects request bodies that do
+	// not match the spec.
+	Validate bool
+	// ValidateResponses also replaces responses that do not match the spec
+	// with a 500. Intended for test and staging environments.
+	ValidateResponses bool
+}
+
+// LoadOpenAPIConfig reads the contract validation settings from the environment.
+func LoadOpenAPIConfig() OpenAPIConfig {
+	return OpenAPIConfig{
+		Validate:          getEnvBool("OPENAPI_VALIDATE", false),
+		ValidateResponses: getEnvBool("OPENAPI_VALIDATE_RESPONSES", false),
+	}
+}
+
+// TracingConfig controls OpenTelemetry tracing.
+type TracingConfig struct {
+	// Exporter is one of "none", "stdout", "file" or "otlp", or any name
+	// registered with tracing.RegisterExporter.
+	Exporter    string
+	FilePath    string
+	ServiceName string
+	SampleRatio float64
+}
+
+// LoadTracingConfig reads the tracing settings from the environment.
+func LoadTracingConfig() TracingConfig {
+	return TracingConfig{
+		Exporter:    getEnv("TRACING_EXPORTER", "none"),
+		FilePath:    getEnv("TRACING_FILE", "traces.json"),
+		ServiceName: getEnv("OTEL_SERVICE_NAME", "product-management"),
+		SampleRatio: getEnvFloat("TRACING_SAMPLE_RATIO", 1),
+	}
+}
+
+// WebhookConfig controls outgoing webhook deliveries.
+type WebhookConfig struct {
+	Enabled bool
+	// PollInterval is how often the dispatcher looks for due deliveries.
+	PollInterval time.Duration
+	// Timeout bounds a single delivery attempt.
+	Timeout time.Duration
+	// MaxAttempts is how many times a delivery is tried before it is
+	// marked failed. Retries back off exponentially from BackoffBase up to
+	// BackoffMax.
+	MaxAttempts int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// LowStockThreshold triggers stock.low when a product's stock drops to
+	// or below it.
+	LowStockThreshold int
+}
+
+// LoadWebhookConfig reads the webhook settings from the environment.
+func LoadWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		Enabled:           getEnvBool("WEBHOOKS_ENABLED", true),
+		PollInterval:      getEnvDuration("WEBHOOK_POLL_INTERVAL", time.Second),
+		Timeout:           getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		MaxAttempts:       getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		BackoffBase:       getEnvDuration("WEBHOOK_BACKOFF_BASE", 10*time.Second),
+		BackoffMax:        getEnvDuration("WEBHOOK_BACKOFF_MAX", time.Hour),
+		LowStockThreshold: getEnvInt("WEBHOOK_LOW_STOCK_THRESHOLD", 5),
+	}
+}
+
+// ReplicationConfig controls how writes reach the secondary repository slot.
+type ReplicationConfig struct {
+	// Async acknowledges a write once the primary commits it and applies it
+	// to the secondary from a queue in the database. When off, both slots
+	// are written before the request returns.
+	Async bool
+	// PollInterval is how often the workers look for due mutations.
+	PollInterval time.Duration
+	// Workers bounds how many products are replicated in parallel; the
+	// mutations of one product are applied one at a time, in order.
+	Workers int
+	// ApplyTimeout bounds a single attempt.
+	ApplyTimeout time.Duration
+	// MaxAttempts is how many times a mutation is tried before it moves to
+	// the dead letters. Retries back off exponentially from BackoffBase up
+	// to BackoffMax.
+	MaxAttempts int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+}
+
+// LoadReplicationConfig reads the replication settings from the environment.
+func LoadReplicationConfig() ReplicationConfig {
+	return ReplicationConfig{
+		Async:        getEnvBool("REPLICATION_ASYNC", false),
+		PollInterval: getEnvDuration("REPLICATION_POLL_INTERVAL", time.Second),
+		Workers:      getEnvInt("REPLICATION_WORKERS", 8),
+		ApplyTimeout: getEnvDuration("REPLICATION_APPLY_TIMEOUT", 10*time.Second),
+		MaxAttempts:  getEnvInt("REPLICATION_MAX_ATTEMPTS", 10),
+		BackoffBase:  getEnvDuration("REPLICATION_BACKOFF_BASE", time.Second),
+		BackoffMax:   getEnvDuration("REPLICATION_BACKOFF_MAX", 5*time.Minute),
+	}
+}
+
+// ShadowReadConfig controls comparing the secondary's answers with the
+// primary's, such as before promoting the secondary.
+type ShadowReadConfig struct {
+	// Enabled repeats reads served by the primary against the secondary in
+	// the background and records where the answers differ.
+	Enabled bool
+	// SampleRate is the fraction of reads repeated, from 0 to 1.
+	SampleRate float64
+	// LogSampleRate is the fraction of mismatches logged with both
+	// payloads. Every mismatch is counted in the metrics.
+	LogSampleRate float64
+	// Timeout bounds each shadow read.
+	Timeout time.Duration
+	// MaxInFlight bounds concurrent shadow reads. Reads beyond it are
+	// skipped rather than slowing down the caller.
+	MaxInFlight int
+}
+
+// LoadShadowReadConfig reads the shadow read settings from the environment.
+func LoadShadowReadConfig() ShadowReadConfig {
+	return ShadowReadConfig{
+		Enabled:       getEnvBool("SHADOW_READS_ENABLED", false),
+		SampleRate:    getEnvFloat("SHADOW_READ_SAMPLE_RATE", 1),
+		LogSampleRate: getEnvFloat("SHADOW_READ_LOG_SAMPLE_RATE", 0.1),
+		Timeout:       getEnvDuration("SHADOW_READ_TIMEOUT", 2*time.Second),
+		MaxInFlight:   getEnvInt("SHADOW_READ_MAX_IN_FLIGHT", 64),
+	}
+}
+
+// CutoverConfig controls moving reads from the primary slot to the
+// secondary. The routing weights themselves are set through the admin API.
+type CutoverConfig struct {
+	// Enabled serves a share of the lookups from the secondary.
+	Enabled bool
+	// RefreshInterval is how often weights set on other instances, or
+	// rolled back by them, are loaded.
+	RefreshInterval time.Duration
+	// ErrorThreshold is the error rate of routed reads, from 0 to 1, at
+	// which every weight is rolled back to zero.
+	ErrorThreshold float64
+	// MinRequests is how many routed reads a window needs before its error
+	// rate is trusted.
+	MinRequests int
+	// Window is how long routed reads are counted before starting over.
+	Window time.Duration
+}
+
+// LoadCutoverConfig reads the cutover settings from the environment.
+func LoadCutoverConfig() CutoverConfig {
+	return CutoverConfig{
+		Enabled:         getEnvBool("CUTOVER_ENABLED", false),
+		RefreshInterval: getEnvDuration("CUTOVER_REFRESH_INTERVAL", 10*time.Second),
+		ErrorThreshold:  getEnvFloat("CUTOVER_ERROR_THRESHOLD", 0.05),
+		MinRequests:     getEnvInt("CUTOVER_MIN_REQUESTS", 20),
+		Window:          getEnvDuration("CUTOVER_WINDOW", time.Minute),
+	}
+}
+
+// IdempotencyConfig controls replay of mutating requests sent with an
+// Idempotency-Key header.
+type IdempotencyConfig struct {
+	Enabled bool
+	// TTL is how long a response is kept for replay.
+	TTL time.Duration
+	// LockTimeout bounds how long a request holds its key; duplicates wait
+	// for it, and take the key over once it expires.
+	LockTimeout time.Duration
+	// PurgeInterval is how often expired keys are deleted.
+	PurgeInterval time.Duration
+}
+
+// LoadIdempotencyConfig reads the idempotency settings from the environment.
+func LoadIdempotencyConfig() IdempotencyConfig {
+	return IdempotencyConfig{
+		Enabled:       getEnvBool("IDEMPOTENCY_ENABLED", true),
+		TTL:           getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		LockTimeout:   getEnvDuration("IDEMPOTENCY_LOCK_TIMEOUT", 30*time.Second),
+		PurgeInterval: getEnvDuration("IDEMPOTENCY_PURGE_INTERVAL", 10*time.Minute),
+	}
+}
+
+// StreamConfig controls the server-sent event stream of product changes.
+type StreamConfig struct {
+	// ReplaySize is how many recent events are kept for clients resuming
+	// with Last-Event-ID.
+	ReplaySize int
+	// ClientBuffer is how many events may queue for one client before it
+	// is disconnected as too slow.
+	ClientBuffer int
+	// Heartbeat is the interval of keep-alive comments on idle streams.
+	Heartbeat time.Duration
+}
+
+// LoadStreamConfig reads the event stream settings from the environment.
+func LoadStreamConfig() StreamConfig {
+	return StreamConfig{
+		ReplaySize:   getEnvInt("STREAM_REPLAY_SIZE", 1000),
+		ClientBuffer: getEnvInt("STREAM_CLIENT_BUFFER", 64),
+		Heartbeat:    getEnvDuration("STREAM_HEARTBEAT", 15*time.Second),
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvRateLimit(key string, fallback RateLimit) RateLimit {
+	requests, period, ok := strings.Cut(os.Getenv(key), "/")
+	if !ok {
+		return fallback
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil {
+		return fallback
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return RateLimit{Requests: n, Period: d}
+}
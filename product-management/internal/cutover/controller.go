@@ -0,0 +1,230 @@
+// internal/cutover/controller.go
+package cutover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"product-management/internal/config"
+	"product-management/internal/durable"
+	"product-management/internal/tenant"
+)
+
+// buckets is the routing resolution: weights are honoured to 0.01%.
+const buckets = 10000
+
+// rollbackTimeout bounds saving a rollback from the request that caused it.
+const rollbackTimeout = 5 * time.Second
+
+// Window counts the routed reads since Since.
+type Window struct {
+	Since  time.Time `json:"since"`
+	Reads  int       `json:"reads"`
+	Errors int       `json:"errors"`
+}
+
+// Status is the routing state reported by the admin API.
+type Status struct {
+	Routes []Route `json:"routes"`
+	Window Window  `json:"window"`
+}
+
+// Controller decides which slot serves a lookup and rolls every
+// weight back to zero when the secondary's error rate on routed reads
+// crosses the threshold. Weights are kept in the store and reloaded
+// periodically, so all instances converge on the latest change.
+type Controller struct {
+	store Store
+	cfg   config.CutoverConfig
+
+	mu     sync.RWMutex
+	routes map[string]Route
+	window Window
+	// generation counts local changes; refreshes loaded before one are
+	// dropped.
+	generation int
+
+	refresher *durable.Worker
+}
+
+// New creates a Controller with every weight at zero. Call Load to apply
+// the stored weights and Start to keep them current.
+func New(store Store, cfg config.CutoverConfig) *Controller {
+	c := &Controller{store: store, cfg: cfg, routes: map[string]Route{}, window: Window{Since: durable.Now()}}
+	c.refresher = durable.NewWorker(cfg.RefreshInterval, c.refresh)
+	return c
+}
+
+// Load replaces the weights with the stored ones.
+func (c *Controller) Load(ctx context.Context) error {
+	c.mu.RLock()
+	generation := c.generation
+	c.mu.RUnlock()
+	stored, err := c.store.Routes(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return nil
+	}
+	routes := make(map[string]Route, len(stored))
+	for _, r := range stored {
+		if slices.Contains(Operations, r.Operation) {
+			routes[r.Operation] = r
+		}
+	}
+	for _, op := range Operations {
+		if routes[op].Weight != c.routes[op].Weight {
+			slog.InfoContext(ctx, "Cutover weight changed", "operation", op,
+				"from", c.routes[op].Weight, "to", routes[op].Weight, "by", routes[op].UpdatedBy)
+			c.window = Window{Since: durable.Now()}
+		}
+	}
+	c.routes = routes
+	return nil
+}
+
+// Start reloads the weights every RefreshInterval until Stop is called.
+func (c *Controller) Start() {
+	c.refresher.Start()
+}
+
+// Stop stops reloading the weights.
+func (c *Controller) Stop(ctx context.Context) error {
+	return c.refresher.Stop(ctx)
+}
+
+func (c *Controller) refresh(ctx context.Context) {
+	if err := c.Load(ctx); err != nil {
+		slog.ErrorContext(ctx, "Failed to load cutover weights", "error", err)
+	}
+}
+
+// Status returns a route for every operation and the current window.
+func (c *Controller) Status() Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	status := Status{Window: c.window}
+	for _, op := range Operations {
+		r := c.routes[op]
+		r.Operation = op
+		status.Routes = append(status.Routes, r)
+	}
+	return status
+}
+
+// SetWeight stores a new weight for operation and applies it.
+func (c *Controller) SetWeight(ctx context.Context, operation string, weight float64, by string) (Route, error) {
+	if !slices.Contains(Operations, operation) {
+		return Route{}, ErrUnknownOperation
+	}
+	if math.IsNaN(weight) || weight < 0 || weight > 100 {
+		return Route{}, ErrInvalidWeight
+	}
+	r := Route{Operation: operation, Weight: weight, UpdatedBy: by, UpdatedAt: durable.Now()}
+	if err := c.store.SaveRoutes(ctx, []Route{r}); err != nil {
+		return Route{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	slog.InfoContext(ctx, "Cutover weight changed", "operation", operation,
+		"from", c.routes[operation].Weight, "to", weight, "by", by)
+	c.routes[operation] = r
+	c.generation++
+	// A new step is judged on its own reads.
+	c.window = Window{Since: durable.Now()}
+	return r, nil
+}
+
+// Secondary reports whether a read of productID for operation is served by
+// the secondary slot. The choice is stable per tenant and product.
+func (c *Controller) Secondary(ctx context.Context, operation, productID string) bool {
+	c.mu.RLock()
+	weight := c.routes[operation].Weight
+	c.mu.RUnlock()
+	if weight <= 0 {
+		return false
+	}
+	tenantID, _ := tenant.FromContext(ctx)
+	return float64(bucket(tenantID, productID)) < weight*buckets/100
+}
+
+// bucket places a product in [0, buckets).
+func bucket(tenantID, productID string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(tenantID))
+	h.Write([]byte{0})
+	h.Write([]byte(productID))
+	return h.Sum32() % buckets
+}
+
+// Observe records the outcome of a read routed to the secondary. A product
+// the secondary does not have is an error; callers going away are not.
+func (c *Controller) Observe(ctx context.Context, operation string, err error) {
+	failed := err != nil && !errors.Is(err, context.Canceled)
+
+	c.mu.Lock()
+	if c.cfg.Window > 0 && time.Since(c.window.Since) > c.cfg.Window {
+		c.window = Window{Since: durable.Now()}
+	}
+	c.window.Reads++
+	if failed {
+		c.window.Errors++
+	}
+	window := c.window
+	trip := failed && window.Reads >= c.cfg.MinRequests &&
+		float64(window.Errors)/float64(window.Reads) >= c.cfg.ErrorThreshold
+	c.mu.Unlock()
+
+	if trip {
+		c.rollback(ctx, operation, window, err)
+	}
+}
+
+// rollback routes every operation back to the primary and stores that, so
+// other instances follow at their next refresh.
+func (c *Controller) rollback(ctx context.Context, operation string, window Window, cause error) {
+	note := fmt.Sprintf("rolled back: %d of %d %s reads failed", window.Errors, window.Reads, operation)
+	routes := make([]Route, 0, len(Operations))
+	c.mu.Lock()
+	for _, op := range Operations {
+		if c.routes[op].Weight > 0 {
+			routes = append(routes, Route{Operation: op, Note: note, UpdatedBy: "cutover", UpdatedAt: durable.Now()})
+		}
+	}
+	if len(routes) == 0 {
+		// Another read rolled back first.
+		c.mu.Unlock()
+		return
+	}
+	for _, r := range routes {
+		c.routes[r.Operation] = r
+	}
+	c.generation++
+	c.window = Window{Since: durable.Now()}
+	c.mu.Unlock()
+
+	slog.ErrorContext(ctx, "Cutover rolled back to the primary", "operation", operation,
+		"reads", window.Reads, "errors", window.Errors, "threshold", c.cfg.ErrorThreshold, "error", cause)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+	if err := c.store.SaveRoutes(ctx, routes); err != nil {
+		slog.ErrorContext(ctx, "Failed to store cutover rollback", "error", err)
+	}
+	// Refreshes that read the weights while they were being saved would
+	// route reads again.
+	c.mu.Lock()
+	c.generation++
+	c.mu.Unlock()
+}
@@ -0,0 +1,45 @@
+// internal/cutover/cutover.go
+package cutover
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Operations that can be routed, named like the repository metrics.
+const (
+	OpGetByID  = "get_by_id"
+	OpGetByIDs = "get_by_ids"
+)
+
+// Operations lists the routable operations.
+var Operations = []string{OpGetByID, OpGetByIDs}
+
+// ErrUnknownOperation is returned for operations not in Operations.
+var ErrUnknownOperation = errors.New("cutover: unknown operation")
+
+// ErrInvalidWeight is returned for weights outside 0 to 100.
+var ErrInvalidWeight = errors.New("cutover: weight must be between 0 and 100")
+
+// Route is the share of an operation's reads served by the secondary slot.
+type Route struct {
+	Operation string `json:"operation"`
+	// Weight is a percentage from 0 to 100. A product keeps its slot for a
+	// given weight, and products routed at a lower weight stay routed at a
+	// higher one.
+	Weight float64 `json:"weight"`
+	// Note says why the weight was last changed, such as a rollback.
+	Note      string    `json:"note,omitempty"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Store keeps the routes, so weights survive restarts and are shared by
+// every instance.
+type Store interface {
+	// Routes returns the stored routes. Operations never set are absent.
+	Routes(ctx context.Context) ([]Route, error)
+	// SaveRoutes replaces the stored routes of the given operations.
+	SaveRoutes(ctx context.Context, routes []Route) error
+}
@@ -0,0 +1,315 @@
+package cutover_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"product-management/internal/auth"
+	"product-management/internal/config"
+	"product-management/internal/cutover"
+	"product-management/internal/domain"
+	"product-management/internal/handler"
+	"product-management/internal/idmap"
+	"product-management/internal/repository/memory"
+	"product-management/internal/repository/mysql/mysqltest"
+	"product-management/internal/repository/sqlite"
+	"product-management/internal/service"
+	"product-management/internal/tenant"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = config.CutoverConfig{
+	Enabled:         true,
+	RefreshInterval: time.Hour,
+	ErrorThreshold:  0.5,
+	MinRequests:     4,
+	Window:          time.Minute,
+}
+
+// stores open an empty store of each kind.
+var stores = map[string]func(t *testing.T) cutover.Store{
+	"mysql": func(t *testing.T) cutover.Store {
+		store := cutover.NewMySQLStore(mysqltest.New(t))
+		require.NoError(t, store.EnsureSchema(context.Background()))
+		return store
+	},
+	"sqlite": func(t *testing.T) cutover.Store {
+		db, err := sqlite.Open(filepath.Join(t.TempDir(), "produk.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		store := cutover.NewSQLiteStore(db)
+		require.NoError(t, store.EnsureSchema(context.Background()))
+		return store
+	},
+}
+
+// flakyRepo fails lookups while down is set.
+type flakyRepo struct {
+	*memory.MemoryProductRepository
+	down  atomic.Bool
+	reads atomic.Int32
+}
+
+func (r *flakyRepo) GetProductById(ctx context.Context, id string) (*domain.Product, error) {
+	r.reads.Add(1)
+	if r.down.Load() {
+		return nil, errors.New("server selection timeout")
+	}
+	return r.MemoryProductRepository.GetProductById(ctx, id)
+}
+
+func (r *flakyRepo) GetProductsByIds(ctx context.Context, ids []string) ([]domain.Product, error) {
+	r.reads.Add(1)
+	if r.down.Load() {
+		return nil, errors.New("server selection timeout")
+	}
+	return r.MemoryProductRepository.GetProductsByIds(ctx, ids)
+}
+
+func (r *flakyRepo) SearchProducts(ctx context.Context, filter domain.ProductFilter, page domain.Page) ([]domain.Product, error) {
+	r.reads.Add(1)
+	if r.down.Load() {
+		return nil, errors.New("server selection timeout")
+	}
+	return r.MemoryProductRepository.SearchProducts(ctx, filter, page)
+}
+
+// removeCopy deletes the secondary's copy of the product called name.
+func (r *flakyRepo) removeCopy(t *testing.T, ctx context.Context, name string) {
+	replica, err := domain.FindByName(ctx, r.MemoryProductRepository, name)
+	require.NoError(t, err)
+	require.NoError(t, r.MemoryProductRepository.DeleteProduct(ctx, replica.ID))
+}
+
+// setup fills both slots with the same products and records the copies.
+// The secondary's IDs are offset from the primary's, and its product under
+// the first primary ID is another one. The description tells which slot
+// answered.
+func setup(t *testing.T, store cutover.Store, count int) (*service.ProductService, *cutover.Controller, *flakyRepo, []string, context.Context) {
+	ctx := tenant.WithTenant(context.Background(), "shop-a")
+	primary := memory.NewMemoryProductRepository()
+	secondary := &flakyRepo{MemoryProductRepository: memory.NewMemoryProductRepository()}
+	copies := idmap.NewMemoryStore()
+	require.NoError(t, secondary.Create(ctx, &domain.Product{Name: "tempe", Description: "mongodb", Price: 5000, Stock: 7}))
+	var ids []string
+	for i := range count {
+		p := &domain.Product{Name: fmt.Sprintf("kecap-%d", i), Description: "mysql", Price: 12000, Stock: 10}
+		require.NoError(t, primary.Create(ctx, p))
+		replica := &domain.Product{Name: p.Name, Description: "mongodb", Price: 12000, Stock: 10}
+		require.NoError(t, secondary.Create(ctx, replica))
+		require.NotEqual(t, p.ID, replica.ID)
+		require.NoError(t, copies.Save(ctx, p.ID, replica.ID))
+		ids = append(ids, p.ID)
+	}
+	productService, err := service.NewProductService(primary, secondary)
+	require.NoError(t, err)
+	productService.SetIDMap(copies)
+	controller := cutover.New(store, testConfig)
+	productService.SetRouter(controller)
+	return productService, controller, secondary, ids, ctx
+}
+
+// servedBySecondary returns the IDs whose lookups the secondary answered.
+func servedBySecondary(t *testing.T, productService *service.ProductService, ctx context.Context, ids []string) map[string]bool {
+	served := map[string]bool{}
+	for i, id := range ids {
+		p, err := productService.GetProductById(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("kecap-%d", i), p.Name, "product %s", id)
+		if p.Description == "mongodb" {
+			served[id] = true
+		}
+	}
+	return served
+}
+
+func TestRoutingIsStickyAndGrowsWithTheWeight(t *testing.T) {
+	productService, controller, _, ids, ctx := setup(t, stores["sqlite"](t), 400)
+	assert.Empty(t, servedBySecondary(t, productService, ctx, ids), "reads stay on the primary until a weight is set")
+
+	previous := map[string]bool{}
+	for _, weight := range []float64{1, 10, 50, 100} {
+		_, err := controller.SetWeight(ctx, cutover.OpGetByID, weight, "ops")
+		require.NoError(t, err)
+		served := servedBySecondary(t, productService, ctx, ids)
+		assert.Equal(t, served, servedBySecondary(t, productService, ctx, ids), "weight %v", weight)
+		for id := range previous {
+			assert.True(t, served[id], "product %s routed at a lower weight stays routed", id)
+		}
+		share := float64(len(served)) / float64(len(ids)) * 100
+		assert.InDelta(t, weight, share, 6, "weight %v", weight)
+		previous = served
+	}
+	assert.Len(t, previous, len(ids))
+	assert.Zero(t, controller.Status().Window.Errors)
+}
+
+func TestRoutedBatchLookupsFallBackToThePrimary(t *testing.T) {
+	productService, controller, secondary, ids, ctx := setup(t, stores["sqlite"](t), 20)
+	_, err := controller.SetWeight(ctx, cutover.OpGetByIDs, 50, "ops")
+	require.NoError(t, err)
+	// The secondary misses one routed product.
+	routedIndex := -1
+	for i, id := range ids {
+		if controller.Secondary(ctx, cutover.OpGetByIDs, id) {
+			routedIndex = i
+			break
+		}
+	}
+	require.NotEqual(t, -1, routedIndex)
+	secondary.removeCopy(t, ctx, fmt.Sprintf("kecap-%d", routedIndex))
+
+	found, err := productService.GetProductsByIds(ctx, append(ids, "404"))
+	require.NoError(t, err)
+	require.Len(t, found, len(ids))
+	routed := 0
+	for i, id := range ids {
+		want := "mysql"
+		if controller.Secondary(ctx, cutover.OpGetByIDs, id) {
+			routed++
+			if i != routedIndex {
+				want = "mongodb"
+			}
+		}
+		assert.Equal(t, fmt.Sprintf("kecap-%d", i), found[id].Name)
+		assert.Equal(t, want, found[id].Description, "product %s", id)
+	}
+	window := controller.Status().Window
+	assert.Equal(t, routed, window.Reads)
+	assert.Equal(t, 1, window.Errors, "the missing copy counts against the secondary")
+
+	// A failing secondary is not seen by the caller.
+	secondary.down.Store(true)
+	found, err = productService.GetProductsByIds(ctx, ids)
+	require.NoError(t, err)
+	require.Len(t, found, len(ids))
+	for _, p := range found {
+		assert.Equal(t, "mysql", p.Description)
+	}
+}
+
+func TestMissingCopiesRollBackEveryWeight(t *testing.T) {
+	store := stores["sqlite"](t)
+	productService, controller, secondary, ids, ctx := setup(t, store, 50)
+	for _, op := range cutover.Operations {
+		_, err := controller.SetWeight(ctx, op, 100, "ops")
+		require.NoError(t, err)
+	}
+	assert.Len(t, servedBySecondary(t, productService, ctx, ids[:testConfig.MinRequests]), testConfig.MinRequests)
+
+	// The secondary lost some products, e.g. writes that never reached it.
+	for i := testConfig.MinRequests; i < 2*testConfig.MinRequests; i++ {
+		secondary.removeCopy(t, ctx, fmt.Sprintf("kecap-%d", i))
+	}
+	for _, id := range ids[testConfig.MinRequests : 2*testConfig.MinRequests] {
+		p, err := productService.GetProductById(ctx, id)
+		require.NoError(t, err, "reads fall back to the primary")
+		assert.Equal(t, "mysql", p.Description)
+	}
+	reads := secondary.reads.Load()
+	assert.Empty(t, servedBySecondary(t, productService, ctx, ids))
+	assert.Equal(t, reads, secondary.reads.Load(), "rolled back reads skip the secondary")
+
+	for _, r := range controller.Status().Routes {
+		assert.Zero(t, r.Weight, r.Operation)
+		assert.Equal(t, "rolled back: 4 of 8 get_by_id reads failed", r.Note)
+	}
+	// The rollback is stored for the other instances.
+	other := cutover.New(store, testConfig)
+	require.NoError(t, other.Load(context.Background()))
+	for _, r := range other.Status().Routes {
+		assert.Zero(t, r.Weight, r.Operation)
+		assert.Equal(t, "cutover", r.UpdatedBy)
+	}
+}
+
+func TestFailingSecondaryRollsBack(t *testing.T) {
+	productService, controller, secondary, ids, ctx := setup(t, stores["sqlite"](t), 10)
+	_, err := controller.SetWeight(ctx, cutover.OpGetByID, 100, "ops")
+	require.NoError(t, err)
+	secondary.down.Store(true)
+	for _, id := range ids[:testConfig.MinRequests] {
+		p, err := productService.GetProductById(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "mysql", p.Description)
+	}
+	assert.Zero(t, controller.Status().Routes[0].Weight)
+	assert.Equal(t, "rolled back: 4 of 4 get_by_id reads failed", controller.Status().Routes[0].Note)
+}
+
+func TestWeightsArePersisted(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			controller := cutover.New(store, testConfig)
+			_, err := controller.SetWeight(context.Background(), cutover.OpGetByID, 10, "ops")
+			require.NoError(t, err)
+			_, err = controller.SetWeight(context.Background(), cutover.OpGetByID, 12.5, "ops")
+			require.NoError(t, err)
+			assert.ErrorIs(t, func() error {
+				_, err := controller.SetWeight(context.Background(), "list", 10, "ops")
+				return err
+			}(), cutover.ErrUnknownOperation)
+
+			restarted := cutover.New(store, testConfig)
+			require.NoError(t, restarted.Load(context.Background()))
+			routes := restarted.Status().Routes
+			require.Len(t, routes, 2)
+			assert.Equal(t, cutover.OpGetByID, routes[0].Operation)
+			assert.Equal(t, 12.5, routes[0].Weight)
+			assert.Equal(t, "ops", routes[0].UpdatedBy)
+			assert.Equal(t, cutover.OpGetByIDs, routes[1].Operation)
+			assert.Zero(t, routes[1].Weight)
+		})
+	}
+}
+
+func TestCutoverAdminAPI(t *testing.T) {
+	controller := cutover.New(stores["sqlite"](t), testConfig)
+	h := cutover.NewHandler(controller)
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		principal := &auth.Principal{Subject: "ops", Role: auth.RoleAdmin, TenantID: c.Get("X-Bound-Tenant")}
+		c.SetUserContext(auth.WithPrincipal(c.UserContext(), principal))
+		return c.Next()
+	})
+	app.Get("/cutover", h.GetStatus)
+	app.Put("/cutover/:operation", h.SetWeight)
+
+	put := func(operation, body string, header ...string) int {
+		req := httptest.NewRequest("PUT", "/cutover/"+operation, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if len(header) > 0 {
+			req.Header.Set("X-Bound-Tenant", header[0])
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+	assert.Equal(t, fiber.StatusOK, put(cutover.OpGetByIDs, `{"weight": 10}`))
+	assert.Equal(t, fiber.StatusNotFound, put("list", `{"weight": 10}`))
+	assert.Equal(t, fiber.StatusUnprocessableEntity, put(cutover.OpGetByID, `{"weight": 101}`))
+	assert.Equal(t, fiber.StatusBadRequest, put(cutover.OpGetByID, `{}`))
+	// Tenant admins cannot move every tenant's reads.
+	assert.Equal(t, fiber.StatusForbidden, put(cutover.OpGetByID, `{"weight": 100}`, "shop-a"))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/cutover", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var status cutover.Status
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	require.Len(t, status.Routes, 2)
+	assert.Zero(t, status.Routes[0].Weight)
+	assert.Equal(t, 10.0, status.Routes[1].Weight)
+	assert.Equal(t, "ops", status.Routes[1].UpdatedBy)
+}
@@ -0,0 +1,65 @@
+// internal/cutover/handler.go
+package cutover
+
+import (
+	"errors"
+	"product-management/internal/auth"
+	"product-management/internal/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler serves the cutover admin API. Routing spans every tenant, so it
+// is only served to principals not bound to one.
+type Handler struct {
+	controller *Controller
+}
+
+// NewHandler creates a Handler.
+func NewHandler(controller *Controller) *Handler {
+	return &Handler{controller: controller}
+}
+
+// errPerTenant refuses principals bound to a tenant.
+var errPerTenant = fiber.NewError(fiber.StatusForbidden, "Cutover is not managed per tenant")
+
+type weightInput struct {
+	Weight *float64 `json:"weight"`
+}
+
+// GetStatus returns the weight of every operation and the routed reads
+// counted towards a rollback.
+func (h *Handler) GetStatus(c *fiber.Ctx) error {
+	if !h.allowed(c) {
+		return errPerTenant
+	}
+	return c.JSON(h.controller.Status())
+}
+
+// SetWeight changes the share of an operation's reads served by the
+// secondary, such as 1, 10, 50 and then 100.
+func (h *Handler) SetWeight(c *fiber.Ctx) error {
+	if !h.allowed(c) {
+		return errPerTenant
+	}
+	var input weightInput
+	if err := c.BodyParser(&input); err != nil || input.Weight == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input")
+	}
+	route, err := h.controller.SetWeight(c.UserContext(), c.Params("operation"), *input.Weight, auth.SubjectFromContext(c.UserContext()))
+	switch {
+	case errors.Is(err, ErrUnknownOperation):
+		return domain.NewError(domain.ErrNotFound, "Operation "+c.Params("operation")+" not found", err)
+	case errors.Is(err, ErrInvalidWeight):
+		return &domain.Error{Kind: domain.ErrValidation, Message: "Invalid weight", Err: err,
+			Fields: []domain.FieldError{{Field: "weight", Reason: "must be between 0 and 100"}}}
+	case err != nil:
+		return err
+	}
+	return c.JSON(route)
+}
+
+func (h *Handler) allowed(c *fiber.Ctx) bool {
+	principal := auth.PrincipalFromContext(c.UserContext())
+	return principal != nil && principal.TenantID == ""
+}
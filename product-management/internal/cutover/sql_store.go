@@ -0,0 +1,89 @@
+// internal/cutover/sql_store.go
+package cutover
+
+import (
+	"context"
+	"database/sql"
+
+	"product-management/internal/durable"
+)
+
+var schema = durable.Schema{
+	MySQL: []string{`
+CREATE TABLE IF NOT EXISTS cutover_routes (
+	operation VARCHAR(32) PRIMARY KEY,
+	weight DOUBLE NOT NULL,
+	note VARCHAR(255) NOT NULL,
+	updated_by VARCHAR(255) NOT NULL,
+	updated_at DATETIME(6) NOT NULL
+)`},
+	SQLite: []string{`
+CREATE TABLE IF NOT EXISTS cutover_routes (
+	operation TEXT PRIMARY KEY,
+	weight REAL NOT NULL,
+	note TEXT NOT NULL,
+	updated_by TEXT NOT NULL,
+	updated_at DATETIME NOT NULL
+)`},
+}
+
+// SQLStore keeps the routes in MySQL, or SQLite in the embedded mode.
+type SQLStore struct {
+	db durable.DB
+}
+
+// NewMySQLStore creates a store backed by a MySQL db.
+func NewMySQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: durable.MySQL(db, schema)}
+}
+
+// NewSQLiteStore creates a store backed by a SQLite db.
+func NewSQLiteStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: durable.SQLite(db, schema)}
+}
+
+// EnsureSchema creates the cutover_routes table if it does not exist.
+func (s *SQLStore) EnsureSchema(ctx context.Context) error {
+	return s.db.EnsureSchema(ctx)
+}
+
+// Routes implements Store.
+func (s *SQLStore) Routes(ctx context.Context) ([]Route, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT operation, weight, note, updated_by, updated_at FROM cutover_routes ORDER BY operation")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var routes []Route
+	for rows.Next() {
+		var r Route
+		if err := rows.Scan(&r.Operation, &r.Weight, &r.Note, &r.UpdatedBy, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+		routes = append(routes, r)
+	}
+	return routes, rows.Err()
+}
+
+// SaveRoutes implements Store. The routes are replaced in one transaction,
+// so a rollback never leaves some operations routed.
+func (s *SQLStore) SaveRoutes(ctx context.Context, routes []Route) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, r := range routes {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM cutover_routes WHERE operation = ?", r.Operation); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO cutover_routes (operation, weight, note, updated_by, updated_at)
+			VALUES (?, ?, ?, ?, ?)`,
+			r.Operation, r.Weight, r.Note, r.UpdatedBy, r.UpdatedAt.UTC()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,87 @@
+// internal/domain/errors.go
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Error kinds. Repositories translate driver errors into them and the
+// transports map them to status codes, so neither has to know the other.
+var (
+	// ErrNotFound means the product does not exist in the caller's tenant.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means the change clashes with stored data, such as a
+	// product name already used in the tenant.
+	ErrConflict = errors.New("conflict")
+	// ErrValidation means the input breaks a product invariant.
+	ErrValidation = errors.New("validation failed")
+	// ErrUnavailable means a backend could not be reached; retrying later
+	// may succeed.
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// Error is a domain error with a message that is safe to show to clients.
+// errors.Is matches both its Kind and its cause, so callers can still check
+// driver errors such as sql.ErrNoRows.
+type Error struct {
+	Kind    error
+	Message string
+	// Fields lists the invalid input fields of a validation error.
+	Fields []FieldError
+	// Err is the underlying cause. It is logged but not shown to clients.
+	Err error
+}
+
+// FieldError describes one invalid input field.
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+func (f FieldError) String() string {
+	return f.Field + " " + f.Reason
+}
+
+// JoinFields renders field errors as one line, such as for transports
+// without structured error details.
+func JoinFields(fields []FieldError) string {
+	lines := make([]string, len(fields))
+	for i, f := range fields {
+		lines[i] = f.String()
+	}
+	return strings.Join(lines, "; ")
+}
+
+// NewError returns an error of kind with a client-facing message.
+func NewError(kind error, message string, cause error) *Error {
+	return &Error{Kind: kind, Message: message, Err: cause}
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if len(e.Fields) > 0 {
+		msg += " (" + JoinFields(e.Fields) + ")"
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// ProductNotFound reports a missing product, wrapping the driver's cause.
+func ProductNotFound(cause error) error {
+	return NewError(ErrNotFound, "Product not found", cause)
+}
+
+// InvalidCursor reports a Page.After that cannot be an ID of the store.
+func InvalidCursor(cause error) error {
+	return NewError(ErrValidation, "Invalid page cursor", cause)
+}
@@ -1,23 +1,153 @@
 // internal/domain/product.go
 package domain
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 // Product represents the product entity with validation tags
 type Product struct {
 	ID          string             `json:"id,omitempty"` // Tambahkan omitempty untuk mengabaikan jika kosong
 	MongoID     primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	TenantID    string             `json:"tenant_id,omitempty" bson:"tenant_id"` // Set by the repository from the context
 	Name        string             `json:"name" validate:"required,max=100"`
 	Description string             `json:"description" validate:"required"`
 	Price       float64            `json:"price" validate:"required,gt=0"`
 	Stock       int                `json:"stock" validate:"required"`
 }
 
+// Validate checks the product invariants shared by every transport; they
+// match ProductInput in the OpenAPI document. The error is of kind
+// ErrValidation with one FieldError per problem.
+func (p *Product) Validate() error {
+	var fields []FieldError
+	if p.Name == "" {
+		fields = append(fields, FieldError{"name", "is required"})
+	} else if utf8.RuneCountInString(p.Name) > 100 {
+		fields = append(fields, FieldError{"name", "must be at most 100 characters"})
+	}
+	if p.Description == "" {
+		fields = append(fields, FieldError{"description", "is required"})
+	}
+	if !(p.Price > 0) {
+		fields = append(fields, FieldError{"price", "must be greater than 0"})
+	}
+	if p.Stock < 0 {
+		fields = append(fields, FieldError{"stock", "must not be negative"})
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return &Error{Kind: ErrValidation, Message: "Invalid product", Fields: fields}
+}
+
+// Key returns the ID the product is looked up by in the backend it was read
+// from: the ObjectID of MongoDB documents, the ID otherwise.
+func (p *Product) Key() string {
+	if !p.MongoID.IsZero() {
+		return p.MongoID.Hex()
+	}
+	return p.ID
+}
+
 // ProductRepository defines the methods for interacting with products in the repository
 type ProductRepository interface {
-	Create(product *Product) error
-	GetAllProducts() ([]Product, error)
-	GetProductById(id string) (*Product, error)
-	UpdateProduct(id string, product *Product) error
-	DeleteProduct(id string) error
+	Create(ctx context.Context, product *Product) error
+	GetAllProducts(ctx context.Context) ([]Product, error)
+	GetProductById(ctx context.Context, id string) (*Product, error)
+	// GetProductsByIds returns the products among ids in one round trip.
+	// Unknown IDs are skipped, so the result may be shorter than ids.
+	GetProductsByIds(ctx context.Context, ids []string) ([]Product, error)
+	UpdateProduct(ctx context.Context, id string, product *Product) error
+	DeleteProduct(ctx context.Context, id string) error
+}
+
+// ProductFilter narrows a product search. Zero fields do not filter.
+type ProductFilter struct {
+	// NameContains matches names containing it, ignoring case.
+	NameContains string
+	// Names matches products called one of them exactly, such as to look
+	// up a batch of products by name with one query.
+	Names    []string
+	MinPrice float64
+	MaxPrice float64
+	// InStock keeps only products with stock left.
+	InStock bool
+}
+
+// Matches reports whether p passes the filter.
+func (f ProductFilter) Matches(p Product) bool {
+	if f.NameContains != "" && !strings.Contains(strings.ToLower(p.Name), strings.ToLower(f.NameContains)) {
+		return false
+	}
+	if len(f.Names) > 0 && !slices.Contains(f.Names, p.Name) {
+		return false
+	}
+	if f.MinPrice > 0 && p.Price < f.MinPrice {
+		return false
+	}
+	if f.MaxPrice > 0 && p.Price > f.MaxPrice {
+		return false
+	}
+	return !f.InStock || p.Stock > 0
+}
+
+// Page selects a window of an ordered result. A zero Limit means no limit.
+type Page struct {
+	Offset int
+	Limit  int
+	// After starts the window after the product with this ID in the
+	// store's order. Unlike Offset it neither skips nor repeats products
+	// when others are created or deleted between pages.
+	After string
+}
+
+// ProductSearcher is implemented by repositories that can filter and page
+// products in the store. Results are ordered by creation.
+type ProductSearcher interface {
+	SearchProducts(ctx context.Context, filter ProductFilter, page Page) ([]Product, error)
+}
+
+// ProductStats summarises a catalog.
+type ProductStats struct {
+	Count int
+	Stock int
+}
+
+// ProductCounter is implemented by repositories that can summarise the
+// products of the context's tenant, or of all tenants, in one query without
+// loading them.
+type ProductCounter interface {
+	CountProducts(ctx context.Context) (ProductStats, error)
+}
+
+// FindByName returns the product of the context's tenant called name, or
+// nil if there is none. Each backend generates its own IDs, so the name is
+// what identifies a product across backends.
+func FindByName(ctx context.Context, s ProductSearcher, name string) (*Product, error) {
+	candidates, err := s.SearchProducts(ctx, ProductFilter{Names: []string{name}}, Page{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range candidates {
+		if candidates[i].Name == name {
+			return &candidates[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// ProductUpserter is implemented by repositories that can write many
+// products in one round trip, such as to copy a catalog between backends.
+// Each product is created, or replaces the description, price and stock of
+// its tenant's product with the same name; later duplicates in products win.
+// Contexts spanning all tenants write to each product's TenantID. Generated
+// IDs are not reported back.
+type ProductUpserter interface {
+	UpsertProducts(ctx context.Context, products []Product) error
 }
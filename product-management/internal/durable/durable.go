@@ -0,0 +1,102 @@
+// internal/durable/durable.go
+package durable
+
+import (
+	"context"
+	"database/sql"
+	"math/rand/v2"
+	"time"
+
+	"product-management/internal/domain"
+	"product-management/internal/tenant"
+)
+
+// ErrAllTenants is returned by SingleTenant for contexts spanning every
+// tenant.
+var ErrAllTenants = domain.NewError(domain.ErrValidation, "Admin calls need a single tenant; set the "+tenant.Header+" header", nil)
+
+// MaxErrorLength matches the last_error columns of the queues.
+const MaxErrorLength = 1024
+
+// Schema is the DDL of a store in both SQL dialects it runs on.
+type Schema struct {
+	MySQL  []string
+	SQLite []string
+}
+
+// DB is the database of a store: MySQL, or SQLite in the single-file
+// embedded mode. Stores write queries that run on both, so only the schema
+// differs; times compare correctly as long as the pool writes them in
+// SQLite's format, as sqlite.Open does.
+type DB struct {
+	*sql.DB
+	ddl []string
+}
+
+// MySQL returns a MySQL db with the MySQL DDL of schema.
+func MySQL(db *sql.DB, schema Schema) DB {
+	return DB{DB: db, ddl: schema.MySQL}
+}
+
+// SQLite returns a SQLite db with the SQLite DDL of schema.
+func SQLite(db *sql.DB, schema Schema) DB {
+	return DB{DB: db, ddl: schema.SQLite}
+}
+
+// EnsureSchema creates the tables and indexes that do not exist yet.
+func (d DB) EnsureSchema(ctx context.Context) error {
+	for _, ddl := range d.ddl {
+		if _, err := d.ExecContext(ctx, ddl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Now returns the current time at the precision MySQL stores.
+func Now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// LeaseUntil returns the end of the lease on a claimed job whose attempt
+// may take up to timeout. The lease outlasts one attempt, so the jobs of a
+// crashed worker become due again.
+func LeaseUntil(timeout time.Duration) time.Time {
+	return Now().Add(2 * timeout)
+}
+
+// Backoff returns the wait after the given number of failed attempts: base
+// doubled per attempt, capped at limit, with up to half of it randomised
+// so retries of one outage spread out.
+func Backoff(attempts int, base, limit time.Duration) time.Duration {
+	wait := base
+	for i := 1; i < attempts && wait < limit; i++ {
+		wait *= 2
+	}
+	wait = min(wait, limit)
+	if wait <= 1 {
+		return wait
+	}
+	half := wait / 2
+	return half + rand.N(half)
+}
+
+// TruncateError shortens an error message to fit MaxErrorLength.
+func TruncateError(msg string) string {
+	if len(msg) > MaxErrorLength {
+		return msg[:MaxErrorLength]
+	}
+	return msg
+}
+
+// SingleTenant returns the tenant of an admin API call.
+func SingleTenant(ctx context.Context) (string, error) {
+	id, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return "", err
+	}
+	if all {
+		return "", ErrAllTenants
+	}
+	return id, nil
+}
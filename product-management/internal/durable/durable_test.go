@@ -0,0 +1,48 @@
+package durable_test
+
+import (
+	"context"
+	"product-management/internal/durable"
+	"product-management/internal/tenant"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoffDoublesUpToTheLimit(t *testing.T) {
+	base, limit := time.Second, 10*time.Second
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 10: limit} {
+		for range 20 {
+			wait := durable.Backoff(attempts, base, limit)
+			assert.GreaterOrEqual(t, wait, want/2, "attempts %d", attempts)
+			assert.Less(t, wait, want, "attempts %d", attempts)
+		}
+	}
+}
+
+func TestSingleTenantRefusesAllTenants(t *testing.T) {
+	id, err := durable.SingleTenant(tenant.WithTenant(context.Background(), "acme"))
+	require.NoError(t, err)
+	assert.Equal(t, "acme", id)
+
+	_, err = durable.SingleTenant(tenant.WithAllTenants(context.Background()))
+	assert.ErrorIs(t, err, durable.ErrAllTenants)
+}
+
+func TestWorkerPollsWhenWokenUntilStopped(t *testing.T) {
+	var polls atomic.Int32
+	w := durable.NewWorker(time.Hour, func(context.Context) { polls.Add(1) })
+	w.Start()
+
+	w.Wake()
+	require.Eventually(t, func() bool { return polls.Load() == 1 }, time.Second, time.Millisecond)
+
+	require.NoError(t, w.Stop(context.Background()))
+	require.NoError(t, w.Stop(context.Background()), "Stop is idempotent")
+	w.Wake()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(1), polls.Load())
+}
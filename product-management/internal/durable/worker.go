@@ -0,0 +1,71 @@
+// internal/durable/worker.go
+package durable
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Worker runs a poll function in the background every interval, or sooner
+// when woken, until it is stopped.
+type Worker struct {
+	interval time.Duration
+	poll     func(ctx context.Context)
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewWorker creates a Worker. Call Start to begin polling.
+func NewWorker(interval time.Duration, poll func(ctx context.Context)) *Worker {
+	return &Worker{
+		interval: interval,
+		poll:     poll,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Wake asks the worker to poll now instead of at the next interval.
+func (w *Worker) Wake() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start polls until Stop is called.
+func (w *Worker) Start() {
+	go w.run()
+}
+
+// Stop stops polling and waits for a running poll, or for ctx.
+func (w *Worker) Stop(ctx context.Context) error {
+	w.once.Do(func() { close(w.stop) })
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Worker) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+		w.poll(context.Background())
+	}
+}
@@ -0,0 +1,56 @@
+// internal/events/events.go
+package events
+
+import (
+	"context"
+	"time"
+
+	"product-management/internal/domain"
+)
+
+// Product lifecycle event types.
+const (
+	ProductCreated = "product.created"
+	ProductUpdated = "product.updated"
+	ProductDeleted = "product.deleted"
+	// StockLow is derived by consumers from a created or updated event
+	// whose stock crossed their threshold; ProductService never emits it.
+	StockLow = "stock.low"
+	// StockChanged is derived by consumers from an updated event whose
+	// stock differs between Before and After.
+	StockChanged = "stock.changed"
+)
+
+// Event describes a committed product change. Before is nil for creations
+// and After is nil for deletions.
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	TenantID   string          `json:"tenant_id"`
+	ProductID  string          `json:"product_id"`
+	Actor      string          `json:"actor"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Before     *domain.Product `json:"before"`
+	After      *domain.Product `json:"after"`
+}
+
+// Publisher receives events after ProductService commits a change.
+// Publish must not block on slow consumers; a failure to publish never
+// fails the change itself.
+type Publisher interface {
+	Publish(ctx context.Context, event Event)
+}
+
+// CrossedBelow reports whether the event moved stock from above threshold
+// to at or below it, or created a product already at or below it.
+func (e Event) CrossedBelow(threshold int) bool {
+	if e.After == nil || e.After.Stock > threshold {
+		return false
+	}
+	return e.Before == nil || e.Before.Stock > threshold
+}
+
+// StockDiffers reports whether an update changed the product's stock.
+func (e Event) StockDiffers() bool {
+	return e.Type == ProductUpdated && e.Before != nil && e.After != nil && e.Before.Stock != e.After.Stock
+}
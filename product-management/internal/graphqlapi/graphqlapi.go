@@ -0,0 +1,81 @@
+// internal/graphqlapi/graphqlapi.go
+package graphqlapi
+
+import (
+	"context"
+	_ "embed"
+	"time"
+
+	"product-management/internal/openapi"
+	"product-management/internal/problem"
+	"product-management/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/graph-gophers/graphql-go"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+const (
+	// batchWait is how long the loader collects lookups before querying.
+	batchWait = 2 * time.Millisecond
+	// maxBatch flushes a batch early once it holds this many IDs.
+	maxBatch = 100
+)
+
+// Handler serves GraphQL queries over POST.
+type Handler struct {
+	schema   *graphql.Schema
+	products *service.ProductService
+}
+
+// New parses the schema and binds it to ProductService. validator may be
+// nil to skip input validation.
+func New(products *service.ProductService, validator *openapi.Validator) (*Handler, error) {
+	schema, err := graphql.ParseSchema(schemaSDL, &resolver{products: products, validator: validator},
+		graphql.MaxDepth(8),
+		graphql.MaxParallelism(maxBatch),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{schema: schema, products: products}, nil
+}
+
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Serve executes one GraphQL request. Authentication and tenant scoping
+// come from the user context like the REST handlers; role checks for
+// mutations happen in the resolvers.
+func (h *Handler) Serve(c *fiber.Ctx) error {
+	var req request
+	if err := c.BodyParser(&req); err != nil || req.Query == "" {
+		return problem.Respond(c, fiber.StatusBadRequest, "Invalid input")
+	}
+
+	ctx := c.UserContext()
+	ctx = context.WithValue(ctx, loaderKey{}, newLoader(ctx, h.batch, batchWait, maxBatch))
+	return c.JSON(h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
+
+// RateLimit passes requests that execute a mutation through the writes
+// limiter and all others through reads, so GraphQL writes share the budget
+// of the REST ones.
+func RateLimit(reads, writes fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req request
+		if err := c.BodyParser(&req); err == nil && mutates(req.Query, req.OperationName) {
+			return writes(c)
+		}
+		return reads(c)
+	}
+}
+
+func (h *Handler) batch(ctx context.Context, ids []string) (map[string]service.SourcedProduct, error) {
+	return h.products.LookupProducts(ctx, ids)
+}
@@ -0,0 +1,279 @@
+package graphqlapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"product-management/internal/auth"
+	"product-management/internal/config"
+	"product-management/internal/domain"
+	"product-management/internal/graphqlapi"
+	"product-management/internal/openapi"
+	"product-management/internal/repository/memory"
+	"product-management/internal/service"
+	"product-management/internal/tenant"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRepo counts the lookups of a memory repository.
+type countingRepo struct {
+	*memory.MemoryProductRepository
+
+	byID  atomic.Int32
+	byIDs atomic.Int32
+}
+
+func (r *countingRepo) GetProductById(ctx context.Context, id string) (*domain.Product, error) {
+	r.byID.Add(1)
+	return r.MemoryProductRepository.GetProductById(ctx, id)
+}
+
+func (r *countingRepo) GetProductsByIds(ctx context.Context, ids []string) ([]domain.Product, error) {
+	r.byIDs.Add(1)
+	return r.MemoryProductRepository.GetProductsByIds(ctx, ids)
+}
+
+func newApp(t *testing.T, role auth.Role) (*fiber.App, *countingRepo) {
+	app, mysqlRepo, _ := newAppWithBackends(t, role, config.BackendMySQL, config.BackendMongoDB)
+	return app, mysqlRepo
+}
+
+// newAppWithBackends seeds four products into the primary slot and names
+// the slots after primary and secondary.
+func newAppWithBackends(t *testing.T, role auth.Role, primary, secondary string) (*fiber.App, *countingRepo, *memory.MemoryProductRepository) {
+	mysqlRepo := &countingRepo{MemoryProductRepository: memory.NewMemoryProductRepository()}
+	mongoRepo := memory.NewMemoryProductRepository()
+	products, err := service.NewProductService(mysqlRepo, mongoRepo)
+	require.NoError(t, err)
+	products.SetListConfig(config.ListConfig{}, primary, secondary)
+	validator, err := openapi.NewValidator(openapi.Options{})
+	require.NoError(t, err)
+	handler, err := graphqlapi.New(products, validator)
+	require.NoError(t, err)
+
+	ctx := tenant.WithTenant(auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "user-1", Role: role}), "shop-a")
+	for _, name := range []string{"kecap", "sambal", "saos", "garam"} {
+		require.NoError(t, mysqlRepo.Create(ctx, &domain.Product{Name: name, Description: "botol", Price: 1000, Stock: 5}))
+	}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.SetUserContext(ctx)
+		return c.Next()
+	})
+	app.Post("/graphql", handler.Serve)
+	return app, mysqlRepo, mongoRepo
+}
+
+type response struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func query(t *testing.T, app *fiber.App, q string, variables map[string]any) response {
+	body, _ := json.Marshal(map[string]any{"query": q, "variables": variables})
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	raw, _ := io.ReadAll(resp.Body)
+	var out response
+	require.NoError(t, json.Unmarshal(raw, &out))
+	return out
+}
+
+func TestNestedLookupsAreBatched(t *testing.T) {
+	app, mysqlRepo := newApp(t, auth.RoleViewer)
+
+	out := query(t, app, `{
+		a: product(id: "1") { name }
+		b: product(id: "2") { name }
+		c: product(id: "404") { name }
+		d: productsByIds(ids: ["3", "1"]) { name source }
+	}`, nil)
+
+	require.Empty(t, out.Errors)
+	assert.JSONEq(t, `{"name":"kecap"}`, string(out.Data["a"]))
+	assert.JSONEq(t, `{"name":"sambal"}`, string(out.Data["b"]))
+	assert.JSONEq(t, `null`, string(out.Data["c"]))
+	assert.JSONEq(t, `[{"name":"saos","source":"MYSQL"},{"name":"kecap","source":"MYSQL"}]`, string(out.Data["d"]))
+	assert.Equal(t, int32(1), mysqlRepo.byIDs.Load(), "lookups should share one batch")
+	assert.Equal(t, int32(0), mysqlRepo.byID.Load())
+}
+
+func TestCursorPagination(t *testing.T) {
+	app, _ := newApp(t, auth.RoleViewer)
+	const page = `query($after: String) {
+		products(first: 3, after: $after) {
+			totalCount
+			edges { node { name } }
+			pageInfo { hasNextPage endCursor }
+		}
+	}`
+
+	var conn struct {
+		TotalCount int
+		Edges      []struct{ Node struct{ Name string } }
+		PageInfo   struct {
+			HasNextPage bool
+			EndCursor   string
+		}
+	}
+	out := query(t, app, page, nil)
+	require.Empty(t, out.Errors)
+	require.NoError(t, json.Unmarshal(out.Data["products"], &conn))
+	assert.Equal(t, 4, conn.TotalCount)
+	assert.Len(t, conn.Edges, 3)
+	assert.True(t, conn.PageInfo.HasNextPage)
+
+	out = query(t, app, page, map[string]any{"after": conn.PageInfo.EndCursor})
+	require.Empty(t, out.Errors)
+	require.NoError(t, json.Unmarshal(out.Data["products"], &conn))
+	require.Len(t, conn.Edges, 1)
+	assert.Equal(t, "garam", conn.Edges[0].Node.Name)
+	assert.False(t, conn.PageInfo.HasNextPage)
+
+	out = query(t, app, page, map[string]any{"after": "not a cursor"})
+	assert.NotEmpty(t, out.Errors)
+}
+
+func TestPagesFollowTheConfiguredBackends(t *testing.T) {
+	app, _, secondary := newAppWithBackends(t, auth.RoleViewer, config.BackendPostgres, config.BackendSQLite)
+	ctx := tenant.WithTenant(context.Background(), "shop-a")
+	for _, name := range []string{"cuka", "merica"} {
+		require.NoError(t, secondary.Create(ctx, &domain.Product{Name: name, Description: "botol", Price: 1000, Stock: 5}))
+	}
+	const page = `query($after: String, $source: Source = ALL) {
+		products(first: 3, after: $after, source: $source) {
+			totalCount
+			edges { node { name source } }
+			pageInfo { hasNextPage endCursor }
+		}
+	}`
+	type connection struct {
+		TotalCount int
+		Edges      []struct{ Node struct{ Name, Source string } }
+		PageInfo   struct {
+			HasNextPage bool
+			EndCursor   string
+		}
+	}
+
+	// The second page starts in the primary and crosses into the secondary.
+	var conn connection
+	var got []string
+	after := any(nil)
+	for {
+		out := query(t, app, page, map[string]any{"after": after})
+		require.Empty(t, out.Errors)
+		require.NoError(t, json.Unmarshal(out.Data["products"], &conn))
+		assert.Equal(t, 6, conn.TotalCount)
+		for _, e := range conn.Edges {
+			got = append(got, e.Node.Name+"@"+e.Node.Source)
+		}
+		if !conn.PageInfo.HasNextPage {
+			break
+		}
+		after = conn.PageInfo.EndCursor
+	}
+	assert.Equal(t, []string{"kecap@POSTGRES", "sambal@POSTGRES", "saos@POSTGRES", "garam@POSTGRES", "cuka@SQLITE", "merica@SQLITE"}, got)
+
+	out := query(t, app, page, map[string]any{"source": "SQLITE"})
+	require.Empty(t, out.Errors)
+	conn = connection{}
+	require.NoError(t, json.Unmarshal(out.Data["products"], &conn))
+	assert.Equal(t, 2, conn.TotalCount)
+	assert.False(t, conn.PageInfo.HasNextPage)
+
+	out = query(t, app, page, map[string]any{"source": "MYSQL"})
+	require.NotEmpty(t, out.Errors)
+	assert.Contains(t, out.Errors[0].Message, "not configured")
+}
+
+func TestConnectionReportsSources(t *testing.T) {
+	app, _ := newApp(t, auth.RoleViewer)
+	out := query(t, app, `{ products { partial sources { backend status count } } }`, nil)
+	require.Empty(t, out.Errors)
+	assert.JSONEq(t, `{"partial":false,"sources":[
+		{"backend":"mysql","status":"ok","count":4},
+		{"backend":"mongodb","status":"ok","count":0}
+	]}`, string(out.Data["products"]))
+}
+
+func TestMutations(t *testing.T) {
+	app, _ := newApp(t, auth.RoleAdmin)
+
+	out := query(t, app, `mutation { createProduct(input: {name: "merica", description: "bubuk", price: 500, stock: 2}) { id tenantId } }`, nil)
+	require.Empty(t, out.Errors)
+	assert.JSONEq(t, `{"id":"5","tenantId":"shop-a"}`, string(out.Data["createProduct"]))
+
+	out = query(t, app, `mutation { updateProduct(id: "5", input: {name: "merica", description: "butir", price: 700, stock: 2}) { description price } }`, nil)
+	require.Empty(t, out.Errors)
+	assert.JSONEq(t, `{"description":"butir","price":700}`, string(out.Data["updateProduct"]))
+
+	out = query(t, app, `mutation { createProduct(input: {name: "", description: "bubuk", price: 0, stock: 2}) { id } }`, nil)
+	require.NotEmpty(t, out.Errors)
+	assert.Contains(t, out.Errors[0].Message, "Invalid input")
+
+	out = query(t, app, `mutation { deleteProduct(id: "404") }`, nil)
+	require.NotEmpty(t, out.Errors)
+	assert.Contains(t, out.Errors[0].Message, "not found")
+
+	out = query(t, app, `mutation { deleteProduct(id: "5") }`, nil)
+	require.Empty(t, out.Errors)
+	assert.JSONEq(t, `true`, string(out.Data["deleteProduct"]))
+}
+
+func TestMutationsRequireRole(t *testing.T) {
+	app, _ := newApp(t, auth.RoleViewer)
+
+	out := query(t, app, `mutation { deleteProduct(id: "1") }`, nil)
+	require.NotEmpty(t, out.Errors)
+	assert.Contains(t, out.Errors[0].Message, "Insufficient role")
+}
+
+func TestMutationsAreLimitedAsWrites(t *testing.T) {
+	var class string
+	limiter := func(name string) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			class = name
+			return c.Next()
+		}
+	}
+	app := fiber.New()
+	app.Post("/graphql", graphqlapi.RateLimit(limiter("reads"), limiter("writes")), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	tests := []struct {
+		query, operationName, want string
+	}{
+		{`{ products { totalCount } }`, "", "reads"},
+		{`query Mutation { product(id: "1") { name } }`, "", "reads"},
+		{`# mutation { deleteProduct(id: "1") }
+		query { product(id: "mutation") { name } }`, "", "reads"},
+		{`mutation { deleteProduct(id: "1") }`, "", "writes"},
+		{`mutation Remove($id: ID!) @trace { deleteProduct(id: $id) }`, "", "writes"},
+		{`query Get { product(id: "1") { name } } mutation Remove { deleteProduct(id: "1") }`, "Get", "reads"},
+		{`query Get { product(id: "1") { name } } mutation Remove { deleteProduct(id: "1") }`, "Remove", "writes"},
+		{`fragment f on Product { name } mutation { createProduct(input: {name: "a\"}", description: """{""", price: 1, stock: 1}) { ...f } }`, "", "writes"},
+	}
+	for _, tt := range tests {
+		body, _ := json.Marshal(map[string]any{"query": tt.query, "operationName": tt.operationName})
+		req := httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		_, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, class, tt.query)
+	}
+}
@@ -0,0 +1,108 @@
+// internal/graphqlapi/loader.go
+package graphqlapi
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"product-management/internal/service"
+)
+
+// batchFunc loads many products at once, keyed by the requested ID.
+type batchFunc func(ctx context.Context, ids []string) (map[string]service.SourcedProduct, error)
+
+// loader collects the product lookups that resolvers issue concurrently
+// within a short window and serves them with one batchFunc call, caching
+// results for the rest of the request. One loader is created per request.
+type loader struct {
+	ctx      context.Context
+	fetch    batchFunc
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	cache   map[string]*result
+	pending []string
+	timer   *time.Timer
+}
+
+type result struct {
+	done    chan struct{}
+	product service.SourcedProduct
+	err     error
+}
+
+func newLoader(ctx context.Context, fetch batchFunc, wait time.Duration, maxBatch int) *loader {
+	return &loader{ctx: ctx, fetch: fetch, wait: wait, maxBatch: maxBatch, cache: make(map[string]*result)}
+}
+
+// Load returns the product with id and its backend. The product is nil when
+// it does not exist.
+func (l *loader) Load(ctx context.Context, id string) (service.SourcedProduct, error) {
+	l.mu.Lock()
+	r, ok := l.cache[id]
+	if !ok {
+		r = &result{done: make(chan struct{})}
+		l.cache[id] = r
+		l.pending = append(l.pending, id)
+		switch {
+		case len(l.pending) >= l.maxBatch:
+			l.dispatchLocked()
+		case l.timer == nil:
+			l.timer = time.AfterFunc(l.wait, l.dispatch)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.product, r.err
+	case <-ctx.Done():
+		return service.SourcedProduct{}, ctx.Err()
+	}
+}
+
+// Forget drops a cached result, for example after a mutation.
+func (l *loader) Forget(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if r, ok := l.cache[id]; ok {
+		select {
+		case <-r.done:
+			delete(l.cache, id)
+		default:
+			// Still in flight; waiters keep the pending result.
+		}
+	}
+}
+
+func (l *loader) dispatch() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.dispatchLocked()
+}
+
+func (l *loader) dispatchLocked() {
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	if len(l.pending) == 0 {
+		return
+	}
+	ids := l.pending
+	l.pending = nil
+	results := make([]*result, len(ids))
+	for i, id := range ids {
+		results[i] = l.cache[id]
+	}
+
+	go func() {
+		products, err := l.fetch(l.ctx, ids)
+		for i, id := range ids {
+			results[i].product, results[i].err = products[id], err
+			close(results[i].done)
+		}
+	}()
+}
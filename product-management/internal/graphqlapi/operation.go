@@ -0,0 +1,106 @@
+// internal/graphqlapi/operation.go
+package graphqlapi
+
+// mutates reports whether executing doc as operationName runs a mutation.
+// It only scans the top level of the document, which is enough to tell
+// operations apart before the schema parses it. Without a name every
+// operation counts, since one mutation among several is still a write.
+func mutates(doc, operationName string) bool {
+	for _, op := range operations(doc) {
+		if op.kind == "mutation" && (operationName == "" || op.name == operationName) {
+			return true
+		}
+	}
+	return false
+}
+
+// definition is a top-level definition of a GraphQL document.
+type definition struct {
+	// kind is query, mutation, subscription or fragment.
+	kind string
+	name string
+}
+
+// operations lists the top-level definitions of doc. A selection set
+// without a keyword is a query.
+func operations(doc string) []definition {
+	var defs []definition
+	depth := 0
+	// start is set between definitions; naming while the name of the
+	// current one may follow.
+	start, naming := true, false
+	for i := 0; i < len(doc); i++ {
+		switch c := doc[i]; {
+		case c == '#':
+			for i < len(doc) && doc[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			i = skipString(doc, i)
+		case c == '{' || c == '(' || c == '[':
+			if depth == 0 && start {
+				defs = append(defs, definition{kind: "query"})
+			}
+			start, naming = false, false
+			depth++
+		case c == '}' || c == ')' || c == ']':
+			depth--
+			if depth == 0 && c == '}' {
+				start = true
+			}
+		case c == '@':
+			naming = false
+		case isNameStart(c):
+			j := i
+			for j < len(doc) && isNameChar(doc[j]) {
+				j++
+			}
+			if depth == 0 {
+				switch {
+				case start:
+					defs = append(defs, definition{kind: doc[i:j]})
+					start, naming = false, true
+				case naming:
+					defs[len(defs)-1].name = doc[i:j]
+					naming = false
+				}
+			}
+			i = j - 1
+		}
+	}
+	return defs
+}
+
+// skipString returns the index of the quote closing the string or block
+// string that opens at i.
+func skipString(doc string, i int) int {
+	if len(doc) >= i+3 && doc[i:i+3] == `"""` {
+		for j := i + 3; j+3 <= len(doc); j++ {
+			if doc[j:min(j+4, len(doc))] == `\"""` {
+				j += 3
+				continue
+			}
+			if doc[j:j+3] == `"""` {
+				return j + 2
+			}
+		}
+		return len(doc)
+	}
+	for j := i + 1; j < len(doc); j++ {
+		switch doc[j] {
+		case '\\':
+			j++
+		case '"', '\n':
+			return j
+		}
+	}
+	return len(doc)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || '0' <= c && c <= '9'
+}
@@ -0,0 +1,336 @@
+// internal/graphqlapi/resolver.go
+package graphqlapi
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"product-management/internal/auth"
+	"product-management/internal/domain"
+	"product-management/internal/openapi"
+	"product-management/internal/service"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+const (
+	// sourceAll pages both slots. The other Source values are the
+	// configured backend names in upper case.
+	sourceAll = "ALL"
+
+	// maxPageSize bounds products(first:).
+	maxPageSize = 100
+)
+
+type resolver struct {
+	products  *service.ProductService
+	validator *openapi.Validator
+}
+
+type loaderKey struct{}
+
+func loaderFrom(ctx context.Context) *loader {
+	return ctx.Value(loaderKey{}).(*loader)
+}
+
+// Queries
+
+func (r *resolver) Product(ctx context.Context, args struct{ ID graphql.ID }) (*productResolver, error) {
+	product, err := loaderFrom(ctx).Load(ctx, string(args.ID))
+	if err != nil {
+		return nil, internalError(ctx, "Failed to retrieve product", err)
+	}
+	return wrap(product), nil
+}
+
+func (r *resolver) ProductsByIds(ctx context.Context, args struct{ IDs []graphql.ID }) ([]*productResolver, error) {
+	if len(args.IDs) > maxPageSize {
+		return nil, errors.New("too many ids")
+	}
+	l := loaderFrom(ctx)
+	out := make([]*productResolver, len(args.IDs))
+	for i, id := range args.IDs {
+		product, err := l.Load(ctx, string(id))
+		if err != nil {
+			return nil, internalError(ctx, "Failed to retrieve products", err)
+		}
+		out[i] = wrap(product)
+	}
+	return out, nil
+}
+
+type productsArgs struct {
+	First  int32
+	After  *string
+	Source string
+}
+
+// Products pages through the repositories by the last product seen, so a
+// page costs one search and one count per backend however deep it is.
+func (r *resolver) Products(ctx context.Context, args productsArgs) (*connectionResolver, error) {
+	first := int(args.First)
+	if first < 0 || first > maxPageSize {
+		return nil, errors.New("first must be between 0 and 100")
+	}
+	backend := ""
+	if args.Source != sourceAll {
+		backend = strings.ToLower(args.Source)
+	}
+	var after service.Position
+	if args.After != nil {
+		var err error
+		if after, err = decodeCursor(*args.After); err != nil {
+			return nil, err
+		}
+	}
+
+	page, err := r.products.PageProducts(ctx, backend, after, first)
+	if err != nil {
+		return nil, internalError(ctx, "Failed to retrieve products", err)
+	}
+	return &connectionResolver{page: page}, nil
+}
+
+// Mutations
+
+func (r *resolver) CreateProduct(ctx context.Context, args struct{ Input productInput }) (*productResolver, error) {
+	if err := requireRole(ctx, auth.RoleEditor); err != nil {
+		return nil, err
+	}
+	product, err := r.input(args.Input)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.products.CreateProduct(ctx, product); err != nil {
+		return nil, internalError(ctx, "Failed to create product", err)
+	}
+	primary, _ := r.products.Backends()
+	return wrap(service.SourcedProduct{Product: product, Backend: primary}), nil
+}
+
+func (r *resolver) UpdateProduct(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input productInput
+}) (*productResolver, error) {
+	if err := requireRole(ctx, auth.RoleEditor); err != nil {
+		return nil, err
+	}
+	id := string(args.ID)
+	product, err := r.input(args.Input)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := r.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.products.UpdateProduct(ctx, id, product); err != nil {
+		return nil, internalError(ctx, "Failed to update product", err)
+	}
+	loaderFrom(ctx).Forget(id)
+	product.ID, product.MongoID, product.TenantID = existing.Product.ID, existing.Product.MongoID, existing.Product.TenantID
+	return wrap(service.SourcedProduct{Product: product, Backend: existing.Backend}), nil
+}
+
+func (r *resolver) DeleteProduct(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	if err := requireRole(ctx, auth.RoleAdmin); err != nil {
+		return false, err
+	}
+	id := string(args.ID)
+	if _, err := r.find(ctx, id); err != nil {
+		return false, err
+	}
+	if err := r.products.DeleteProduct(ctx, id); err != nil {
+		return false, internalError(ctx, "Failed to delete product", err)
+	}
+	loaderFrom(ctx).Forget(id)
+	return true, nil
+}
+
+// find loads a product that a mutation is about to change.
+func (r *resolver) find(ctx context.Context, id string) (service.SourcedProduct, error) {
+	product, err := loaderFrom(ctx).Load(ctx, id)
+	if isNotFound(err) || (err == nil && product.Product == nil) {
+		return service.SourcedProduct{}, errors.New("Product with ID " + id + " not found")
+	}
+	if err != nil {
+		return service.SourcedProduct{}, internalError(ctx, "Failed to retrieve product", err)
+	}
+	return product, nil
+}
+
+type productInput struct {
+	Name        string
+	Description string
+	Price       float64
+	Stock       int32
+}
+
+// input validates against the REST ProductInput schema so both APIs accept
+// the same products.
+func (r *resolver) input(in productInput) (*domain.Product, error) {
+	product := &domain.Product{Name: in.Name, Description: in.Description, Price: in.Price, Stock: int(in.Stock)}
+	if r.validator == nil {
+		return product, nil
+	}
+	raw, err := json.Marshal(map[string]any{
+		"name":        product.Name,
+		"description": product.Description,
+		"price":       product.Price,
+		"stock":       product.Stock,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if details := r.validator.ValidateSchema("ProductInput", raw); len(details) > 0 {
+		return nil, errors.New("Invalid input: " + strings.Join(details, "; "))
+	}
+	return product, nil
+}
+
+func requireRole(ctx context.Context, role auth.Role) error {
+	principal := auth.PrincipalFromContext(ctx)
+	if principal == nil || !principal.Role.Allows(role) {
+		return errors.New("Insufficient role: " + string(role) + " required")
+	}
+	return nil
+}
+
+func isNotFound(err error) bool {
+	return errors.Is(err, domain.ErrNotFound)
+}
+
+// internalError returns the client-facing message of domain errors, such as
+// a name conflict. Other errors are logged and reported as msg, so driver
+// details do not leak into the errors array.
+func internalError(ctx context.Context, msg string, err error) error {
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) && domainErr.Message != "" {
+		if errors.Is(err, domain.ErrUnavailable) {
+			slog.WarnContext(ctx, msg, "error", err)
+		}
+		if len(domainErr.Fields) > 0 {
+			return errors.New(domainErr.Message + ": " + domain.JoinFields(domainErr.Fields))
+		}
+		return errors.New(domainErr.Message)
+	}
+	slog.ErrorContext(ctx, msg, "error", err)
+	return errors.New(msg)
+}
+
+// Products
+
+type productResolver struct {
+	p       *domain.Product
+	backend string
+}
+
+func wrap(p service.SourcedProduct) *productResolver {
+	if p.Product == nil {
+		return nil
+	}
+	return &productResolver{p: p.Product, backend: p.Backend}
+}
+
+func (r *productResolver) ID() graphql.ID {
+	if r.p.ID == "" && !r.p.MongoID.IsZero() {
+		return graphql.ID(r.p.MongoID.Hex())
+	}
+	return graphql.ID(r.p.ID)
+}
+
+func (r *productResolver) MongoID() *string {
+	if r.p.MongoID.IsZero() {
+		return nil
+	}
+	id := r.p.MongoID.Hex()
+	return &id
+}
+
+func (r *productResolver) TenantID() string    { return r.p.TenantID }
+func (r *productResolver) Name() string        { return r.p.Name }
+func (r *productResolver) Description() string { return r.p.Description }
+func (r *productResolver) Price() float64      { return r.p.Price }
+func (r *productResolver) Stock() int32        { return int32(r.p.Stock) }
+
+func (r *productResolver) Source() string { return strings.ToUpper(r.backend) }
+
+// Connections
+
+type connectionResolver struct {
+	page *service.ProductPage
+}
+
+func (c *connectionResolver) Edges() []*edgeResolver {
+	edges := make([]*edgeResolver, len(c.page.Products))
+	for i, p := range c.page.Products {
+		edges[i] = &edgeResolver{p: p}
+	}
+	return edges
+}
+
+func (c *connectionResolver) PageInfo() *pageInfoResolver {
+	info := &pageInfoResolver{hasNext: c.page.HasNext}
+	if n := len(c.page.Products); n > 0 {
+		cursor := encodeCursor(c.page.Products[n-1])
+		info.endCursor = &cursor
+	}
+	return info
+}
+
+func (c *connectionResolver) TotalCount() int32 { return int32(c.page.Total) }
+func (c *connectionResolver) Partial() bool     { return c.page.Partial }
+
+func (c *connectionResolver) Sources() []*sourceResolver {
+	sources := make([]*sourceResolver, len(c.page.Sources))
+	for i := range c.page.Sources {
+		sources[i] = &sourceResolver{s: c.page.Sources[i]}
+	}
+	return sources
+}
+
+type sourceResolver struct {
+	s service.Source
+}
+
+func (r *sourceResolver) Backend() string { return r.s.Backend }
+func (r *sourceResolver) Status() string  { return r.s.Status }
+func (r *sourceResolver) Count() int32    { return int32(r.s.Count) }
+
+type edgeResolver struct {
+	p service.SourcedProduct
+}
+
+func (e *edgeResolver) Cursor() string         { return encodeCursor(e.p) }
+func (e *edgeResolver) Node() *productResolver { return wrap(e.p) }
+
+type pageInfoResolver struct {
+	hasNext   bool
+	endCursor *string
+}
+
+func (p *pageInfoResolver) HasNextPage() bool  { return p.hasNext }
+func (p *pageInfoResolver) EndCursor() *string { return p.endCursor }
+
+// Cursors are opaque to clients: the configured backend of the last product
+// seen and its ID there, base64 encoded.
+
+func encodeCursor(p service.SourcedProduct) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(p.Backend + ":" + p.Product.Key()))
+}
+
+func decodeCursor(cursor string) (service.Position, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return service.Position{}, errors.New("invalid cursor")
+	}
+	backend, id, ok := strings.Cut(string(raw), ":")
+	if !ok || backend == "" || id == "" {
+		return service.Position{}, errors.New("invalid cursor")
+	}
+	return service.Position{Backend: backend, ID: id}, nil
+}
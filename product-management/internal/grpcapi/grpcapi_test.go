@@ -0,0 +1,263 @@
+package grpcapi_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net"
+	"product-management/internal/auth"
+	"product-management/internal/domain"
+	"product-management/internal/grpcapi"
+	"product-management/internal/idmap"
+	"product-management/internal/openapi"
+	"product-management/internal/service"
+	"product-management/internal/tenant"
+	"strconv"
+	"sync"
+	"testing"
+
+	productsv1 "product-management/api/products/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// memoryRepo stores products per tenant and fails lookups with notFound.
+type memoryRepo struct {
+	mu       sync.Mutex
+	notFound error
+	products map[string]domain.Product
+	nextID   int
+}
+
+func newMemoryRepo(notFound error) *memoryRepo {
+	return &memoryRepo{notFound: notFound, products: make(map[string]domain.Product)}
+}
+
+func (r *memoryRepo) Create(ctx context.Context, product *domain.Product) error {
+	id, _, err := tenant.Scope(ctx)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if product.ID == "" {
+		r.nextID++
+		product.ID = strconv.Itoa(r.nextID)
+	}
+	product.TenantID = id
+	r.products[product.ID] = *product
+	return nil
+}
+
+func (r *memoryRepo) GetAllProducts(ctx context.Context) ([]domain.Product, error) {
+	id, _, err := tenant.Scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []domain.Product
+	for _, p := range r.products {
+		if p.TenantID == id {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryRepo) GetProductById(ctx context.Context, id string) (*domain.Product, error) {
+	tenantID, _, err := tenant.Scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[id]
+	if !ok || p.TenantID != tenantID {
+		return nil, r.notFound
+	}
+	return &p, nil
+}
+
+func (r *memoryRepo) GetProductsByIds(ctx context.Context, ids []string) ([]domain.Product, error) {
+	var out []domain.Product
+	for _, id := range ids {
+		if p, err := r.GetProductById(ctx, id); err == nil {
+			out = append(out, *p)
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryRepo) UpdateProduct(ctx context.Context, id string, product *domain.Product) error {
+	existing, err := r.GetProductById(ctx, id)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product.ID, product.TenantID = id, existing.TenantID
+	r.products[id] = *product
+	return nil
+}
+
+func (r *memoryRepo) DeleteProduct(ctx context.Context, id string) error {
+	if _, err := r.GetProductById(ctx, id); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.products, id)
+	return nil
+}
+
+// stubKeyStore fails lookups of keys stored without a principal, as if
+// the database were down.
+type stubKeyStore map[string]*auth.Principal
+
+func (s stubKeyStore) Lookup(ctx context.Context, keyHash string) (*auth.Principal, error) {
+	if p, ok := s[keyHash]; ok {
+		if p == nil {
+			return nil, errors.New("dial tcp: connection refused")
+		}
+		return p, nil
+	}
+	return nil, auth.ErrInvalidAPIKey
+}
+
+func newClient(t *testing.T) *grpc.ClientConn {
+	products, err := service.NewProductService(newMemoryRepo(domain.ProductNotFound(sql.ErrNoRows)), newMemoryRepo(domain.ProductNotFound(mongo.ErrNoDocuments)))
+	require.NoError(t, err)
+	products.SetIDMap(idmap.NewMemoryStore())
+	validator, err := openapi.NewValidator(openapi.Options{})
+	require.NoError(t, err)
+	keys := stubKeyStore{
+		auth.HashAPIKey("viewer-key"): {Subject: "apikey:viewer", Role: auth.RoleViewer},
+		auth.HashAPIKey("admin-key"):  {Subject: "apikey:admin", Role: auth.RoleAdmin},
+		auth.HashAPIKey("acme-key"):   {Subject: "apikey:acme", Role: auth.RoleAdmin, TenantID: "acme"},
+		auth.HashAPIKey("down-key"):   nil,
+	}
+
+	srv, _ := grpcapi.New(products, grpcapi.Options{
+		Authenticator: auth.NewAuthenticator(nil, keys),
+		DefaultTenant: "default",
+		Validator:     validator,
+	})
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func withKey(key string, pairs ...string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), append([]string{"x-api-key", key}, pairs...)...)
+}
+
+func input(name string) *productsv1.ProductInput {
+	return &productsv1.ProductInput{Name: name, Description: "botol", Price: 12500, Stock: 4}
+}
+
+func TestProductLifecycle(t *testing.T) {
+	client := productsv1.NewProductServiceClient(newClient(t))
+	ctx := withKey("admin-key")
+
+	created, err := client.Create(ctx, &productsv1.CreateRequest{Product: input("kecap")})
+	require.NoError(t, err)
+	id := created.GetProduct().GetId()
+	assert.NotEmpty(t, id)
+	assert.Equal(t, "default", created.GetProduct().GetTenantId())
+
+	got, err := client.Get(ctx, &productsv1.GetRequest{Id: id})
+	require.NoError(t, err)
+	assert.Equal(t, "kecap", got.GetProduct().GetName())
+
+	_, err = client.Update(ctx, &productsv1.UpdateRequest{Id: id, Product: input("kecap manis")})
+	require.NoError(t, err)
+
+	stream, err := client.List(ctx, &productsv1.ListRequest{Source: productsv1.ListRequest_SOURCE_MYSQL})
+	require.NoError(t, err)
+	var names []string
+	for {
+		p, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, p.GetName())
+	}
+	assert.Equal(t, []string{"kecap manis"}, names)
+
+	batch, err := client.BatchGet(ctx, &productsv1.BatchGetRequest{Ids: []string{id, "999"}})
+	require.NoError(t, err)
+	assert.Len(t, batch.GetProducts(), 1)
+	assert.Equal(t, []string{"999"}, batch.GetNotFoundIds())
+
+	_, err = client.Delete(ctx, &productsv1.DeleteRequest{Id: id})
+	require.NoError(t, err)
+	_, err = client.Get(ctx, &productsv1.GetRequest{Id: id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestAuthAndTenant(t *testing.T) {
+	client := productsv1.NewProductServiceClient(newClient(t))
+
+	_, err := client.Get(context.Background(), &productsv1.GetRequest{Id: "1"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.Get(withKey("wrong-key"), &productsv1.GetRequest{Id: "1"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.Get(withKey("down-key"), &productsv1.GetRequest{Id: "1"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	_, err = client.Create(withKey("viewer-key"), &productsv1.CreateRequest{Product: input("kecap")})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.Get(withKey("acme-key", "x-tenant-id", "globex"), &productsv1.GetRequest{Id: "1"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// Products are only visible to their tenant.
+	created, err := client.Create(withKey("acme-key"), &productsv1.CreateRequest{Product: input("kecap")})
+	require.NoError(t, err)
+	assert.Equal(t, "acme", created.GetProduct().GetTenantId())
+	_, err = client.Get(withKey("admin-key"), &productsv1.GetRequest{Id: created.GetProduct().GetId()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestInputValidation(t *testing.T) {
+	client := productsv1.NewProductServiceClient(newClient(t))
+
+	_, err := client.Create(withKey("admin-key"), &productsv1.CreateRequest{
+		Product: &productsv1.ProductInput{Name: "kecap", Description: "botol", Price: 0, Stock: 1},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "/price")
+
+	_, err = client.Create(withKey("admin-key"), &productsv1.CreateRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestHealthIsPublic(t *testing.T) {
+	resp, err := healthpb.NewHealthClient(newClient(t)).Check(context.Background(), &healthpb.HealthCheckRequest{
+		Service: productsv1.ProductService_ServiceDesc.ServiceName,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+}
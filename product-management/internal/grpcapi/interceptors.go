@@ -0,0 +1,148 @@
+// internal/grpcapi/interceptors.go
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	productsv1 "product-management/api/products/v1"
+	"product-management/internal/auth"
+	"product-management/internal/domain"
+	"product-management/internal/logging"
+	"product-management/internal/tenant"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys mirror the REST headers; gRPC metadata keys are lower case.
+var (
+	authorizationKey = "authorization"
+	apiKeyKey        = strings.ToLower(auth.APIKeyHeader)
+	tenantKey        = strings.ToLower(tenant.Header)
+	requestIDKey     = strings.ToLower(logging.RequestIDHeader)
+)
+
+// maxRequestIDLength matches the bound of the REST request ID middleware.
+const maxRequestIDLength = 128
+
+// roles lists the minimum role per product RPC, matching the REST routes.
+// Methods not listed here (health, reflection) are public.
+var roles = map[string]auth.Role{
+	productsv1.ProductService_Create_FullMethodName:   auth.RoleEditor,
+	productsv1.ProductService_Get_FullMethodName:      auth.RoleViewer,
+	productsv1.ProductService_List_FullMethodName:     auth.RoleViewer,
+	productsv1.ProductService_Update_FullMethodName:   auth.RoleEditor,
+	productsv1.ProductService_Delete_FullMethodName:   auth.RoleAdmin,
+	productsv1.ProductService_BatchGet_FullMethodName: auth.RoleViewer,
+}
+
+type interceptors struct {
+	authenticator *auth.Authenticator
+	defaultTenant string
+}
+
+func (i *interceptors) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	ctx, err := i.prepare(ctx, info.FullMethod)
+	var resp any
+	if err == nil {
+		resp, err = handler(ctx, req)
+	}
+	logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+func (i *interceptors) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx, err := i.prepare(ss.Context(), info.FullMethod)
+	if err == nil {
+		err = handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+	logCall(ctx, info.FullMethod, start, err)
+	return err
+}
+
+// prepare does for an RPC what the REST middleware chain does for a
+// request: request ID, authentication, role check and tenant scope.
+func (i *interceptors) prepare(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	id := first(md, requestIDKey)
+	if id == "" || len(id) > maxRequestIDLength {
+		id = uuid.NewString()
+	}
+	ctx = logging.WithRequestID(ctx, id)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+
+	role, ok := roles[method]
+	if !ok {
+		return ctx, nil
+	}
+
+	principal := &auth.Principal{Subject: "anonymous", Role: auth.RoleAdmin}
+	if i.authenticator != nil {
+		var err error
+		principal, err = i.authenticator.Authenticate(ctx, first(md, apiKeyKey), first(md, authorizationKey))
+		if errors.Is(err, domain.ErrUnavailable) {
+			return ctx, toStatus(ctx, "Failed to authenticate", err)
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "Authentication failed", "error", err)
+			return ctx, status.Error(codes.Unauthenticated, "Unauthorized")
+		}
+	}
+	ctx = auth.WithPrincipal(ctx, principal)
+	if !principal.Role.Allows(role) {
+		return ctx, status.Error(codes.PermissionDenied, "Insufficient role: "+string(role)+" required")
+	}
+
+	tenantID, err := tenant.Resolve(principal, first(md, tenantKey), i.defaultTenant)
+	switch {
+	case errors.Is(err, tenant.ErrForbiddenTenant):
+		return ctx, status.Error(codes.PermissionDenied, "Access to the requested tenant is not allowed")
+	case errors.Is(err, tenant.ErrMissingTenant):
+		return ctx, status.Error(codes.InvalidArgument, "Missing "+tenantKey+" metadata")
+	case err != nil:
+		return ctx, status.Error(codes.InvalidArgument, "Invalid tenant ID")
+	}
+	return tenant.WithTenant(ctx, tenantID), nil
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		level = slog.LevelError
+	}
+	slog.Default().LogAttrs(ctx, level, "rpc completed",
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("duration", time.Since(start)),
+	)
+}
+
+func first(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// contextStream overrides the context of a server stream so handlers see
+// the principal and tenant set by the interceptor.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
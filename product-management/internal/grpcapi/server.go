@@ -0,0 +1,237 @@
+// internal/grpcapi/server.go
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	productsv1 "product-management/api/products/v1"
+	"product-management/internal/auth"
+	"product-management/internal/domain"
+	"product-management/internal/openapi"
+	"product-management/internal/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// maxBatchSize bounds BatchGet so one call cannot fan out unboundedly.
+const maxBatchSize = 100
+
+// Options configures the gRPC server.
+type Options struct {
+	// Authenticator verifies credentials from metadata. Nil disables
+	// authentication and treats every caller as an anonymous admin, like
+	// the REST server with AUTH_ENABLED=false.
+	Authenticator *auth.Authenticator
+	// DefaultTenant is used for principals that name no tenant.
+	DefaultTenant string
+	// Validator checks product input against the REST contract.
+	Validator *openapi.Validator
+}
+
+// Server implements productsv1.ProductServiceServer on ProductService.
+type Server struct {
+	productsv1.UnimplementedProductServiceServer
+	products  *service.ProductService
+	validator *openapi.Validator
+}
+
+// NewServer creates a Server.
+func NewServer(products *service.ProductService, validator *openapi.Validator) *Server {
+	return &Server{products: products, validator: validator}
+}
+
+// New builds a grpc.Server with the product, health and reflection
+// services registered. The returned health server reports SERVING until
+// Shutdown is called on it.
+func New(products *service.ProductService, opts Options) (*grpc.Server, *grpchealth.Server) {
+	i := &interceptors{authenticator: opts.Authenticator, defaultTenant: opts.DefaultTenant}
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(i.unary),
+		grpc.ChainStreamInterceptor(i.stream),
+	)
+	productsv1.RegisterProductServiceServer(srv, NewServer(products, opts.Validator))
+
+	healthServer := grpchealth.NewServer()
+	healthServer.SetServingStatus(productsv1.ProductService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, healthServer)
+	reflection.Register(srv)
+	return srv, healthServer
+}
+
+func (s *Server) Create(ctx context.Context, req *productsv1.CreateRequest) (*productsv1.CreateResponse, error) {
+	product, err := s.input(req.GetProduct())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.products.CreateProduct(ctx, product); err != nil {
+		return nil, toStatus(ctx, "Failed to create product", err)
+	}
+	return &productsv1.CreateResponse{Product: toProto(product)}, nil
+}
+
+func (s *Server) Get(ctx context.Context, req *productsv1.GetRequest) (*productsv1.GetResponse, error) {
+	product, err := s.get(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return &productsv1.GetResponse{Product: toProto(product)}, nil
+}
+
+func (s *Server) List(req *productsv1.ListRequest, stream grpc.ServerStreamingServer[productsv1.Product]) error {
+	ctx := stream.Context()
+
+	var (
+		products []domain.Product
+		err      error
+	)
+	switch req.GetSource() {
+	case productsv1.ListRequest_SOURCE_MYSQL:
+		products, err = s.products.GetMySQLProducts(ctx)
+	case productsv1.ListRequest_SOURCE_MONGODB:
+		products, err = s.products.GetMongoDBProducts(ctx)
+	case productsv1.ListRequest_SOURCE_UNSPECIFIED:
+		var list *service.ProductList
+		if list, err = s.products.ListProducts(ctx); err == nil {
+			products = list.Products
+			err = stream.SetHeader(sourcesMetadata(list))
+		}
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown source %d", req.GetSource())
+	}
+	if err != nil {
+		return toStatus(ctx, "Failed to retrieve products", err)
+	}
+
+	for i := range products {
+		if err := stream.Send(toProto(&products[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sourcesMetadata describes which backends answered a list, like the
+// X-Partial-Result and X-Product-Sources headers of the REST API.
+func sourcesMetadata(list *service.ProductList) metadata.MD {
+	md := metadata.Pairs("x-partial-result", strconv.FormatBool(list.Partial))
+	for _, source := range list.Sources {
+		md.Append("x-product-sources", source.String())
+	}
+	return md
+}
+
+func (s *Server) Update(ctx context.Context, req *productsv1.UpdateRequest) (*productsv1.UpdateResponse, error) {
+	product, err := s.input(req.GetProduct())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.get(ctx, req.GetId()); err != nil {
+		return nil, err
+	}
+	if err := s.products.UpdateProduct(ctx, req.GetId(), product); err != nil {
+		return nil, toStatus(ctx, "Failed to update product", err)
+	}
+	product.ID = req.GetId()
+	return &productsv1.UpdateResponse{Product: toProto(product)}, nil
+}
+
+func (s *Server) Delete(ctx context.Context, req *productsv1.DeleteRequest) (*productsv1.DeleteResponse, error) {
+	if _, err := s.get(ctx, req.GetId()); err != nil {
+		return nil, err
+	}
+	if err := s.products.DeleteProduct(ctx, req.GetId()); err != nil {
+		return nil, toStatus(ctx, "Failed to delete product", err)
+	}
+	return &productsv1.DeleteResponse{}, nil
+}
+
+func (s *Server) BatchGet(ctx context.Context, req *productsv1.BatchGetRequest) (*productsv1.BatchGetResponse, error) {
+	ids := req.GetIds()
+	if len(ids) > maxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d ids per batch", maxBatchSize)
+	}
+
+	found, err := s.products.GetProductsByIds(ctx, ids)
+	if err != nil {
+		return nil, toStatus(ctx, "Failed to retrieve products", err)
+	}
+	resp := &productsv1.BatchGetResponse{}
+	for _, id := range ids {
+		if product, ok := found[id]; ok {
+			resp.Products = append(resp.Products, toProto(product))
+		} else {
+			resp.NotFoundIds = append(resp.NotFoundIds, id)
+		}
+	}
+	return resp, nil
+}
+
+// get loads a product and maps a missing one to NotFound.
+func (s *Server) get(ctx context.Context, id string) (*domain.Product, error) {
+	if id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	product, err := s.products.GetProductById(ctx, id)
+	if err != nil {
+		return nil, toStatus(ctx, "Failed to retrieve product", err)
+	}
+	if product == nil {
+		return nil, status.Error(codes.NotFound, "Product with ID "+id+" not found")
+	}
+	return product, nil
+}
+
+// input validates a ProductInput against the REST ProductInput schema and
+// converts it to a domain product.
+func (s *Server) input(in *productsv1.ProductInput) (*domain.Product, error) {
+	if in == nil {
+		return nil, status.Error(codes.InvalidArgument, "product is required")
+	}
+	product := &domain.Product{
+		Name:        in.GetName(),
+		Description: in.GetDescription(),
+		Price:       in.GetPrice(),
+		Stock:       int(in.GetStock()),
+	}
+	if s.validator == nil {
+		return product, nil
+	}
+
+	raw, err := json.Marshal(map[string]any{
+		"name":        product.Name,
+		"description": product.Description,
+		"price":       product.Price,
+		"stock":       product.Stock,
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to validate product")
+	}
+	if details := s.validator.ValidateSchema("ProductInput", raw); len(details) > 0 {
+		return nil, status.Error(codes.InvalidArgument, "Request does not match the API contract: "+strings.Join(details, "; "))
+	}
+	return product, nil
+}
+
+func toProto(p *domain.Product) *productsv1.Product {
+	out := &productsv1.Product{
+		Id:          p.ID,
+		TenantId:    p.TenantID,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       p.Price,
+		Stock:       int32(p.Stock),
+	}
+	if !p.MongoID.IsZero() {
+		out.MongoId = p.MongoID.Hex()
+	}
+	return out
+}
@@ -0,0 +1,52 @@
+// internal/grpcapi/status.go
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"product-management/internal/domain"
+	"product-management/internal/tenant"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus maps a service error to a gRPC status. Unexpected errors are
+// logged and reported as Internal with msg, so driver details do not leak
+// to callers.
+func toStatus(ctx context.Context, msg string, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return status.Error(codes.NotFound, "Product not found")
+	case errors.Is(err, domain.ErrConflict):
+		return status.Error(codes.AlreadyExists, message(err, "Conflict"))
+	case errors.Is(err, domain.ErrValidation):
+		return status.Error(codes.InvalidArgument, message(err, "Invalid input"))
+	case errors.Is(err, domain.ErrUnavailable):
+		slog.WarnContext(ctx, msg, "error", err)
+		return status.Error(codes.Unavailable, message(err, "Service temporarily unavailable"))
+	case errors.Is(err, tenant.ErrMissingTenant), errors.Is(err, tenant.ErrInvalidTenant):
+		return status.Error(codes.InvalidArgument, "Invalid tenant")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	}
+	slog.ErrorContext(ctx, msg, "error", err)
+	return status.Error(codes.Internal, msg)
+}
+
+// message returns the client-facing message of a domain error with its
+// details, or fallback.
+func message(err error, fallback string) string {
+	var domainErr *domain.Error
+	if !errors.As(err, &domainErr) || domainErr.Message == "" {
+		return fallback
+	}
+	if len(domainErr.Fields) > 0 {
+		return domainErr.Message + ": " + domain.JoinFields(domainErr.Fields)
+	}
+	return domainErr.Message
+}
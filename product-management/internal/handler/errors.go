@@ -0,0 +1,97 @@
+// internal/handler/errors.go
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"product-management/internal/domain"
+	"product-management/internal/problem"
+	"product-management/internal/tenant"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// renderedKey marks a request whose error response has been written.
+const renderedKey = "handler.rendered"
+
+// ErrorHandler is the app's Fiber error handler. Handlers return errors
+// rather than writing them, and this renders them as problem details:
+// domain error kinds as 404, 409, 422 and 503, *fiber.Error with its own
+// code and anything else as a 500. Only messages written for clients are
+// sent; the full error of failures is logged with the request ID. A
+// request's error is rendered once; later calls leave the response as is.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	if c.Locals(renderedKey) != nil {
+		return nil
+	}
+	c.Locals(renderedKey, true)
+	p := toProblem(err)
+	switch {
+	case p.Status == fiber.StatusServiceUnavailable:
+		slog.WarnContext(c.UserContext(), "Backend unavailable", "method", c.Method(), "path", c.Path(), "error", err)
+	case p.Status >= fiber.StatusInternalServerError:
+		slog.ErrorContext(c.UserContext(), "Request failed", "method", c.Method(), "path", c.Path(), "error", err)
+	}
+	return problem.Write(c, p)
+}
+
+// RenderErrors renders errors returned by the rest of the chain with the
+// app's error handler and passes them on. Middleware registered before it
+// see the status sent and can still record the error.
+func RenderErrors() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+		return err
+	}
+}
+
+// toProblem describes err for clients.
+func toProblem(err error) *problem.Problem {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		if fiberErr.Code >= fiber.StatusInternalServerError {
+			return problem.New(fiberErr.Code, "")
+		}
+		return problem.New(fiberErr.Code, fiberErr.Message)
+	}
+
+	kinds := []struct {
+		kind   error
+		status int
+		typ    string
+		title  string
+	}{
+		{domain.ErrNotFound, fiber.StatusNotFound, problem.TypeNotFound, "Not Found"},
+		{domain.ErrConflict, fiber.StatusConflict, problem.TypeConflict, "Conflict"},
+		{domain.ErrValidation, fiber.StatusUnprocessableEntity, problem.TypeValidation, "Validation Failed"},
+		{domain.ErrUnavailable, fiber.StatusServiceUnavailable, problem.TypeUnavailable, "Service Unavailable"},
+	}
+	var domainErr *domain.Error
+	errors.As(err, &domainErr)
+	for _, k := range kinds {
+		if !errors.Is(err, k.kind) {
+			continue
+		}
+		p := &problem.Problem{Type: k.typ, Title: k.title, Status: k.status}
+		if domainErr != nil {
+			p.Detail = domainErr.Message
+			for _, f := range domainErr.Fields {
+				p.InvalidParams = append(p.InvalidParams, problem.InvalidParam{Name: f.Field, Reason: f.Reason})
+			}
+		}
+		return p
+	}
+
+	switch {
+	case errors.Is(err, tenant.ErrMissingTenant):
+		return problem.New(fiber.StatusBadRequest, "Missing "+tenant.Header+" header")
+	case errors.Is(err, tenant.ErrInvalidTenant):
+		return problem.New(fiber.StatusBadRequest, "Invalid tenant ID")
+	}
+	return problem.New(fiber.StatusInternalServerError, "")
+}
@@ -1,14 +1,18 @@
 package handler
 
 import (
-	"log"
+	"fmt"
 	"net/http" // Tambahkan ini untuk memperbaiki error 'undefined: http'
 	"product-management/internal/domain"
 	"product-management/internal/service"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// ProductHandler serves the product routes. Errors are returned to Fiber
+// and rendered by ErrorHandler.
 type ProductHandler struct {
 	productService *service.ProductService
 }
@@ -20,11 +24,9 @@ func NewProductHandler(productService *service.ProductService) *ProductHandler {
 }
 
 func (h *ProductHandler) GetMySQLProducts(c *fiber.Ctx) error {
-	products, err := h.productService.GetMySQLProducts()
+	products, err := h.productService.GetMySQLProducts(c.UserContext())
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Unable to fetch products",
-		})
+		return fmt.Errorf("retrieve MySQL products: %w", err)
 	}
 	return c.JSON(products)
 }
@@ -33,17 +35,12 @@ func (h *ProductHandler) GetMySQLProducts(c *fiber.Ctx) error {
 func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 	var product domain.Product
 	if err := c.BodyParser(&product); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid input",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input")
 	}
 
 	// Create product in database
-	err := h.productService.CreateProduct(&product)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create product",
-		})
+	if err := h.productService.CreateProduct(c.UserContext(), &product); err != nil {
+		return fmt.Errorf("create product: %w", err)
 	}
 
 	// Use messenger to send success message
@@ -53,36 +50,44 @@ func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 	})
 }
 
+// Headers describing which backends answered a product list.
+const (
+	// PartialResultHeader is "true" when a backend failed and its products
+	// are missing from the list.
+	PartialResultHeader = "X-Partial-Result"
+	// SourcesHeader lists each backend with its status, such as
+	// "mysql=ok;count=3, mongodb=timeout;count=0".
+	SourcesHeader = "X-Product-Sources"
+)
+
 // GetAllProducts retrieves all products from MySQL and MongoDB
 func (h *ProductHandler) GetAllProducts(c *fiber.Ctx) error {
-	products, err := h.productService.GetAllProducts()
+	list, err := h.productService.ListProducts(c.UserContext())
 	if err != nil {
-		log.Printf("Error retrieving products: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve products",
-		})
+		return fmt.Errorf("retrieve products: %w", err)
 	}
 
-	return c.Status(fiber.StatusOK).JSON(products)
+	c.Set(PartialResultHeader, strconv.FormatBool(list.Partial))
+	c.Set(SourcesHeader, formatSources(list.Sources))
+	return c.Status(fiber.StatusOK).JSON(list.Products)
 }
 
-// GetProductByID retrieves a product by its ID
+func formatSources(sources []service.Source) string {
+	parts := make([]string, len(sources))
+	for i, s := range sources {
+		parts[i] = s.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// GetProductByID retrieves a product by its ID. A missing product is a
+// domain.ErrNotFound from the service and answers 404.
 func (h *ProductHandler) GetProductByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	product, err := h.productService.GetProductById(id)
+	product, err := h.productService.GetProductById(c.UserContext(), id)
 	if err != nil {
-		log.Printf("Error retrieving product by ID: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve product",
-		})
-	}
-
-	// Tambahkan kondisi jika produk tidak ditemukan
-	if product == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Product with ID " + id + " not found",
-		})
+		return fmt.Errorf("retrieve product %s: %w", id, err)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(product)
@@ -94,32 +99,11 @@ func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
 	var product domain.Product
 
 	if err := c.BodyParser(&product); err != nil {
-		log.Printf("Error parsing product input: %v", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid input",
-		})
-	}
-
-	// Cek apakah produk dengan ID yang diberikan ada
-	existingProduct, err := h.productService.GetProductById(id)
-	if err != nil {
-		log.Printf("Error retrieving product: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve product",
-		})
-	}
-	if existingProduct == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Product with ID " + id + " not found",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input")
 	}
 
-	err = h.productService.UpdateProduct(id, &product)
-	if err != nil {
-		log.Printf("Error updating product: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to update product",
-		})
+	if err := h.productService.UpdateProduct(c.UserContext(), id, &product); err != nil {
+		return fmt.Errorf("update product %s: %w", id, err)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -132,26 +116,8 @@ func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
 func (h *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	// Cek apakah produk dengan ID yang diberikan ada
-	existingProduct, err := h.productService.GetProductById(id)
-	if err != nil {
-		log.Printf("Error retrieving product: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve product",
-		})
-	}
-	if existingProduct == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Product with ID " + id + " not found",
-		})
-	}
-
-	err = h.productService.DeleteProduct(id)
-	if err != nil {
-		log.Printf("Error deleting product: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to delete product",
-		})
+	if err := h.productService.DeleteProduct(c.UserContext(), id); err != nil {
+		return fmt.Errorf("delete product %s: %w", id, err)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -160,11 +126,9 @@ func (h *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
 }
 
 func (h *ProductHandler) GetMongoDBProducts(c *fiber.Ctx) error {
-	products, err := h.productService.GetMongoDBProducts()
+	products, err := h.productService.GetMongoDBProducts(c.UserContext())
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return fmt.Errorf("retrieve MongoDB products: %w", err)
 	}
 	return c.Status(http.StatusOK).JSON(products)
 }
@@ -0,0 +1,190 @@
+// internal/health/health.go
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// Overall readiness states reported by /readyz.
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFailed   = "unavailable"
+)
+
+// Check is a named dependency probe.
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// MySQLCheck pings the MySQL pool.
+func MySQLCheck(db *sql.DB) Check {
+	return Check{Name: "mysql", Check: db.PingContext}
+}
+
+// PostgresCheck pings the PostgreSQL pool.
+func PostgresCheck(db *sql.DB) Check {
+	return Check{Name: "postgres", Check: db.PingContext}
+}
+
+// SQLiteCheck pings the SQLite database file.
+func SQLiteCheck(db *sql.DB) Check {
+	return Check{Name: "sqlite", Check: db.PingContext}
+}
+
+// MongoDBCheck pings the primary of the MongoDB deployment.
+func MongoDBCheck(client *mongo.Client) Check {
+	return Check{Name: "mongodb", Check: func(ctx context.Context) error {
+		return client.Ping(ctx, readpref.Primary())
+	}}
+}
+
+// DependencyStatus is the result of a single check. /readyz is served
+// without authentication, so why a check failed is only logged.
+type DependencyStatus struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+	healthy bool
+}
+
+// Report is the JSON body returned by /readyz.
+type Report struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+// Healthy reports whether every dependency is up.
+func (r Report) Healthy() bool { return r.Status == StatusOK }
+
+// Serviceable reports whether at least one dependency is up.
+func (r Report) Serviceable() bool { return r.Status != StatusFailed }
+
+// Health runs dependency checks with a per-check timeout.
+type Health struct {
+	checks   []Check
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+// New creates a Health over the given checks.
+func New(timeout time.Duration, checks ...Check) *Health {
+	return &Health{checks: checks, timeout: timeout}
+}
+
+// Run executes every check concurrently and aggregates the result. When
+// only some dependencies are up the status is "degraded".
+func (h *Health) Run(ctx context.Context) Report {
+	report := Report{Dependencies: make(map[string]DependencyStatus, len(h.checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			status := h.runCheck(ctx, check)
+			mu.Lock()
+			report.Dependencies[check.Name] = status
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	up := 0
+	for _, status := range report.Dependencies {
+		if status.healthy {
+			up++
+		}
+	}
+	switch {
+	case up == len(h.checks):
+		report.Status = StatusOK
+	case up > 0:
+		report.Status = StatusDegraded
+	default:
+		report.Status = StatusFailed
+	}
+	return report
+}
+
+func (h *Health) runCheck(ctx context.Context, check Check) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Check(ctx)
+	elapsed := time.Since(start)
+
+	status := DependencyStatus{Status: StatusUp, Latency: elapsed.String(), healthy: err == nil}
+	if err != nil {
+		status.Status = StatusDown
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s: %w", h.timeout, err)
+		}
+		slog.WarnContext(ctx, "Dependency check failed", "dependency", check.Name, "error", err)
+	}
+	return status
+}
+
+// Livez reports that the process is running. It never touches dependencies
+// so a slow database cannot get the process restarted.
+func (h *Health) Livez(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": StatusOK})
+}
+
+// StartDraining makes /readyz fail so load balancers stop routing new
+// traffic while in-flight requests finish.
+func (h *Health) StartDraining() {
+	h.draining.Store(true)
+}
+
+// Readyz reports per-dependency status. A degraded service is still ready
+// because reads fall back to the healthy backend; it is only unready when
+// every dependency is down or the process is shutting down.
+func (h *Health) Readyz(c *fiber.Ctx) error {
+	if h.draining.Load() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "draining"})
+	}
+	report := h.Run(c.UserContext())
+	status := fiber.StatusOK
+	if !report.Serviceable() {
+		status = fiber.StatusServiceUnavailable
+	}
+	return c.Status(status).JSON(report)
+}
+
+// WaitForDependencies runs the checks until all of them pass or the
+// attempts are used up, sleeping interval between attempts. It returns the
+// last report so the caller can decide whether a degraded start is
+// acceptable.
+func (h *Health) WaitForDependencies(ctx context.Context, attempts int, interval time.Duration) Report {
+	var report Report
+	for attempt := 1; ; attempt++ {
+		report = h.Run(ctx)
+		if report.Healthy() || attempt >= attempts {
+			return report
+		}
+		slog.WarnContext(ctx, "Dependencies not ready, retrying",
+			"attempt", attempt, "max_attempts", attempts, "status", report.Status, "dependencies", report.Dependencies)
+
+		select {
+		case <-ctx.Done():
+			return report
+		case <-time.After(interval):
+		}
+	}
+}
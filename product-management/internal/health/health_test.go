@@ -0,0 +1,109 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"product-management/internal/health"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func up(name string) health.Check {
+	return health.Check{Name: name, Check: func(context.Context) error { return nil }}
+}
+
+func down(name string) health.Check {
+	return health.Check{Name: name, Check: func(context.Context) error { return errors.New("connection refused") }}
+}
+
+func hanging(name string) health.Check {
+	return health.Check{Name: name, Check: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+}
+
+func readyz(t *testing.T, h *health.Health) (int, health.Report) {
+	app := fiber.New()
+	app.Get("/readyz", h.Readyz)
+	resp, err := app.Test(httptest.NewRequest("GET", "/readyz", nil))
+	assert.NoError(t, err)
+
+	var report health.Report
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	return resp.StatusCode, report
+}
+
+func TestReadyzReportsEachDependency(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     []health.Check
+		wantCode   int
+		wantStatus string
+	}{
+		{"all up", []health.Check{up("mysql"), up("mongodb")}, fiber.StatusOK, health.StatusOK},
+		{"one down", []health.Check{up("mysql"), down("mongodb")}, fiber.StatusOK, health.StatusDegraded},
+		{"all down", []health.Check{down("mysql"), hanging("mongodb")}, fiber.StatusServiceUnavailable, health.StatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, report := readyz(t, health.New(50*time.Millisecond, tt.checks...))
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantStatus, report.Status)
+			assert.Len(t, report.Dependencies, len(tt.checks))
+		})
+	}
+}
+
+func TestReadyzTimesOutSlowDependency(t *testing.T) {
+	_, report := readyz(t, health.New(20*time.Millisecond, up("mysql"), hanging("mongodb")))
+	assert.Equal(t, health.StatusDown, report.Dependencies["mongodb"].Status)
+	assert.Equal(t, health.StatusUp, report.Dependencies["mysql"].Status)
+}
+
+func TestReadyzDoesNotExposeCheckErrors(t *testing.T) {
+	app := fiber.New()
+	app.Get("/readyz", health.New(time.Second, up("mysql"), down("mongodb")).Readyz)
+	resp, err := app.Test(httptest.NewRequest("GET", "/readyz", nil))
+	assert.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"mongodb":{"status":"down"`)
+	assert.NotContains(t, string(body), "connection refused")
+}
+
+func TestWaitForDependenciesRetries(t *testing.T) {
+	calls := 0
+	flaky := health.Check{Name: "mysql", Check: func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	}}
+
+	report := health.New(time.Second, flaky).WaitForDependencies(context.Background(), 5, time.Millisecond)
+	assert.True(t, report.Healthy())
+	assert.Equal(t, 3, calls)
+
+	report = health.New(time.Second, down("mysql")).WaitForDependencies(context.Background(), 2, time.Millisecond)
+	assert.False(t, report.Serviceable())
+}
+
+func TestReadyzFailsWhileDraining(t *testing.T) {
+	h := health.New(time.Second, up("mysql"))
+	h.StartDraining()
+
+	app := fiber.New()
+	app.Get("/readyz", h.Readyz)
+	resp, err := app.Test(httptest.NewRequest("GET", "/readyz", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+}
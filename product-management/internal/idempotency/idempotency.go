@@ -0,0 +1,69 @@
+// internal/idempotency/idempotency.go
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Headers of idempotent requests.
+const (
+	// KeyHeader carries the client-chosen key of a mutating request.
+	KeyHeader = "Idempotency-Key"
+	// ReplayedHeader marks a response served from the store.
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// MaxKeyLength bounds client-supplied keys.
+const MaxKeyLength = 255
+
+// Record is the stored outcome of the first request with a key. Status is
+// zero while that request is still in flight.
+type Record struct {
+	Fingerprint string
+	Status      int
+	ContentType string
+	Body        []byte
+	// ExpiresAt ends the lease of an in-flight request, or the retention
+	// of a completed one. Expired records are treated as absent.
+	ExpiresAt time.Time
+}
+
+// Completed reports whether the record holds a response.
+func (r *Record) Completed() bool {
+	return r.Status != 0
+}
+
+// Store keeps idempotency records. id identifies the key within its client
+// and tenant; see ID.
+type Store interface {
+	// Acquire reserves id for a request with fingerprint until leaseUntil,
+	// replacing an expired record. When an unexpired record exists it is
+	// returned instead and acquired is false.
+	Acquire(ctx context.Context, id, fingerprint string, now, leaseUntil time.Time) (existing *Record, acquired bool, err error)
+	// Complete stores the response of the request holding the lease that
+	// ends at leaseUntil.
+	Complete(ctx context.Context, id string, leaseUntil time.Time, rec *Record) error
+	// Release drops the lease that ends at leaseUntil so the key can be
+	// retried.
+	Release(ctx context.Context, id string, leaseUntil time.Time) error
+	// DeleteExpired removes records that expired before now.
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// ID scopes a key to the tenant and client that sent it, so clients cannot
+// replay each other's responses.
+func ID(tenantID, client, key string) string {
+	sum := sha256.Sum256([]byte(tenantID + "\x00" + client + "\x00" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Fingerprint identifies the request a key was first used for.
+func Fingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\x00"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
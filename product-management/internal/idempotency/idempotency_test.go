@@ -0,0 +1,269 @@
+package idempotency_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"product-management/internal/auth"
+	"product-management/internal/config"
+	"product-management/internal/domain"
+	"product-management/internal/handler"
+	"product-management/internal/idempotency"
+	"product-management/internal/repository/mysql/mysqltest"
+	"product-management/internal/repository/sqlite"
+	"product-management/internal/tenant"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = config.IdempotencyConfig{
+	Enabled:       true,
+	TTL:           time.Hour,
+	LockTimeout:   5 * time.Second,
+	PurgeInterval: time.Hour,
+}
+
+// stores open an empty store of each kind.
+var stores = map[string]func(t *testing.T) idempotency.Store{
+	"mysql": func(t *testing.T) idempotency.Store {
+		store := idempotency.NewMySQLStore(mysqltest.New(t))
+		require.NoError(t, store.EnsureSchema(context.Background()))
+		return store
+	},
+	"sqlite": func(t *testing.T) idempotency.Store {
+		db, err := sqlite.Open(filepath.Join(t.TempDir(), "produk.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		store := idempotency.NewSQLiteStore(db)
+		require.NoError(t, store.EnsureSchema(context.Background()))
+		return store
+	},
+}
+
+// newApp serves POST /products through the middleware. The tenant and
+// principal come from the X-Tenant-ID and X-User headers.
+func newApp(store idempotency.Store, cfg config.IdempotencyConfig, h fiber.Handler) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		ctx := tenant.WithTenant(c.UserContext(), c.Get("X-Tenant-ID", "shop-a"))
+		if user := c.Get("X-User"); user != "" {
+			ctx = auth.WithPrincipal(ctx, &auth.Principal{Subject: user, Role: auth.RoleEditor})
+		}
+		c.SetUserContext(ctx)
+		return c.Next()
+	})
+	app.Post("/products", idempotency.New(store, cfg).Handler(), h)
+	return app
+}
+
+// counting answers 201 with the number of times it ran.
+func counting(calls *atomic.Int32) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		n := calls.Add(1)
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"call": n})
+	}
+}
+
+type response struct {
+	status   int
+	body     string
+	replayed string
+}
+
+func send(t *testing.T, app *fiber.App, key, body string, headers ...string) response {
+	req := httptest.NewRequest("POST", "/products", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(idempotency.KeyHeader, key)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	raw, _ := io.ReadAll(resp.Body)
+	return response{resp.StatusCode, string(raw), resp.Header.Get(idempotency.ReplayedHeader)}
+}
+
+func TestRetriesReplayTheFirstResponse(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+			app := newApp(newStore(t), testConfig, counting(&calls))
+
+			first := send(t, app, "key-1", `{"name":"kecap"}`)
+			assert.Equal(t, response{fiber.StatusCreated, `{"call":1}`, ""}, first)
+
+			retry := send(t, app, "key-1", `{"name":"kecap"}`)
+			assert.Equal(t, response{fiber.StatusCreated, `{"call":1}`, "true"}, retry)
+			assert.EqualValues(t, 1, calls.Load())
+
+			// Without a key every request runs.
+			send(t, app, "", `{"name":"kecap"}`)
+			send(t, app, "", `{"name":"kecap"}`)
+			assert.EqualValues(t, 3, calls.Load())
+		})
+	}
+}
+
+func TestKeyReuseForADifferentRequestIsRejected(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+			app := newApp(newStore(t), testConfig, counting(&calls))
+
+			send(t, app, "key-1", `{"name":"kecap"}`)
+			got := send(t, app, "key-1", `{"name":"saos"}`)
+			assert.Equal(t, fiber.StatusUnprocessableEntity, got.status)
+			assert.Contains(t, got.body, "already used for a different request")
+			assert.EqualValues(t, 1, calls.Load())
+		})
+	}
+}
+
+func TestKeysAreScopedToTenantAndClient(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+			app := newApp(newStore(t), testConfig, counting(&calls))
+
+			body := `{"name":"kecap"}`
+			assert.Equal(t, `{"call":1}`, send(t, app, "key-1", body, "X-User", "alice").body)
+			assert.Equal(t, `{"call":2}`, send(t, app, "key-1", body, "X-User", "bob").body)
+			assert.Equal(t, `{"call":3}`, send(t, app, "key-1", body, "X-User", "alice", "X-Tenant-ID", "shop-b").body)
+			assert.Equal(t, `{"call":1}`, send(t, app, "key-1", body, "X-User", "alice").body)
+		})
+	}
+}
+
+func TestConcurrentDuplicatesRunOnce(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+			release := make(chan struct{})
+			app := newApp(newStore(t), testConfig, func(c *fiber.Ctx) error {
+				<-release
+				return counting(&calls)(c)
+			})
+
+			const duplicates = 5
+			responses := make([]response, duplicates)
+			var wg sync.WaitGroup
+			for i := range duplicates {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					responses[i] = send(t, app, "key-1", `{"name":"kecap"}`)
+				}()
+			}
+			time.Sleep(100 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			assert.EqualValues(t, 1, calls.Load())
+			for _, r := range responses {
+				assert.Equal(t, fiber.StatusCreated, r.status)
+				assert.Equal(t, `{"call":1}`, r.body)
+			}
+		})
+	}
+}
+
+func TestServerErrorsAreNotStored(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+			app := newApp(newStore(t), testConfig, func(c *fiber.Ctx) error {
+				if calls.Add(1) == 1 {
+					return errors.New("dial tcp: connection refused")
+				}
+				return c.Status(fiber.StatusCreated).SendString(strconv.Itoa(int(calls.Load())))
+			})
+
+			assert.Equal(t, fiber.StatusInternalServerError, send(t, app, "key-1", `{}`).status)
+			assert.Equal(t, response{fiber.StatusCreated, "2", ""}, send(t, app, "key-1", `{}`))
+			assert.Equal(t, response{fiber.StatusCreated, "2", "true"}, send(t, app, "key-1", `{}`))
+		})
+	}
+}
+
+func TestErrorsAreStoredAsSentAndPassedOn(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			var passed []error
+			app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+			app.Use(func(c *fiber.Ctx) error {
+				err := c.Next()
+				passed = append(passed, err)
+				return err
+			})
+			app.Use(handler.RenderErrors())
+			app.Post("/products", idempotency.New(newStore(t), testConfig).Handler(), func(c *fiber.Ctx) error {
+				return domain.NewError(domain.ErrConflict, "A product with this name already exists", nil)
+			})
+
+			first := send(t, app, "key-1", `{"name":"kecap"}`)
+			assert.Equal(t, fiber.StatusConflict, first.status)
+			var p map[string]any
+			assert.NoError(t, json.Unmarshal([]byte(first.body), &p), "the problem is written once")
+
+			retry := send(t, app, "key-1", `{"name":"kecap"}`)
+			assert.Equal(t, response{fiber.StatusConflict, first.body, "true"}, retry)
+			if assert.Len(t, passed, 2) {
+				assert.ErrorIs(t, passed[0], domain.ErrConflict)
+				assert.NoError(t, passed[1])
+			}
+		})
+	}
+}
+
+func TestExpiredKeysAreForgotten(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+			store := newStore(t)
+			cfg := testConfig
+			cfg.TTL = time.Millisecond
+			app := newApp(store, cfg, counting(&calls))
+
+			send(t, app, "key-1", `{}`)
+			time.Sleep(5 * time.Millisecond)
+			assert.Equal(t, `{"call":2}`, send(t, app, "key-1", `{}`).body)
+
+			time.Sleep(5 * time.Millisecond)
+			n, err := store.DeleteExpired(context.Background(), time.Now())
+			assert.NoError(t, err)
+			assert.EqualValues(t, 1, n)
+		})
+	}
+}
+
+// brokenStore fails every call.
+type brokenStore struct{ idempotency.Store }
+
+func (brokenStore) Acquire(context.Context, string, string, time.Time, time.Time) (*idempotency.Record, bool, error) {
+	return nil, false, errors.New("dial tcp: connection refused")
+}
+
+func TestStoreFailureRefusesTheRequest(t *testing.T) {
+	var calls atomic.Int32
+	app := newApp(brokenStore{}, testConfig, counting(&calls))
+
+	got := send(t, app, "key-1", `{}`)
+	assert.Equal(t, fiber.StatusServiceUnavailable, got.status)
+	assert.NotContains(t, got.body, "connection refused")
+	assert.Zero(t, calls.Load())
+
+	assert.Equal(t, fiber.StatusBadRequest, send(t, app, strings.Repeat("k", idempotency.MaxKeyLength+1), `{}`).status)
+}
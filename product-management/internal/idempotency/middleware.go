@@ -0,0 +1,144 @@
+// internal/idempotency/middleware.go
+package idempotency
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"product-management/internal/config"
+	"product-management/internal/domain"
+	"product-management/internal/durable"
+	"product-management/internal/problem"
+	"product-management/internal/ratelimit"
+	"product-management/internal/tenant"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Polling bounds while a duplicate waits for the request holding its key.
+const (
+	minPoll = 20 * time.Millisecond
+	maxPoll = 500 * time.Millisecond
+)
+
+// Middleware makes mutating requests safe to retry. The first response to
+// a key is stored and replayed to later requests with the same key from the
+// same client; concurrent duplicates wait for the first to finish.
+type Middleware struct {
+	store  Store
+	cfg    config.IdempotencyConfig
+	purger *durable.Worker
+}
+
+// New creates a Middleware. Call Start to purge expired keys.
+func New(store Store, cfg config.IdempotencyConfig) *Middleware {
+	m := &Middleware{store: store, cfg: cfg}
+	m.purger = durable.NewWorker(cfg.PurgeInterval, m.purge)
+	return m
+}
+
+// Handler applies idempotency to requests carrying an Idempotency-Key.
+// Reusing a key for a different method, path or body answers 422. Server
+// errors are not stored, so the client can retry them with the same key.
+// If the store fails the request is refused with 503 rather than risking a
+// duplicate.
+func (m *Middleware) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(KeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > MaxKeyLength {
+			return problem.Respond(c, fiber.StatusBadRequest,
+				KeyHeader+" must be at most "+strconv.Itoa(MaxKeyLength)+" characters")
+		}
+
+		ctx := c.UserContext()
+		tenantID, _ := tenant.FromContext(ctx)
+		id := ID(tenantID, ratelimit.ClientKey(c), key)
+		fingerprint := Fingerprint(c.Method(), c.Path(), c.Body())
+
+		var leaseUntil time.Time
+		for wait := minPoll; ; wait = min(2*wait, maxPoll) {
+			at := durable.Now()
+			leaseUntil = at.Add(m.cfg.LockTimeout)
+			rec, acquired, err := m.store.Acquire(ctx, id, fingerprint, at, leaseUntil)
+			if err != nil {
+				return domain.NewError(domain.ErrUnavailable, "Idempotency store is unavailable", err)
+			}
+			if acquired {
+				break
+			}
+			if rec.Fingerprint != fingerprint {
+				return problem.Respond(c, fiber.StatusUnprocessableEntity,
+					KeyHeader+" was already used for a different request")
+			}
+			if rec.Completed() {
+				c.Set(ReplayedHeader, "true")
+				c.Set(fiber.HeaderContentType, rec.ContentType)
+				return c.Status(rec.Status).Send(rec.Body)
+			}
+			// Another request with this key is in flight. Its lease bounds
+			// the wait: once it expires, Acquire takes the key over.
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+
+		// The stored response must be the one sent, so errors are rendered
+		// here; the app's error handler writes a request's error only once,
+		// and the error is still passed on to be logged and traced.
+		err := c.Next()
+		if err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		// The outcome is recorded even if the request context has ended.
+		ctx = context.WithoutCancel(ctx)
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			if err := m.store.Release(ctx, id, leaseUntil); err != nil {
+				slog.ErrorContext(ctx, "Failed to release idempotency key", "error", err)
+			}
+			return err
+		}
+		rec := &Record{
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: string(c.Response().Header.ContentType()),
+			Body:        append([]byte{}, c.Response().Body()...),
+			ExpiresAt:   durable.Now().Add(m.cfg.TTL),
+		}
+		if err := m.store.Complete(ctx, id, leaseUntil, rec); err != nil {
+			slog.ErrorContext(ctx, "Failed to store idempotent response", "status", status, "error", err)
+		}
+		return err
+	}
+}
+
+// Start deletes expired keys every PurgeInterval until Stop.
+func (m *Middleware) Start() {
+	m.purger.Start()
+}
+
+// Stop stops purging and waits for a running purge, or for ctx.
+func (m *Middleware) Stop(ctx context.Context) error {
+	return m.purger.Stop(ctx)
+}
+
+func (m *Middleware) purge(ctx context.Context) {
+	n, err := m.store.DeleteExpired(ctx, durable.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to purge idempotency keys", "error", err)
+		return
+	}
+	if n > 0 {
+		slog.DebugContext(ctx, "Purged idempotency keys", "count", n)
+	}
+}
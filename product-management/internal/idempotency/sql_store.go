@@ -0,0 +1,121 @@
+// internal/idempotency/sql_store.go
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"product-management/internal/durable"
+)
+
+var schema = durable.Schema{
+	MySQL: []string{`
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	id CHAR(64) PRIMARY KEY,
+	fingerprint CHAR(64) NOT NULL,
+	status INT NOT NULL,
+	content_type VARCHAR(255) NOT NULL,
+	body MEDIUMBLOB NOT NULL,
+	created_at DATETIME(6) NOT NULL,
+	expires_at DATETIME(6) NOT NULL,
+	KEY idx_idempotency_keys_expires (expires_at)
+)`},
+	SQLite: []string{`
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	id TEXT PRIMARY KEY,
+	fingerprint TEXT NOT NULL,
+	status INTEGER NOT NULL,
+	content_type TEXT NOT NULL,
+	body BLOB NOT NULL,
+	created_at DATETIME NOT NULL,
+	expires_at DATETIME NOT NULL
+)`,
+		"CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON idempotency_keys (expires_at)",
+	},
+}
+
+// ErrLeaseLost is returned by Complete when the lease expired and another
+// request took over the key.
+var ErrLeaseLost = errors.New("idempotency: lease expired before the response was stored")
+
+// SQLStore keeps idempotency records in MySQL, or SQLite in the embedded
+// mode, so replays work across instances and restarts.
+type SQLStore struct {
+	db durable.DB
+}
+
+// NewMySQLStore creates a store backed by a MySQL db.
+func NewMySQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: durable.MySQL(db, schema)}
+}
+
+// NewSQLiteStore creates a store backed by a SQLite db.
+func NewSQLiteStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: durable.SQLite(db, schema)}
+}
+
+// EnsureSchema creates the idempotency_keys table if it does not exist.
+func (s *SQLStore) EnsureSchema(ctx context.Context) error {
+	return s.db.EnsureSchema(ctx)
+}
+
+// Acquire implements Store. The primary key serializes concurrent requests:
+// only one insert of an id succeeds, the others read the winner's record.
+func (s *SQLStore) Acquire(ctx context.Context, id, fingerprint string, now, leaseUntil time.Time) (*Record, bool, error) {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE id = ? AND expires_at <= ?", id, now.UTC()); err != nil {
+		return nil, false, err
+	}
+	_, insertErr := s.db.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (id, fingerprint, status, content_type, body, created_at, expires_at)
+		VALUES (?, ?, 0, '', '', ?, ?)`,
+		id, fingerprint, now.UTC(), leaseUntil.UTC())
+	if insertErr == nil {
+		return nil, true, nil
+	}
+
+	var rec Record
+	err := s.db.QueryRowContext(ctx,
+		"SELECT fingerprint, status, content_type, body, expires_at FROM idempotency_keys WHERE id = ?", id,
+	).Scan(&rec.Fingerprint, &rec.Status, &rec.ContentType, &rec.Body, &rec.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The insert failed for another reason than an existing record.
+		return nil, false, insertErr
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &rec, false, nil
+}
+
+// Complete implements Store with a compare-and-set on the lease.
+func (s *SQLStore) Complete(ctx context.Context, id string, leaseUntil time.Time, rec *Record) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE idempotency_keys SET status = ?, content_type = ?, body = ?, expires_at = ?
+		WHERE id = ? AND status = 0 AND expires_at = ?`,
+		rec.Status, rec.ContentType, rec.Body, rec.ExpiresAt.UTC(), id, leaseUntil.UTC())
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Release implements Store.
+func (s *SQLStore) Release(ctx context.Context, id string, leaseUntil time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE id = ? AND status = 0 AND expires_at = ?", id, leaseUntil.UTC())
+	return err
+}
+
+// DeleteExpired implements Store.
+func (s *SQLStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= ?", now.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
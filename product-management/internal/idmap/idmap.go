@@ -0,0 +1,78 @@
+// internal/idmap/idmap.go
+package idmap
+
+import (
+	"context"
+	"sync"
+
+	"product-management/internal/durable"
+)
+
+// Store records which product of the secondary slot is the copy of which
+// product of the primary, per tenant. Each backend generates its own IDs,
+// so the primary's ID only finds the copy through this record.
+type Store interface {
+	// Save records secondaryID as the copy of primaryID in the tenant of
+	// ctx, replacing an earlier record.
+	Save(ctx context.Context, primaryID, secondaryID string) error
+	// Lookup returns the copies of primaryIDs in the tenant of ctx keyed by
+	// primary ID. IDs without a recorded copy are absent.
+	Lookup(ctx context.Context, primaryIDs []string) (map[string]string, error)
+	// Delete forgets the copy of primaryID in the tenant of ctx.
+	Delete(ctx context.Context, primaryID string) error
+}
+
+type key struct{ tenantID, primaryID string }
+
+// MemoryStore keeps the copies in memory, for the demo mode where the
+// products themselves are not persisted either.
+type MemoryStore struct {
+	mu  sync.RWMutex
+	ids map[key]string
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{ids: map[key]string{}}
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(ctx context.Context, primaryID, secondaryID string) error {
+	id, err := durable.SingleTenant(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids[key{id, primaryID}] = secondaryID
+	return nil
+}
+
+// Lookup implements Store.
+func (s *MemoryStore) Lookup(ctx context.Context, primaryIDs []string) (map[string]string, error) {
+	id, err := durable.SingleTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	found := make(map[string]string, len(primaryIDs))
+	for _, primaryID := range primaryIDs {
+		if secondaryID, ok := s.ids[key{id, primaryID}]; ok {
+			found[primaryID] = secondaryID
+		}
+	}
+	return found, nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(ctx context.Context, primaryID string) error {
+	id, err := durable.SingleTenant(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.ids, key{id, primaryID})
+	return nil
+}
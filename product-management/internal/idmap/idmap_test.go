@@ -0,0 +1,63 @@
+package idmap_test
+
+import (
+	"context"
+	"path/filepath"
+	"product-management/internal/idmap"
+	"product-management/internal/repository/mysql/mysqltest"
+	"product-management/internal/repository/sqlite"
+	"product-management/internal/tenant"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stores open an empty store of each kind.
+var stores = map[string]func(t *testing.T) idmap.Store{
+	"memory": func(t *testing.T) idmap.Store { return idmap.NewMemoryStore() },
+	"mysql": func(t *testing.T) idmap.Store {
+		store := idmap.NewMySQLStore(mysqltest.New(t))
+		require.NoError(t, store.EnsureSchema(context.Background()))
+		return store
+	},
+	"sqlite": func(t *testing.T) idmap.Store {
+		db, err := sqlite.Open(filepath.Join(t.TempDir(), "produk.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		store := idmap.NewSQLiteStore(db)
+		require.NoError(t, store.EnsureSchema(context.Background()))
+		return store
+	},
+}
+
+func TestCopiesAreKeptPerTenant(t *testing.T) {
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+			shopA := tenant.WithTenant(context.Background(), "shop-a")
+			shopB := tenant.WithTenant(context.Background(), "shop-b")
+
+			require.NoError(t, store.Save(shopA, "1", "6650c0ffee0000000000000a"))
+			require.NoError(t, store.Save(shopA, "2", "6650c0ffee0000000000000b"))
+			require.NoError(t, store.Save(shopB, "1", "6650c0ffee0000000000000c"))
+			// A copy made again replaces the record.
+			require.NoError(t, store.Save(shopA, "2", "6650c0ffee0000000000000d"))
+
+			ids, err := store.Lookup(shopA, []string{"1", "2", "3"})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"1": "6650c0ffee0000000000000a", "2": "6650c0ffee0000000000000d"}, ids)
+
+			require.NoError(t, store.Delete(shopA, "1"))
+			ids, err = store.Lookup(shopA, []string{"1"})
+			require.NoError(t, err)
+			assert.Empty(t, ids)
+			ids, err = store.Lookup(shopB, []string{"1"})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"1": "6650c0ffee0000000000000c"}, ids)
+
+			_, err = store.Lookup(tenant.WithAllTenants(context.Background()), []string{"1"})
+			assert.Error(t, err)
+		})
+	}
+}
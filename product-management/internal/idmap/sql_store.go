@@ -0,0 +1,101 @@
+// internal/idmap/sql_store.go
+package idmap
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"product-management/internal/durable"
+)
+
+var schema = durable.Schema{
+	MySQL: []string{`
+CREATE TABLE IF NOT EXISTS product_copies (
+	tenant_id VARCHAR(64) NOT NULL,
+	primary_id VARCHAR(64) NOT NULL,
+	secondary_id VARCHAR(64) NOT NULL,
+	PRIMARY KEY (tenant_id, primary_id)
+)`},
+	SQLite: []string{`
+CREATE TABLE IF NOT EXISTS product_copies (
+	tenant_id TEXT NOT NULL,
+	primary_id TEXT NOT NULL,
+	secondary_id TEXT NOT NULL,
+	PRIMARY KEY (tenant_id, primary_id)
+)`},
+}
+
+// SQLStore keeps the copies in MySQL or, in the single-file embedded mode,
+// SQLite.
+type SQLStore struct {
+	db durable.DB
+}
+
+// NewMySQLStore creates a store backed by a MySQL db.
+func NewMySQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: durable.MySQL(db, schema)}
+}
+
+// NewSQLiteStore creates a store backed by a SQLite db.
+func NewSQLiteStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: durable.SQLite(db, schema)}
+}
+
+// EnsureSchema creates the copies table if it does not exist.
+func (s *SQLStore) EnsureSchema(ctx context.Context) error {
+	return s.db.EnsureSchema(ctx)
+}
+
+// Save implements Store. REPLACE means the same in both dialects.
+func (s *SQLStore) Save(ctx context.Context, primaryID, secondaryID string) error {
+	id, err := durable.SingleTenant(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, "REPLACE INTO product_copies (tenant_id, primary_id, secondary_id) VALUES (?, ?, ?)",
+		id, primaryID, secondaryID)
+	return err
+}
+
+// Lookup implements Store with one query for all of primaryIDs.
+func (s *SQLStore) Lookup(ctx context.Context, primaryIDs []string) (map[string]string, error) {
+	id, err := durable.SingleTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]string, len(primaryIDs))
+	if len(primaryIDs) == 0 {
+		return found, nil
+	}
+	args := make([]any, 0, len(primaryIDs)+1)
+	args = append(args, id)
+	for _, primaryID := range primaryIDs {
+		args = append(args, primaryID)
+	}
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT primary_id, secondary_id FROM product_copies WHERE tenant_id = ? AND primary_id IN (?"+
+			strings.Repeat(", ?", len(primaryIDs)-1)+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var primaryID, secondaryID string
+		if err := rows.Scan(&primaryID, &secondaryID); err != nil {
+			return nil, err
+		}
+		found[primaryID] = secondaryID
+	}
+	return found, rows.Err()
+}
+
+// Delete implements Store.
+func (s *SQLStore) Delete(ctx context.Context, primaryID string) error {
+	id, err := durable.SingleTenant(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, "DELETE FROM product_copies WHERE tenant_id = ? AND primary_id = ?", id, primaryID)
+	return err
+}
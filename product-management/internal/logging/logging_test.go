@@ -0,0 +1,108 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"product-management/internal/config"
+	"product-management/internal/domain"
+	"product-management/internal/handler"
+	"product-management/internal/logging"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDIsPropagatedToLogs(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(config.LoggingConfig{Level: "debug", Format: "json"}, &buf)
+	assert.NoError(t, err)
+
+	app := fiber.New()
+	app.Use(logging.RequestID())
+	app.Get("/products", func(c *fiber.Ctx) error {
+		logger.InfoContext(c.UserContext(), "listing products")
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest("GET", "/products", nil)
+	req.Header.Set(logging.RequestIDHeader, "abc-123")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc-123", resp.Header.Get(logging.RequestIDHeader))
+
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "abc-123", record["request_id"])
+	assert.Equal(t, "listing products", record["msg"])
+}
+
+func TestRequestIDIsGeneratedWhenMissing(t *testing.T) {
+	app := fiber.New()
+	app.Use(logging.RequestID())
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(logging.RequestIDFromContext(c.UserContext()))
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.Header.Get(logging.RequestIDHeader))
+}
+
+func TestAccessLogRecordsRenderedStatusAndPassesErrorOn(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(config.LoggingConfig{Level: "info", Format: "json"}, &buf)
+	assert.NoError(t, err)
+
+	var passed error
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		passed = c.Next()
+		return passed
+	})
+	app.Use(logging.AccessLog(logger))
+	app.Use(handler.RenderErrors())
+	app.Get("/products/:id", func(c *fiber.Ctx) error {
+		return domain.ProductNotFound(nil)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/products/42", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	var p map[string]any
+	assert.NoError(t, json.Unmarshal(body, &p), "the problem is written once")
+	assert.ErrorIs(t, passed, domain.ErrNotFound)
+
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, float64(fiber.StatusNotFound), record["status"])
+}
+
+func TestLevelFiltersRecords(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(config.LoggingConfig{Level: "warn", Format: "text"}, &buf)
+	assert.NoError(t, err)
+
+	logger.Info("dropped")
+	assert.Empty(t, buf.String())
+	logger.Warn("kept")
+	assert.Contains(t, buf.String(), "kept")
+
+	_, err = logging.New(config.LoggingConfig{Level: "loud"}, &buf)
+	assert.Error(t, err)
+	_, err = logging.ParseLevel("ERROR")
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelInfo, must(logging.ParseLevel("")))
+}
+
+func must(level slog.Level, err error) slog.Level {
+	if err != nil {
+		panic(err)
+	}
+	return level
+}
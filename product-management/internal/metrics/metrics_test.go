@@ -0,0 +1,130 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"product-management/internal/domain"
+	"product-management/internal/handler"
+	"product-management/internal/metrics"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubRepo struct {
+	products []domain.Product
+	err      error
+}
+
+func (s *stubRepo) Create(ctx context.Context, product *domain.Product) error { return s.err }
+func (s *stubRepo) GetAllProducts(ctx context.Context) ([]domain.Product, error) {
+	return s.products, s.err
+}
+func (s *stubRepo) GetProductById(ctx context.Context, id string) (*domain.Product, error) {
+	return nil, s.err
+}
+func (s *stubRepo) GetProductsByIds(ctx context.Context, ids []string) ([]domain.Product, error) {
+	return nil, s.err
+}
+func (s *stubRepo) UpdateProduct(ctx context.Context, id string, product *domain.Product) error {
+	return s.err
+}
+func (s *stubRepo) DeleteProduct(ctx context.Context, id string) error { return s.err }
+func (s *stubRepo) CountProducts(ctx context.Context) (domain.ProductStats, error) {
+	stats := domain.ProductStats{Count: len(s.products)}
+	for _, p := range s.products {
+		stats.Stock += p.Stock
+	}
+	return stats, s.err
+}
+
+func scrape(t *testing.T, app *fiber.App) string {
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestMiddlewareRecordsRouteTemplate(t *testing.T) {
+	m := metrics.New()
+	app := fiber.New()
+	app.Use(m.Middleware())
+	app.Get("/metrics", m.Handler())
+	app.Get("/products/:id", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNotFound)
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/products/42", nil))
+	assert.NoError(t, err)
+
+	body := scrape(t, app)
+	assert.Contains(t, body, `product_management_http_requests_total{method="GET",route="/products/:id",status="404"} 1`)
+	assert.NotContains(t, body, "/products/42")
+}
+
+func TestMiddlewareRecordsStatusOfErrorHandler(t *testing.T) {
+	m := metrics.New()
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(m.Middleware())
+	app.Use(handler.RenderErrors())
+	app.Get("/metrics", m.Handler())
+	app.Get("/products/:id", func(c *fiber.Ctx) error {
+		return domain.ProductNotFound(nil)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/products/42", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	body := scrape(t, app)
+	assert.Contains(t, body, `product_management_http_requests_total{method="GET",route="/products/:id",status="404"} 1`)
+}
+
+func TestInstrumentedRepositoryCountsErrors(t *testing.T) {
+	m := metrics.New()
+	repo := metrics.NewInstrumentedRepository(&stubRepo{err: errors.New("boom")}, "mysql", m)
+
+	assert.Error(t, repo.DeleteProduct(context.Background(), "1"))
+	assert.Error(t, repo.Create(context.Background(), &domain.Product{}))
+
+	app := fiber.New()
+	app.Get("/metrics", m.Handler())
+	body := scrape(t, app)
+	assert.Contains(t, body, `product_management_repository_operation_errors_total{backend="mysql",operation="delete"} 1`)
+	assert.Contains(t, body, `product_management_repository_operation_errors_total{backend="mysql",operation="create"} 1`)
+}
+
+func TestInstrumentedRepositoryIgnoresNotFound(t *testing.T) {
+	m := metrics.New()
+	repo := metrics.NewInstrumentedRepository(&stubRepo{err: domain.ProductNotFound(nil)}, "mysql", m)
+
+	_, err := repo.GetProductById(context.Background(), "1")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	app := fiber.New()
+	app.Get("/metrics", m.Handler())
+	body := scrape(t, app)
+	assert.Contains(t, body, `product_management_repository_operation_duration_seconds_count{backend="mysql",operation="get_by_id"} 1`)
+	assert.NotContains(t, body, "product_management_repository_operation_errors_total")
+}
+
+func TestProductCollectorReportsCountAndStock(t *testing.T) {
+	m := metrics.New()
+	m.MustRegister(metrics.NewProductCollector(map[string]domain.ProductCounter{
+		"mysql":   &stubRepo{products: []domain.Product{{Stock: 3}, {Stock: 4}}},
+		"mongodb": &stubRepo{err: errors.New("down")},
+	}, time.Minute, time.Second))
+
+	app := fiber.New()
+	app.Get("/metrics", m.Handler())
+	body := scrape(t, app)
+	assert.Contains(t, body, `product_management_products_count{backend="mysql"} 2`)
+	assert.Contains(t, body, `product_management_products_stock_total{backend="mysql"} 7`)
+	assert.Contains(t, body, `product_management_products_scrape_error{backend="mongodb"} 1`)
+	assert.False(t, strings.Contains(body, `product_management_products_count{backend="mongodb"}`))
+}
@@ -0,0 +1,33 @@
+// internal/metrics/middleware.go
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Middleware records request counts and latency per route and status.
+// The route label uses the registered path (e.g. /products/:id) rather than
+// the raw URL so that product IDs do not explode label cardinality. Errors
+// are rendered further down the chain, so the status is the one sent.
+func (m *Metrics) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+
+		route := c.Route().Path
+		if route == "/" && c.Path() != "/" {
+			// Only this middleware matched, so no handler route exists.
+			route = "unmatched"
+		}
+
+		labels := []string{c.Method(), route, strconv.Itoa(status)}
+		m.httpRequests.WithLabelValues(labels...).Inc()
+		m.httpDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
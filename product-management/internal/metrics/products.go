@@ -0,0 +1,95 @@
+// internal/metrics/products.go
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"product-management/internal/domain"
+	"product-management/internal/tenant"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	productCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "products", "count"),
+		"Number of products stored in each backend.",
+		[]string{"backend"}, nil,
+	)
+	productStockDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "products", "stock_total"),
+		"Sum of product stock in each backend.",
+		[]string{"backend"}, nil,
+	)
+	productScrapeErrorDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "products", "scrape_error"),
+		"1 if the last product scan of the backend failed, 0 otherwise.",
+		[]string{"backend"}, nil,
+	)
+)
+
+type productSnapshot struct {
+	stats domain.ProductStats
+	err   error
+}
+
+// ProductCollector exposes business gauges (product count and total stock)
+// per backend across all tenants. Each backend answers them with one count
+// query, bounded by timeout; results are cached for the configured interval
+// so frequent scrapes do not add load.
+type ProductCollector struct {
+	repos    map[string]domain.ProductCounter
+	interval time.Duration
+	timeout  time.Duration
+
+	mu        sync.Mutex
+	snapshots map[string]productSnapshot
+	updatedAt time.Time
+}
+
+// NewProductCollector creates a collector over the given backends, keyed by
+// backend name. A zero interval queries on every scrape.
+func NewProductCollector(repos map[string]domain.ProductCounter, interval, timeout time.Duration) *ProductCollector {
+	return &ProductCollector{repos: repos, interval: interval, timeout: timeout}
+}
+
+// Describe implements prometheus.Collector.
+func (c *ProductCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- productCountDesc
+	ch <- productStockDesc
+	ch <- productScrapeErrorDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *ProductCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.snapshots == nil || time.Since(c.updatedAt) >= c.interval {
+		c.refresh()
+	}
+
+	for backend, snap := range c.snapshots {
+		if snap.err != nil {
+			ch <- prometheus.MustNewConstMetric(productScrapeErrorDesc, prometheus.GaugeValue, 1, backend)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(productScrapeErrorDesc, prometheus.GaugeValue, 0, backend)
+		ch <- prometheus.MustNewConstMetric(productCountDesc, prometheus.GaugeValue, float64(snap.stats.Count), backend)
+		ch <- prometheus.MustNewConstMetric(productStockDesc, prometheus.GaugeValue, float64(snap.stats.Stock), backend)
+	}
+}
+
+func (c *ProductCollector) refresh() {
+	ctx, cancel := context.WithTimeout(tenant.WithAllTenants(context.Background()), c.timeout)
+	defer cancel()
+	snapshots := make(map[string]productSnapshot, len(c.repos))
+	for backend, repo := range c.repos {
+		stats, err := repo.CountProducts(ctx)
+		snapshots[backend] = productSnapshot{stats: stats, err: err}
+	}
+	c.snapshots = snapshots
+	c.updatedAt = time.Now()
+}
@@ -0,0 +1,97 @@
+// internal/metrics/repository.go
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"product-management/internal/domain"
+)
+
+// InstrumentedRepository decorates a ProductRepository and records call
+// latency and errors labelled with the backend name.
+type InstrumentedRepository struct {
+	next    domain.ProductRepository
+	backend string
+	metrics *Metrics
+}
+
+// NewInstrumentedRepository wraps repo so every call is observed under backend.
+func NewInstrumentedRepository(repo domain.ProductRepository, backend string, m *Metrics) *InstrumentedRepository {
+	return &InstrumentedRepository{next: repo, backend: backend, metrics: m}
+}
+
+func (r *InstrumentedRepository) observe(operation string, start time.Time, err error) {
+	r.metrics.repoDuration.WithLabelValues(r.backend, operation).Observe(time.Since(start).Seconds())
+	// A missing product is an answer, not a failure of the backend.
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		r.metrics.repoErrors.WithLabelValues(r.backend, operation).Inc()
+	}
+}
+
+func (r *InstrumentedRepository) Create(ctx context.Context, product *domain.Product) error {
+	start := time.Now()
+	err := r.next.Create(ctx, product)
+	r.observe("create", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) GetAllProducts(ctx context.Context) ([]domain.Product, error) {
+	start := time.Now()
+	products, err := r.next.GetAllProducts(ctx)
+	r.observe("get_all", start, err)
+	return products, err
+}
+
+// SearchProducts fails if the wrapped repository cannot search.
+func (r *InstrumentedRepository) SearchProducts(ctx context.Context, filter domain.ProductFilter, page domain.Page) ([]domain.Product, error) {
+	searcher, ok := r.next.(domain.ProductSearcher)
+	if !ok {
+		return nil, errors.New("repository " + r.backend + " cannot search products")
+	}
+	start := time.Now()
+	products, err := searcher.SearchProducts(ctx, filter, page)
+	r.observe("search", start, err)
+	return products, err
+}
+
+// CountProducts fails if the wrapped repository cannot count.
+func (r *InstrumentedRepository) CountProducts(ctx context.Context) (domain.ProductStats, error) {
+	counter, ok := r.next.(domain.ProductCounter)
+	if !ok {
+		return domain.ProductStats{}, errors.New("repository " + r.backend + " cannot count products")
+	}
+	start := time.Now()
+	stats, err := counter.CountProducts(ctx)
+	r.observe("count", start, err)
+	return stats, err
+}
+
+func (r *InstrumentedRepository) GetProductById(ctx context.Context, id string) (*domain.Product, error) {
+	start := time.Now()
+	product, err := r.next.GetProductById(ctx, id)
+	r.observe("get_by_id", start, err)
+	return product, err
+}
+
+func (r *InstrumentedRepository) GetProductsByIds(ctx context.Context, ids []string) ([]domain.Product, error) {
+	start := time.Now()
+	products, err := r.next.GetProductsByIds(ctx, ids)
+	r.observe("get_by_ids", start, err)
+	return products, err
+}
+
+func (r *InstrumentedRepository) UpdateProduct(ctx context.Context, id string, product *domain.Product) error {
+	start := time.Now()
+	err := r.next.UpdateProduct(ctx, id, product)
+	r.observe("update", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) DeleteProduct(ctx context.Context, id string) error {
+	start := time.Now()
+	err := r.next.DeleteProduct(ctx, id)
+	r.observe("delete", start, err)
+	return err
+}
@@ -0,0 +1,10 @@
+// internal/metrics/shadow.go
+package metrics
+
+// ObserveShadowRead counts one shadow read and the fields that differed.
+func (m *Metrics) ObserveShadowRead(operation, result string, fields []string) {
+	m.shadowReads.WithLabelValues(operation, result).Inc()
+	for _, field := range fields {
+		m.shadowMismatches.WithLabelValues(operation, field).Inc()
+	}
+}
@@ -0,0 +1,105 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"product-management/internal/openapi"
+	"product-management/internal/problem"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newApp(t *testing.T, opts openapi.Options, handler fiber.Handler) *fiber.App {
+	validator, err := openapi.NewValidator(opts)
+	require.NoError(t, err)
+	app := fiber.New()
+	app.Use(validator.Middleware())
+	app.Post("/products", handler)
+	app.Get("/products/:id", handler)
+	app.Get("/undocumented", handler)
+	return app
+}
+
+func send(t *testing.T, app *fiber.App, method, path, body string) (int, map[string]any) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	raw, _ := io.ReadAll(resp.Body)
+	var out map[string]any
+	json.Unmarshal(raw, &out)
+	return resp.StatusCode, out
+}
+
+func TestSpecIsServed(t *testing.T) {
+	app := fiber.New()
+	app.Get("/openapi.json", openapi.Handler)
+	app.Get("/docs", openapi.SwaggerUI)
+
+	code, doc := send(t, app, "GET", "/openapi.json", "")
+	assert.Equal(t, fiber.StatusOK, code)
+	assert.Equal(t, "3.1.0", doc["openapi"])
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/docs", nil))
+	require.NoError(t, err)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+}
+
+func TestRequestValidation(t *testing.T) {
+	app := newApp(t, openapi.Options{}, func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	code, _ := send(t, app, "POST", "/products", `{"name":"kecap","description":"asus","price":10000,"stock":20}`)
+	assert.Equal(t, fiber.StatusNoContent, code)
+
+	code, body := send(t, app, "POST", "/products", `{"name":"kecap","price":-1,"stock":"20"}`)
+	assert.Equal(t, fiber.StatusBadRequest, code)
+	assert.Equal(t, "Request does not match the API contract", body["detail"])
+	assert.ElementsMatch(t, []any{
+		map[string]any{"name": "body", "reason": "missing property 'description'"},
+		map[string]any{"name": "price", "reason": "exclusiveMinimum: got -1, want 0"},
+		map[string]any{"name": "stock", "reason": "got string, want integer"},
+	}, body["invalid_params"])
+
+	code, _ = send(t, app, "POST", "/products", "")
+	assert.Equal(t, fiber.StatusBadRequest, code)
+
+	code, _ = send(t, app, "GET", "/undocumented", "")
+	assert.Equal(t, fiber.StatusNoContent, code)
+}
+
+func TestResponseValidation(t *testing.T) {
+	respond := func(status int, body fiber.Map) fiber.Handler {
+		return func(c *fiber.Ctx) error { return c.Status(status).JSON(body) }
+	}
+
+	app := newApp(t, openapi.Options{ValidateResponses: true},
+		respond(fiber.StatusOK, fiber.Map{"name": "kecap", "description": "asus", "price": 1, "stock": 2}))
+	code, _ := send(t, app, "GET", "/products/1", "")
+	assert.Equal(t, fiber.StatusOK, code)
+
+	app = newApp(t, openapi.Options{ValidateResponses: true}, respond(fiber.StatusOK, fiber.Map{"name": "kecap"}))
+	code, body := send(t, app, "GET", "/products/1", "")
+	assert.Equal(t, fiber.StatusInternalServerError, code)
+	assert.Equal(t, "Response does not match the API contract", body["detail"])
+
+	// Violations are logged, not sent to the client.
+	app = newApp(t, openapi.Options{ValidateResponses: true}, respond(fiber.StatusTeapot, fiber.Map{}))
+	code, body = send(t, app, "GET", "/products/1", "")
+	assert.Equal(t, fiber.StatusInternalServerError, code)
+	assert.NotContains(t, body, "invalid_params")
+
+	app = newApp(t, openapi.Options{ValidateResponses: true}, func(c *fiber.Ctx) error {
+		return problem.Respond(c, fiber.StatusNotFound, "Product not found")
+	})
+	code, _ = send(t, app, "GET", "/products/1", "")
+	assert.Equal(t, fiber.StatusNotFound, code)
+}
@@ -0,0 +1,319 @@
+// internal/openapi/validator.go
+package openapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"product-management/internal/problem"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+const (
+	specURL  = "file:///openapi.json"
+	jsonType = "application/json"
+)
+
+var methods = []string{"get", "put", "post", "delete", "patch", "head", "options"}
+
+// Options configures the validation middleware.
+type Options struct {
+	// ValidateResponses checks outgoing bodies and status codes against the
+	// spec and replaces mismatching responses with a 500. Meant for tests,
+	// where it turns drift between handlers and the contract into failures.
+	ValidateResponses bool
+}
+
+// Validator checks requests (and optionally responses) against the spec.
+type Validator struct {
+	opts       Options
+	doc        map[string]any
+	compiler   *jsonschema.Compiler
+	operations []*operation
+	printer    *message.Printer
+
+	mu      sync.Mutex
+	schemas map[string]*jsonschema.Schema
+}
+
+type operation struct {
+	method   string
+	segments []string
+	pointer  string
+	literals int
+}
+
+// NewValidator parses the embedded spec.
+func NewValidator(opts Options) (*Validator, error) {
+	return newValidator(spec, opts)
+}
+
+func newValidator(raw []byte, opts Options) (*Validator, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("parse OpenAPI document: %w", err)
+	}
+	root, ok := doc.(map[string]any)
+	if !ok {
+		return nil, errors.New("OpenAPI document is not an object")
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(specURL, doc); err != nil {
+		return nil, err
+	}
+
+	v := &Validator{
+		opts:     opts,
+		doc:      root,
+		compiler: compiler,
+		printer:  message.NewPrinter(language.English),
+		schemas:  make(map[string]*jsonschema.Schema),
+	}
+
+	paths, _ := root["paths"].(map[string]any)
+	for path, item := range paths {
+		item, _ := item.(map[string]any)
+		for _, method := range methods {
+			if _, ok := item[method]; !ok {
+				continue
+			}
+			op := &operation{
+				method:   strings.ToUpper(method),
+				segments: strings.Split(strings.Trim(path, "/"), "/"),
+				pointer:  "#/paths/" + escape(path) + "/" + method,
+			}
+			for _, s := range op.segments {
+				if !strings.HasPrefix(s, "{") {
+					op.literals++
+				}
+			}
+			v.operations = append(v.operations, op)
+		}
+	}
+	// Prefer literal segments so /products/stream wins over /products/{id}.
+	sort.SliceStable(v.operations, func(i, j int) bool {
+		return v.operations[i].literals > v.operations[j].literals
+	})
+	return v, nil
+}
+
+// Middleware validates JSON request bodies of documented operations and
+// answers 400 with the violations. Undocumented routes pass through.
+func (v *Validator) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		op := v.find(c.Method(), c.Path())
+		if op == nil {
+			return c.Next()
+		}
+
+		if details := v.validateRequest(c, op); len(details) > 0 {
+			p := problem.New(fiber.StatusBadRequest, "Request does not match the API contract")
+			p.InvalidParams = invalidParams(details)
+			return problem.Write(c, p)
+		}
+
+		err := c.Next()
+		if !v.opts.ValidateResponses {
+			return err
+		}
+		if err != nil {
+			// Error responses are validated too. The app's error handler
+			// writes a request's error only once, and the error is passed on.
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				return err
+			}
+		}
+		if details := v.validateResponse(c, op); len(details) > 0 {
+			slog.ErrorContext(c.UserContext(), "Response does not match the API contract",
+				"method", c.Method(), "path", c.Path(), "status", c.Response().StatusCode(), "details", details)
+			// The violations describe the server, so they stay in the log.
+			c.Response().ResetBody()
+			if werr := problem.Write(c, problem.New(fiber.StatusInternalServerError, "Response does not match the API contract")); werr != nil {
+				return werr
+			}
+		}
+		return err
+	}
+}
+
+// ValidateSchema checks a JSON document against a named schema under
+// components/schemas, so other transports share the REST contract's rules.
+// It returns one line per violation.
+func (v *Validator) ValidateSchema(name string, raw []byte) []string {
+	return v.validate("#/components/schemas/"+escape(name), raw)
+}
+
+func (v *Validator) find(method, path string) *operation {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, op := range v.operations {
+		if op.method != method || len(op.segments) != len(segments) {
+			continue
+		}
+		match := true
+		for i, s := range op.segments {
+			if !strings.HasPrefix(s, "{") && s != segments[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return op
+		}
+	}
+	return nil
+}
+
+func (v *Validator) validateRequest(c *fiber.Ctx, op *operation) []string {
+	body, pointer := v.resolve(op.pointer + "/requestBody")
+	if body == nil {
+		return nil
+	}
+	required, _ := body["required"].(bool)
+	if len(c.Body()) == 0 {
+		if required {
+			return []string{"request body is required"}
+		}
+		return nil
+	}
+	if !hasJSONContent(body) {
+		return nil
+	}
+	if !strings.HasPrefix(c.Get(fiber.HeaderContentType), jsonType) {
+		return []string{"content type must be " + jsonType}
+	}
+	return v.validate(pointer+"/content/"+escape(jsonType)+"/schema", c.Body())
+}
+
+func (v *Validator) validateResponse(c *fiber.Ctx, op *operation) []string {
+	status := c.Response().StatusCode()
+	response, pointer := v.resolve(op.pointer + "/responses/" + strconv.Itoa(status))
+	if response == nil {
+		response, pointer = v.resolve(op.pointer + "/responses/default")
+	}
+	if response == nil {
+		return []string{fmt.Sprintf("status %d is not documented", status)}
+	}
+	if !hasJSONContent(response) {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(string(c.Response().Header.ContentType()))
+	content, _ := response["content"].(map[string]any)
+	if _, ok := content[mediaType]; !ok || !isJSON(mediaType) {
+		return []string{fmt.Sprintf("content type %q is not documented for status %d", mediaType, status)}
+	}
+	return v.validate(pointer+"/content/"+escape(mediaType)+"/schema", c.Response().Body())
+}
+
+// invalidParams turns "/pointer: reason" violations into problem members.
+// Violations of the whole body are named "body".
+func invalidParams(details []string) []problem.InvalidParam {
+	params := make([]problem.InvalidParam, len(details))
+	for i, d := range details {
+		location, reason, _ := strings.Cut(d, ": ")
+		name := strings.TrimPrefix(location, "/")
+		if name == "" {
+			name = "body"
+		}
+		params[i] = problem.InvalidParam{Name: name, Reason: reason}
+	}
+	return params
+}
+
+// validate checks a JSON document against the schema at pointer and
+// returns one line per violation.
+func (v *Validator) validate(pointer string, raw []byte) []string {
+	schema, err := v.schema(pointer)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return []string{"body is not valid JSON"}
+	}
+	err = schema.Validate(instance)
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return nil
+	}
+	var details []string
+	v.collect(ve, &details)
+	return details
+}
+
+func (v *Validator) collect(ve *jsonschema.ValidationError, details *[]string) {
+	if len(ve.Causes) == 0 {
+		*details = append(*details, "/"+strings.Join(ve.InstanceLocation, "/")+": "+ve.ErrorKind.LocalizedString(v.printer))
+	}
+	for _, cause := range ve.Causes {
+		v.collect(cause, details)
+	}
+}
+
+func (v *Validator) schema(pointer string) (*jsonschema.Schema, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok := v.schemas[pointer]; ok {
+		return s, nil
+	}
+	s, err := v.compiler.Compile(specURL + pointer)
+	if err != nil {
+		return nil, fmt.Errorf("compile schema %s: %w", pointer, err)
+	}
+	v.schemas[pointer] = s
+	return s, nil
+}
+
+// resolve returns the object at a JSON pointer, following $ref once as used
+// for shared responses, and the pointer of the resolved object.
+func (v *Validator) resolve(pointer string) (map[string]any, string) {
+	var node any = v.doc
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "#/"), "/") {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, ""
+		}
+		node = m[unescape(token)]
+	}
+	obj, ok := node.(map[string]any)
+	if !ok {
+		return nil, ""
+	}
+	if ref, ok := obj["$ref"].(string); ok {
+		return v.resolve(ref)
+	}
+	return obj, pointer
+}
+
+func hasJSONContent(obj map[string]any) bool {
+	content, _ := obj["content"].(map[string]any)
+	for mediaType := range content {
+		if isJSON(mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+func escape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func unescape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
+
+// isJSON reports whether mediaType is application/json or a JSON-based type
+// such as application/problem+json.
+func isJSON(mediaType string) bool {
+	return mediaType == jsonType || strings.HasSuffix(mediaType, "+json")
+}
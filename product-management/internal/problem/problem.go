@@ -0,0 +1,64 @@
+// internal/problem/problem.go
+package problem
+
+import (
+	"product-management/internal/logging"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// ContentType is the media type of problem details (RFC 7807).
+const ContentType = "application/problem+json"
+
+// Problem types. Errors that need no more explanation than their status
+// use "about:blank", whose title is the status text.
+const (
+	TypeBlank       = "about:blank"
+	TypeNotFound    = "/problems/not-found"
+	TypeConflict    = "/problems/conflict"
+	TypeValidation  = "/problems/validation"
+	TypeUnavailable = "/problems/unavailable"
+)
+
+// Problem is an RFC 7807 problem details object. Detail must be safe to
+// show to clients; internal error text belongs in the server log.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// RequestID matches the X-Request-ID header and the server log.
+	RequestID string `json:"request_id,omitempty"`
+	// InvalidParams lists the input fields that failed validation.
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+}
+
+// InvalidParam is one invalid input field.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// New returns a problem of type about:blank.
+func New(status int, detail string) *Problem {
+	return &Problem{Type: TypeBlank, Title: utils.StatusMessage(status), Status: status, Detail: detail}
+}
+
+// Write sends p as the response. The instance defaults to the request path
+// and the request ID is taken from the request context.
+func Write(c *fiber.Ctx, p *Problem) error {
+	if p.Instance == "" {
+		p.Instance = c.OriginalURL()
+	}
+	if p.RequestID == "" {
+		p.RequestID = logging.RequestIDFromContext(c.UserContext())
+	}
+	return c.Status(p.Status).JSON(p, ContentType)
+}
+
+// Respond writes an about:blank problem; a shorthand for middleware.
+func Respond(c *fiber.Ctx, status int, detail string) error {
+	return Write(c, New(status, detail))
+}
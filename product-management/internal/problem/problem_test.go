@@ -0,0 +1,61 @@
+package problem_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"product-management/internal/logging"
+	"product-management/internal/problem"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRespondWritesProblemDetails(t *testing.T) {
+	app := fiber.New()
+	app.Use(logging.RequestID())
+	app.Get("/products/:id", func(c *fiber.Ctx) error {
+		return problem.Respond(c, fiber.StatusTooManyRequests, "Rate limit exceeded")
+	})
+
+	req := httptest.NewRequest("GET", "/products/7?fields=name", nil)
+	req.Header.Set(logging.RequestIDHeader, "abc-123")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, problem.ContentType, resp.Header.Get("Content-Type"))
+
+	var got problem.Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, problem.Problem{
+		Type:      problem.TypeBlank,
+		Title:     "Too Many Requests",
+		Status:    fiber.StatusTooManyRequests,
+		Detail:    "Rate limit exceeded",
+		Instance:  "/products/7?fields=name",
+		RequestID: "abc-123",
+	}, got)
+}
+
+func TestWriteKeepsExplicitMembers(t *testing.T) {
+	app := fiber.New()
+	app.Post("/products", func(c *fiber.Ctx) error {
+		return problem.Write(c, &problem.Problem{
+			Type:          problem.TypeValidation,
+			Title:         "Validation Failed",
+			Status:        fiber.StatusUnprocessableEntity,
+			Instance:      "/products/new",
+			InvalidParams: []problem.InvalidParam{{Name: "price", Reason: "must be greater than 0"}},
+		})
+	})
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/products", nil))
+	assert.NoError(t, err)
+
+	var got map[string]any
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, "/products/new", got["instance"])
+	assert.NotContains(t, got, "detail")
+	assert.NotContains(t, got, "request_id")
+	assert.Equal(t, []any{map[string]any{"name": "price", "reason": "must be greater than 0"}}, got["invalid_params"])
+}
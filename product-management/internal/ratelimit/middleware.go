@@ -0,0 +1,77 @@
+// internal/ratelimit/middleware.go
+package ratelimit
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"product-management/internal/auth"
+	"product-management/internal/problem"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Route classes with separately configured limits.
+const (
+	ClassReads   = "reads"
+	ClassWrites  = "writes"
+	ClassExports = "exports"
+)
+
+// Limiter applies per-client limits for each route class.
+type Limiter struct {
+	store  Store
+	limits map[string]Limit
+}
+
+// New creates a Limiter. Classes missing from limits are not limited.
+func New(store Store, limits map[string]Limit) *Limiter {
+	return &Limiter{store: store, limits: limits}
+}
+
+// ClientKey identifies the caller: the authenticated subject (API key name
+// or JWT subject) when there is one, otherwise the client IP.
+func ClientKey(c *fiber.Ctx) string {
+	if p := auth.PrincipalFromContext(c.UserContext()); p != nil && p.Subject != "anonymous" {
+		return "sub:" + p.Subject
+	}
+	return "ip:" + c.IP()
+}
+
+// Handler limits requests of the given class. It sets the RateLimit-Limit,
+// RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers on every
+// response and answers 429 with Retry-After once the bucket is empty. If the
+// store fails the request is let through.
+func (l *Limiter) Handler(class string) fiber.Handler {
+	limit, ok := l.limits[class]
+	if !ok || limit.Requests <= 0 {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
+	policy := fmt.Sprintf("%d;w=%d", limit.Requests, int(limit.Period.Seconds()))
+
+	return func(c *fiber.Ctx) error {
+		result, err := l.store.Take(c.UserContext(), class+"|"+ClientKey(c), limit)
+		if err != nil {
+			slog.ErrorContext(c.UserContext(), "Rate limit store failed", "class", class, "error", err)
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("RateLimit-Reset", ceilSeconds(result.Reset))
+		c.Set("RateLimit-Policy", policy)
+
+		if !result.Allowed {
+			c.Set(fiber.HeaderRetryAfter, ceilSeconds(result.RetryAfter))
+			return problem.Respond(c, fiber.StatusTooManyRequests, "Rate limit exceeded")
+		}
+		return c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,94 @@
+package ratelimit_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"product-management/internal/auth"
+	"product-management/internal/ratelimit"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newApp(limits map[string]ratelimit.Limit) *fiber.App {
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), limits)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if sub := c.Get("X-Test-Subject"); sub != "" {
+			c.SetUserContext(auth.WithPrincipal(c.UserContext(), &auth.Principal{Subject: sub, Role: auth.RoleViewer}))
+		}
+		return c.Next()
+	})
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/products", limiter.Handler(ratelimit.ClassReads), ok)
+	app.Post("/products", limiter.Handler(ratelimit.ClassWrites), ok)
+	return app
+}
+
+func get(t *testing.T, app *fiber.App, method, subject string) (int, map[string]string) {
+	req := httptest.NewRequest(method, "/products", nil)
+	if subject != "" {
+		req.Header.Set("X-Test-Subject", subject)
+	}
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	headers := map[string]string{}
+	for _, h := range []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"} {
+		headers[h] = resp.Header.Get(h)
+	}
+	return resp.StatusCode, headers
+}
+
+func TestLimitsPerClientAndClass(t *testing.T) {
+	app := newApp(map[string]ratelimit.Limit{
+		ratelimit.ClassReads:  {Requests: 2, Period: time.Minute},
+		ratelimit.ClassWrites: {Requests: 1, Period: time.Minute},
+	})
+
+	code, headers := get(t, app, "GET", "alice")
+	assert.Equal(t, fiber.StatusOK, code)
+	assert.Equal(t, "2", headers["RateLimit-Limit"])
+	assert.Equal(t, "1", headers["RateLimit-Remaining"])
+
+	code, _ = get(t, app, "GET", "alice")
+	assert.Equal(t, fiber.StatusOK, code)
+
+	code, headers = get(t, app, "GET", "alice")
+	assert.Equal(t, fiber.StatusTooManyRequests, code)
+	assert.Equal(t, "0", headers["RateLimit-Remaining"])
+	assert.Equal(t, "30", headers["Retry-After"])
+
+	// Another client and another class have their own buckets.
+	code, _ = get(t, app, "GET", "bob")
+	assert.Equal(t, fiber.StatusOK, code)
+	code, _ = get(t, app, "POST", "alice")
+	assert.Equal(t, fiber.StatusOK, code)
+}
+
+func TestUnconfiguredClassIsNotLimited(t *testing.T) {
+	app := newApp(map[string]ratelimit.Limit{ratelimit.ClassReads: {Requests: 1, Period: time.Minute}})
+	for i := 0; i < 3; i++ {
+		code, headers := get(t, app, "POST", "")
+		assert.Equal(t, fiber.StatusOK, code)
+		assert.Empty(t, headers["RateLimit-Limit"])
+	}
+}
+
+func TestMemoryStoreRefills(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Requests: 10, Period: 100 * time.Millisecond}
+	for i := 0; i < 10; i++ {
+		result, err := store.Take(context.Background(), "k", limit)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+	result, _ := store.Take(context.Background(), "k", limit)
+	assert.False(t, result.Allowed)
+	assert.Greater(t, result.RetryAfter, time.Duration(0))
+
+	time.Sleep(20 * time.Millisecond)
+	result, _ = store.Take(context.Background(), "k", limit)
+	assert.True(t, result.Allowed)
+}
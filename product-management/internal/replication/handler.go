@@ -0,0 +1,83 @@
+// internal/replication/handler.go
+package replication
+
+import (
+	"errors"
+	"product-management/internal/domain"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxDeadLetters bounds the dead letters returned per request.
+const maxDeadLetters = 100
+
+// Handler serves the dead-letter admin API for the tenant of the request.
+type Handler struct {
+	store      Store
+	replicator *Replicator
+}
+
+// NewHandler creates a Handler. replicator is woken after retries.
+func NewHandler(store Store, replicator *Replicator) *Handler {
+	return &Handler{store: store, replicator: replicator}
+}
+
+// ListDeadLetters returns the oldest mutations that ran out of attempts.
+// Each one holds back the later mutations of its product.
+func (h *Handler) ListDeadLetters(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 50)
+	if limit < 1 || limit > maxDeadLetters {
+		limit = maxDeadLetters
+	}
+	mutations, err := h.store.ListDeadLetters(c.UserContext(), limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(mutations)
+}
+
+// RetryDeadLetter queues a dead letter again, e.g. after the secondary has
+// recovered.
+func (h *Handler) RetryDeadLetter(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err == nil {
+		err = h.store.Retry(c.UserContext(), id, time.Now())
+	}
+	if err != nil {
+		return notFound(c, err)
+	}
+	if h.replicator != nil {
+		h.replicator.Wake()
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Mutation queued",
+	})
+}
+
+// DiscardDeadLetter drops a dead letter without applying it.
+func (h *Handler) DiscardDeadLetter(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err == nil {
+		err = h.store.Discard(c.UserContext(), id)
+	}
+	if err != nil {
+		return notFound(c, err)
+	}
+	if h.replicator != nil {
+		// Later mutations of the product may be due now.
+		h.replicator.Wake()
+	}
+	return c.JSON(fiber.Map{
+		"message": "Dead letter successfully discarded",
+	})
+}
+
+// notFound turns ErrNotFound and malformed IDs into a domain error.
+func notFound(c *fiber.Ctx, err error) error {
+	if errors.Is(err, ErrNotFound) || errors.Is(err, strconv.ErrSyntax) || errors.Is(err, strconv.ErrRange) {
+		return domain.NewError(domain.ErrNotFound, "Dead letter "+c.Params("id")+" not found", err)
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+// internal/replication/replication.go
+package replication
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"product-management/internal/domain"
+)
+
+// ErrNotFound is returned for unknown dead letters, and for those of
+// another tenant.
+var ErrNotFound = errors.New("replication: not found")
+
+// Operations a mutation replays on the secondary.
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// Mutation statuses. Applied mutations are removed from the queue.
+const (
+	StatusPending = "pending"
+	StatusDead    = "dead"
+)
+
+// Mutation is a write committed to the primary slot and waiting to be
+// applied to the secondary.
+type Mutation struct {
+	// ID orders the mutations; those of one product are applied in ID order.
+	ID        int64  `json:"id"`
+	TenantID  string `json:"tenant_id"`
+	ProductID string `json:"product_id"`
+	Op        string `json:"op"`
+	// Name finds the secondary's copy of the product, which has an ID of
+	// its own: the name before an update, or of the deleted product.
+	Name string `json:"name"`
+	// Product is the state written by a create or update.
+	Product       *domain.Product `json:"product,omitempty"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// Store is the durable queue of mutations. Methods used by the admin API
+// are scoped to the tenant in the context; the workers call the others
+// across all tenants.
+type Store interface {
+	Enqueue(ctx context.Context, m *Mutation) error
+	ListDeadLetters(ctx context.Context, limit int) ([]Mutation, error)
+	// Retry moves a dead letter back to the queue with a fresh attempt
+	// budget.
+	Retry(ctx context.Context, id int64, at time.Time) error
+	// Discard drops a dead letter, letting later mutations of its product
+	// through.
+	Discard(ctx context.Context, id int64) error
+
+	// Due returns pending mutations whose next attempt is due and that are
+	// the oldest queued mutation of their product.
+	Due(ctx context.Context, now time.Time, limit int) ([]Mutation, error)
+	// Claim moves a due mutation's next attempt to leaseUntil so other
+	// workers skip it while it is applied. It reports false when someone
+	// else claimed it first.
+	Claim(ctx context.Context, m *Mutation, leaseUntil time.Time) (bool, error)
+	// Complete removes an applied mutation.
+	Complete(ctx context.Context, m *Mutation) error
+	// RecordFailure stores the outcome of a failed attempt.
+	RecordFailure(ctx context.Context, m *Mutation) error
+}
@@ -0,0 +1,405 @@
+package replication_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"product-management/internal/config"
+	"product-management/internal/domain"
+	"product-management/internal/handler"
+	"product-management/internal/idmap"
+	"product-management/internal/replication"
+	"product-management/internal/repository/memory"
+	"product-management/internal/repository/mysql/mysqltest"
+	"product-management/internal/repository/sqlite"
+	"product-management/internal/service"
+	"product-management/internal/tenant"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = config.ReplicationConfig{
+	Async:        true,
+	PollInterval: time.Hour,
+	Workers:      4,
+	ApplyTimeout: 5 * time.Second,
+	MaxAttempts:  2,
+}
+
+// stores open an empty store of each kind.
+var stores = map[string]func(t *testing.T) replication.Store{
+	"mysql": func(t *testing.T) replication.Store {
+		store := replication.NewMySQLStore(mysqltest.New(t))
+		require.NoError(t, store.EnsureSchema(context.Background()))
+		return store
+	},
+	"sqlite": func(t *testing.T) replication.Store {
+		db, err := sqlite.Open(filepath.Join(t.TempDir(), "produk.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		store := replication.NewSQLiteStore(db)
+		require.NoError(t, store.EnsureSchema(context.Background()))
+		return store
+	},
+}
+
+// secondaryRepo generates IDs of its own, logs the writes applied to it by
+// product name and fails writes to the products named in down.
+type secondaryRepo struct {
+	domain.ProductRepository
+	mu       sync.Mutex
+	nextID   int
+	products map[string]domain.Product
+	log      []string
+	down     map[string]bool
+}
+
+func newSecondary() *secondaryRepo {
+	return &secondaryRepo{products: map[string]domain.Product{}, down: map[string]bool{}}
+}
+
+func (r *secondaryRepo) Create(ctx context.Context, p *domain.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.down[p.Name] {
+		return errors.New("server selection timeout")
+	}
+	p.TenantID, _ = tenant.FromContext(ctx)
+	if _, exists := r.byName(p.TenantID, p.Name); exists {
+		return domain.NewError(domain.ErrConflict, "A product with this name already exists", nil)
+	}
+	r.nextID++
+	p.ID = strconv.Itoa(r.nextID)
+	r.products[p.ID] = *p
+	r.log = append(r.log, fmt.Sprintf("create %s stock=%d", p.Name, p.Stock))
+	return nil
+}
+
+func (r *secondaryRepo) UpdateProduct(ctx context.Context, id string, p *domain.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.products[id]
+	if !ok {
+		return domain.ProductNotFound(nil)
+	}
+	if r.down[existing.Name] {
+		return errors.New("server selection timeout")
+	}
+	existing.Name, existing.Description, existing.Price, existing.Stock = p.Name, p.Description, p.Price, p.Stock
+	r.products[id] = existing
+	r.log = append(r.log, fmt.Sprintf("update %s stock=%d", existing.Name, existing.Stock))
+	return nil
+}
+
+func (r *secondaryRepo) DeleteProduct(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.products[id]
+	if !ok {
+		return domain.ProductNotFound(nil)
+	}
+	if r.down[existing.Name] {
+		return errors.New("server selection timeout")
+	}
+	delete(r.products, id)
+	r.log = append(r.log, "delete "+existing.Name)
+	return nil
+}
+
+func (r *secondaryRepo) SearchProducts(ctx context.Context, filter domain.ProductFilter, page domain.Page) ([]domain.Product, error) {
+	tenantID, _ := tenant.FromContext(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var products []domain.Product
+	for _, p := range r.products {
+		if p.TenantID == tenantID && filter.Matches(p) {
+			products = append(products, p)
+		}
+	}
+	return products, nil
+}
+
+// byName returns the tenant's product called name. The caller holds the
+// lock.
+func (r *secondaryRepo) byName(tenantID, name string) (domain.Product, bool) {
+	for _, p := range r.products {
+		if p.TenantID == tenantID && p.Name == name {
+			return p, true
+		}
+	}
+	return domain.Product{}, false
+}
+
+// product returns the copy of the tenant's product called name.
+func (r *secondaryRepo) product(tenantID, name string) (domain.Product, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.byName(tenantID, name)
+}
+
+func (r *secondaryRepo) setDown(name string, down bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.down[name] = down
+}
+
+func (r *secondaryRepo) applied() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.log...)
+}
+
+func setup(t *testing.T, store replication.Store) (*service.ProductService, *replication.Replicator, *secondaryRepo, context.Context) {
+	secondary := newSecondary()
+	productService, err := service.NewProductService(memory.NewMemoryProductRepository(), secondary)
+	require.NoError(t, err)
+	productService.SetIDMap(idmap.NewMemoryStore())
+	replicator := replication.New(store, productService, testConfig)
+	productService.SetReplicator(replicator)
+	return productService, replicator, secondary, tenant.WithTenant(context.Background(), "shop-a")
+}
+
+// drain polls until nothing more is applied.
+func drain(r *replication.Replicator) {
+	for r.Poll(context.Background()) > 0 {
+	}
+}
+
+func TestWritesReachTheSecondaryInOrder(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			productService, replicator, secondary, ctx := setup(t, newStore(t))
+
+			kecap := &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 10}
+			sambal := &domain.Product{Name: "sambal", Description: "pedas", Price: 9000, Stock: 4}
+			require.NoError(t, productService.CreateProduct(ctx, kecap))
+			require.NoError(t, productService.CreateProduct(ctx, sambal))
+			for stock := 9; stock >= 7; stock-- {
+				require.NoError(t, productService.UpdateProduct(ctx, kecap.ID, &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: stock}))
+			}
+			require.NoError(t, productService.DeleteProduct(ctx, sambal.ID))
+			assert.Empty(t, secondary.applied(), "writes return before the secondary is updated")
+
+			drain(replicator)
+			// Products are replicated in parallel, each in order.
+			var kecapLog []string
+			for _, entry := range secondary.applied() {
+				if strings.Fields(entry)[1] == "kecap" {
+					kecapLog = append(kecapLog, entry)
+				}
+			}
+			assert.Equal(t, []string{
+				"create kecap stock=10",
+				"update kecap stock=9",
+				"update kecap stock=8",
+				"update kecap stock=7",
+			}, kecapLog)
+			assert.Len(t, secondary.applied(), 6)
+			replica, ok := secondary.product("shop-a", "kecap")
+			require.True(t, ok)
+			assert.Equal(t, 7, replica.Stock)
+			_, ok = secondary.product("shop-a", "sambal")
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestMutationsFindTheSecondaryCopy(t *testing.T) {
+	productService, replicator, secondary, ctx := setup(t, stores["sqlite"](t))
+	// The secondary's IDs are offset from the primary's: its first product
+	// has the ID kecap gets in the primary.
+	require.NoError(t, secondary.Create(ctx, &domain.Product{Name: "tempe", Description: "goreng", Price: 5000, Stock: 7}))
+	tempe, _ := secondary.product("shop-a", "tempe")
+
+	kecap := &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 10}
+	require.NoError(t, productService.CreateProduct(ctx, kecap))
+	require.Equal(t, tempe.ID, kecap.ID)
+	drain(replicator)
+	replica, _ := secondary.product("shop-a", "kecap")
+	require.NotEqual(t, kecap.ID, replica.ID)
+
+	// The copy's recorded ID finds it across a rename.
+	require.NoError(t, productService.UpdateProduct(ctx, kecap.ID, &domain.Product{Name: "kecap asin", Description: "asin", Price: 12000, Stock: 8}))
+	require.NoError(t, productService.UpdateProduct(ctx, kecap.ID, &domain.Product{Name: "kecap asin", Description: "asin", Price: 12000, Stock: 6}))
+	require.NoError(t, productService.DeleteProduct(ctx, kecap.ID))
+	drain(replicator)
+
+	assert.Equal(t, []string{
+		"create tempe stock=7",
+		"create kecap stock=10",
+		"update kecap asin stock=8",
+		"update kecap asin stock=6",
+		"delete kecap asin",
+	}, secondary.applied())
+	got, ok := secondary.product("shop-a", "tempe")
+	require.True(t, ok, "the product with the primary's ID is left alone")
+	assert.Equal(t, tempe, got)
+}
+
+func TestFailedMutationsMoveToDeadLetters(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			productService, replicator, secondary, ctx := setup(t, store)
+
+			kecap := &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 10}
+			sambal := &domain.Product{Name: "sambal", Description: "pedas", Price: 9000, Stock: 4}
+			require.NoError(t, productService.CreateProduct(ctx, kecap))
+			require.NoError(t, productService.CreateProduct(ctx, sambal))
+			secondary.setDown("kecap", true)
+			require.NoError(t, productService.UpdateProduct(ctx, kecap.ID, &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 3}))
+
+			// Two attempts, then the create is dead and holds back the update.
+			drain(replicator)
+			drain(replicator)
+			assert.Equal(t, []string{"create sambal stock=4"}, secondary.applied())
+			dead, err := store.ListDeadLetters(ctx, 10)
+			require.NoError(t, err)
+			require.Len(t, dead, 1)
+			assert.Equal(t, replication.OpCreate, dead[0].Op)
+			assert.Equal(t, kecap.ID, dead[0].ProductID)
+			assert.Equal(t, "kecap", dead[0].Name)
+			assert.Equal(t, 2, dead[0].Attempts)
+			assert.Equal(t, "server selection timeout", dead[0].LastError)
+			assert.Equal(t, "kecap", dead[0].Product.Name)
+
+			// Other tenants do not see the dead letter.
+			other, err := store.ListDeadLetters(tenant.WithTenant(context.Background(), "shop-b"), 10)
+			require.NoError(t, err)
+			assert.Empty(t, other)
+			assert.ErrorIs(t, store.Retry(tenant.WithTenant(context.Background(), "shop-b"), dead[0].ID, time.Now()), replication.ErrNotFound)
+
+			secondary.setDown("kecap", false)
+			require.NoError(t, store.Retry(ctx, dead[0].ID, time.Now()))
+			drain(replicator)
+			assert.Equal(t, []string{
+				"create sambal stock=4",
+				"create kecap stock=10",
+				"update kecap stock=3",
+			}, secondary.applied())
+			dead, err = store.ListDeadLetters(ctx, 10)
+			require.NoError(t, err)
+			assert.Empty(t, dead)
+		})
+	}
+}
+
+func TestReplayedCreatesAreIdempotent(t *testing.T) {
+	store := stores["sqlite"](t)
+	productService, replicator, secondary, ctx := setup(t, store)
+
+	kecap := &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 10}
+	require.NoError(t, productService.CreateProduct(ctx, kecap))
+	// The secondary already has the product, e.g. from an attempt whose
+	// success was not recorded.
+	require.NoError(t, secondary.Create(ctx, &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 10}))
+	assert.Equal(t, 1, replicator.Poll(context.Background()))
+	assert.Equal(t, []string{"create kecap stock=10"}, secondary.applied())
+	dead, err := store.ListDeadLetters(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, dead)
+
+	// The adopted copy is the one later writes go to.
+	require.NoError(t, productService.UpdateProduct(ctx, kecap.ID, &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 4}))
+	drain(replicator)
+	replica, _ := secondary.product("shop-a", "kecap")
+	assert.Equal(t, 4, replica.Stock)
+}
+
+func TestCreatesConflictingWithAnotherProductAreNotApplied(t *testing.T) {
+	store := stores["sqlite"](t)
+	productService, replicator, secondary, ctx := setup(t, store)
+
+	kecap := &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 10}
+	require.NoError(t, productService.CreateProduct(ctx, kecap))
+	// Another product already holds the name in the secondary.
+	require.NoError(t, secondary.Create(ctx, &domain.Product{Name: "kecap", Description: "asin", Price: 8000, Stock: 2}))
+	drain(replicator)
+	drain(replicator)
+
+	dead, err := store.ListDeadLetters(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, replication.OpCreate, dead[0].Op)
+	assert.Contains(t, dead[0].LastError, "The secondary has another product with this name")
+	replica, _ := secondary.product("shop-a", "kecap")
+	assert.Equal(t, "asin", replica.Description, "the other product is left alone")
+}
+
+func TestMutationsWithoutSecondaryCopyAreNotDropped(t *testing.T) {
+	store := stores["sqlite"](t)
+	productService, replicator, secondary, ctx := setup(t, store)
+
+	kecap := &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 10}
+	require.NoError(t, productService.CreateProduct(ctx, kecap))
+	drain(replicator)
+	// The copy disappears from the secondary behind the replicator's back.
+	replica, _ := secondary.product("shop-a", "kecap")
+	require.NoError(t, secondary.DeleteProduct(ctx, replica.ID))
+
+	require.NoError(t, productService.UpdateProduct(ctx, kecap.ID, &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 3}))
+	assert.Zero(t, replicator.Poll(context.Background()))
+	assert.Zero(t, replicator.Poll(context.Background()))
+	dead, err := store.ListDeadLetters(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, replication.OpUpdate, dead[0].Op)
+	assert.Equal(t, "secondary has no copy of the product", dead[0].LastError)
+}
+
+func TestDeadLetterAdminAPI(t *testing.T) {
+	store := stores["sqlite"](t)
+	productService, replicator, secondary, ctx := setup(t, store)
+	kecap := &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 10}
+	sambal := &domain.Product{Name: "sambal", Description: "pedas", Price: 9000, Stock: 4}
+	require.NoError(t, productService.CreateProduct(ctx, kecap))
+	require.NoError(t, productService.CreateProduct(ctx, sambal))
+	secondary.setDown("kecap", true)
+	secondary.setDown("sambal", true)
+	drain(replicator)
+	drain(replicator)
+
+	h := replication.NewHandler(store, replicator)
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		c.SetUserContext(tenant.WithTenant(c.UserContext(), "shop-a"))
+		return c.Next()
+	})
+	app.Get("/replication/dead-letters", h.ListDeadLetters)
+	app.Post("/replication/dead-letters/:id/retry", h.RetryDeadLetter)
+	app.Delete("/replication/dead-letters/:id", h.DiscardDeadLetter)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/replication/dead-letters", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var dead []replication.Mutation
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&dead))
+	require.Len(t, dead, 2)
+	assert.Equal(t, replication.StatusDead, dead[0].Status)
+
+	secondary.setDown("kecap", false)
+	resp, err = app.Test(httptest.NewRequest("POST", fmt.Sprintf("/replication/dead-letters/%d/retry", dead[0].ID), nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusAccepted, resp.StatusCode)
+	resp, err = app.Test(httptest.NewRequest("DELETE", fmt.Sprintf("/replication/dead-letters/%d", dead[1].ID), nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	drain(replicator)
+	assert.Equal(t, []string{"create kecap stock=10"}, secondary.applied())
+	for _, path := range []string{"/replication/dead-letters/" + fmt.Sprint(dead[1].ID), "/replication/dead-letters/abc"} {
+		resp, err = app.Test(httptest.NewRequest("DELETE", path, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	}
+}
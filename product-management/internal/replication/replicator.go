@@ -0,0 +1,161 @@
+// internal/replication/replicator.go
+package replication
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"product-management/internal/config"
+	"product-management/internal/domain"
+	"product-management/internal/durable"
+	"product-management/internal/tenant"
+)
+
+// batchSize bounds how many due mutations one poll picks up.
+const batchSize = 100
+
+// Replicator queues writes committed to the primary slot and applies them
+// to the secondary in the background, retrying failures with exponential
+// backoff. The mutations of one product are applied in the order they were
+// queued.
+type Replicator struct {
+	store   Store
+	applier Applier
+	cfg     config.ReplicationConfig
+	worker  *durable.Worker
+}
+
+// Applier writes a mutation to the secondary's copy of a product, the way
+// service.ProductService does for synchronous writes.
+type Applier interface {
+	ApplyToSecondary(ctx context.Context, op, productID, name string, product *domain.Product) error
+}
+
+// New creates a Replicator that applies mutations through applier. Call
+// Start to begin applying.
+func New(store Store, applier Applier, cfg config.ReplicationConfig) *Replicator {
+	r := &Replicator{store: store, applier: applier, cfg: cfg}
+	r.worker = durable.NewWorker(cfg.PollInterval, func(ctx context.Context) {
+		// Applying a mutation may make the next one of its product due.
+		if r.Poll(ctx) > 0 {
+			r.Wake()
+		}
+	})
+	return r
+}
+
+// Enqueue durably queues a mutation for the tenant in ctx and wakes the
+// workers. name is the product's name before the mutation, which finds the
+// secondary's copy; product is copied and is nil for deletes.
+func (r *Replicator) Enqueue(ctx context.Context, op, productID, name string, product *domain.Product) error {
+	m := &Mutation{ProductID: productID, Op: op, Name: name, Status: StatusPending}
+	m.TenantID, _ = tenant.FromContext(ctx)
+	if product != nil {
+		p := *product
+		p.ID, p.TenantID = productID, m.TenantID
+		m.Product = &p
+	}
+	m.NextAttemptAt = durable.Now()
+	m.CreatedAt, m.UpdatedAt = m.NextAttemptAt, m.NextAttemptAt
+	if err := r.store.Enqueue(ctx, m); err != nil {
+		return domain.NewError(domain.ErrUnavailable, "Replication queue is unavailable", err)
+	}
+	r.Wake()
+	return nil
+}
+
+// Wake asks the workers to poll now instead of at the next interval.
+func (r *Replicator) Wake() {
+	r.worker.Wake()
+}
+
+// Start runs the workers until Stop is called.
+func (r *Replicator) Start() {
+	r.worker.Start()
+}
+
+// Stop stops polling and waits for mutations being applied, or for ctx.
+// Unfinished mutations are retried after their lease by the next process.
+func (r *Replicator) Stop(ctx context.Context) error {
+	return r.worker.Stop(ctx)
+}
+
+// Poll applies every due mutation once and returns how many were applied.
+func (r *Replicator) Poll(ctx context.Context) int {
+	ctx = tenant.WithAllTenants(ctx)
+	due, err := r.store.Due(ctx, durable.Now(), batchSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load due replication mutations", "error", err)
+		return 0
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		applied int
+	)
+	sem := make(chan struct{}, max(r.cfg.Workers, 1))
+	for i := range due {
+		m := &due[i]
+		claimed, err := r.store.Claim(ctx, m, durable.LeaseUntil(r.cfg.ApplyTimeout))
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to claim replication mutation", "mutation_id", m.ID, "error", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			if r.attempt(ctx, m) {
+				mu.Lock()
+				applied++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return applied
+}
+
+// attempt applies a mutation once, records the outcome and reports whether
+// it was applied.
+func (r *Replicator) attempt(ctx context.Context, m *Mutation) bool {
+	m.Attempts++
+	err := r.apply(ctx, m)
+	if err == nil {
+		if err := r.store.Complete(ctx, m); err != nil {
+			slog.ErrorContext(ctx, "Failed to complete replication mutation", "mutation_id", m.ID, "error", err)
+			return false
+		}
+		return true
+	}
+
+	m.LastError = err.Error()
+	if m.Attempts >= r.cfg.MaxAttempts {
+		m.Status = StatusDead
+		slog.ErrorContext(ctx, "Replication mutation moved to dead letters", "mutation_id", m.ID, "tenant_id", m.TenantID,
+			"product_id", m.ProductID, "op", m.Op, "attempts", m.Attempts, "error", err)
+	} else {
+		m.NextAttemptAt = durable.Now().Add(durable.Backoff(m.Attempts, r.cfg.BackoffBase, r.cfg.BackoffMax))
+		slog.WarnContext(ctx, "Replication mutation failed", "mutation_id", m.ID, "product_id", m.ProductID,
+			"op", m.Op, "attempts", m.Attempts, "error", err)
+	}
+	if err := r.store.RecordFailure(ctx, m); err != nil {
+		slog.ErrorContext(ctx, "Failed to record replication attempt", "mutation_id", m.ID, "error", err)
+	}
+	return false
+}
+
+// apply replays a mutation on the secondary. Updates and deletes fail while
+// the secondary has no copy of the product, so they are retried and end up
+// in the dead letters rather than being dropped.
+func (r *Replicator) apply(ctx context.Context, m *Mutation) error {
+	ctx, cancel := context.WithTimeout(tenant.WithTenant(ctx, m.TenantID), r.cfg.ApplyTimeout)
+	defer cancel()
+
+	return r.applier.ApplyToSecondary(ctx, m.Op, m.ProductID, m.Name, m.Product)
+}
@@ -0,0 +1,215 @@
+// internal/replication/sql_store.go
+package replication
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"product-management/internal/domain"
+	"product-management/internal/durable"
+)
+
+var schema = durable.Schema{
+	MySQL: []string{`
+CREATE TABLE IF NOT EXISTS replication_queue (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	tenant_id VARCHAR(64) NOT NULL,
+	product_id VARCHAR(64) NOT NULL,
+	op VARCHAR(16) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	product MEDIUMTEXT NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	attempts INT NOT NULL,
+	last_error VARCHAR(1024) NOT NULL,
+	next_attempt_at DATETIME(6) NOT NULL,
+	created_at DATETIME(6) NOT NULL,
+	updated_at DATETIME(6) NOT NULL,
+	KEY idx_replication_queue_due (status, next_attempt_at),
+	KEY idx_replication_queue_product (tenant_id, product_id, id)
+)`},
+	SQLite: []string{`
+CREATE TABLE IF NOT EXISTS replication_queue (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	tenant_id TEXT NOT NULL,
+	product_id TEXT NOT NULL,
+	op TEXT NOT NULL,
+	name TEXT NOT NULL,
+	product TEXT NOT NULL,
+	status TEXT NOT NULL,
+	attempts INTEGER NOT NULL,
+	last_error TEXT NOT NULL,
+	next_attempt_at DATETIME NOT NULL,
+	created_at DATETIME NOT NULL,
+	updated_at DATETIME NOT NULL
+)`,
+		"CREATE INDEX IF NOT EXISTS idx_replication_queue_due ON replication_queue (status, next_attempt_at)",
+		"CREATE INDEX IF NOT EXISTS idx_replication_queue_product ON replication_queue (tenant_id, product_id, id)",
+	},
+}
+
+// SQLStore keeps the replication queue in MySQL or, in the single-file
+// embedded mode, SQLite, so queued mutations survive restarts.
+type SQLStore struct {
+	db durable.DB
+}
+
+// NewMySQLStore creates a store backed by a MySQL db.
+func NewMySQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: durable.MySQL(db, schema)}
+}
+
+// NewSQLiteStore creates a store backed by a SQLite db.
+func NewSQLiteStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: durable.SQLite(db, schema)}
+}
+
+// EnsureSchema creates the queue table if it does not exist.
+func (s *SQLStore) EnsureSchema(ctx context.Context) error {
+	return s.db.EnsureSchema(ctx)
+}
+
+// Enqueue stores m as pending and fills in its ID.
+func (s *SQLStore) Enqueue(ctx context.Context, m *Mutation) error {
+	product, err := json.Marshal(m.Product)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO replication_queue (tenant_id, product_id, op, name, product, status, attempts, last_error,
+			next_attempt_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		m.TenantID, m.ProductID, m.Op, m.Name, string(product), m.Status, m.Attempts, m.LastError,
+		m.NextAttemptAt.UTC(), m.CreatedAt.UTC(), m.UpdatedAt.UTC())
+	if err != nil {
+		return err
+	}
+	m.ID, err = res.LastInsertId()
+	return err
+}
+
+const mutationColumns = "q.id, q.tenant_id, q.product_id, q.op, q.name, q.product, q.status, q.attempts, q.last_error, " +
+	"q.next_attempt_at, q.created_at, q.updated_at"
+
+func scanMutations(rows *sql.Rows) ([]Mutation, error) {
+	defer rows.Close()
+	mutations := []Mutation{}
+	for rows.Next() {
+		var m Mutation
+		var product string
+		if err := rows.Scan(&m.ID, &m.TenantID, &m.ProductID, &m.Op, &m.Name, &product, &m.Status, &m.Attempts, &m.LastError,
+			&m.NextAttemptAt, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, err
+		}
+		var p *domain.Product
+		if err := json.Unmarshal([]byte(product), &p); err != nil {
+			return nil, err
+		}
+		m.Product = p
+		mutations = append(mutations, m)
+	}
+	return mutations, rows.Err()
+}
+
+// ListDeadLetters returns the oldest dead letters of the tenant in ctx.
+func (s *SQLStore) ListDeadLetters(ctx context.Context, limit int) ([]Mutation, error) {
+	id, err := durable.SingleTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+mutationColumns+" FROM replication_queue q WHERE q.tenant_id = ? AND q.status = ? ORDER BY q.id LIMIT ?",
+		id, StatusDead, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanMutations(rows)
+}
+
+// Retry implements Store for dead letters of the tenant in ctx.
+func (s *SQLStore) Retry(ctx context.Context, mutationID int64, at time.Time) error {
+	id, err := durable.SingleTenant(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE replication_queue SET status = ?, attempts = 0, next_attempt_at = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ? AND status = ?`,
+		StatusPending, at.UTC(), durable.Now(), mutationID, id, StatusDead)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Discard implements Store for dead letters of the tenant in ctx.
+func (s *SQLStore) Discard(ctx context.Context, mutationID int64) error {
+	id, err := durable.SingleTenant(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, "DELETE FROM replication_queue WHERE id = ? AND tenant_id = ? AND status = ?",
+		mutationID, id, StatusDead)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Due implements Store. A product whose oldest mutation is dead gets
+// nothing applied until the dead letter is retried or discarded.
+func (s *SQLStore) Due(ctx context.Context, at time.Time, limit int) ([]Mutation, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+mutationColumns+`
+		FROM replication_queue q
+		WHERE q.status = ? AND q.next_attempt_at <= ? AND NOT EXISTS (
+			SELECT 1 FROM replication_queue e
+			WHERE e.tenant_id = q.tenant_id AND e.product_id = q.product_id AND e.id < q.id)
+		ORDER BY q.id LIMIT ?`,
+		StatusPending, at.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanMutations(rows)
+}
+
+// Claim implements Store with a compare-and-set on next_attempt_at.
+func (s *SQLStore) Claim(ctx context.Context, m *Mutation, leaseUntil time.Time) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE replication_queue SET next_attempt_at = ?
+		WHERE id = ? AND status = ? AND next_attempt_at = ?`,
+		leaseUntil.UTC(), m.ID, StatusPending, m.NextAttemptAt.UTC())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 {
+		return false, err
+	}
+	m.NextAttemptAt = leaseUntil
+	return true, nil
+}
+
+// Complete implements Store.
+func (s *SQLStore) Complete(ctx context.Context, m *Mutation) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM replication_queue WHERE id = ?", m.ID)
+	return err
+}
+
+// RecordFailure implements Store.
+func (s *SQLStore) RecordFailure(ctx context.Context, m *Mutation) error {
+	m.LastError = durable.TruncateError(m.LastError)
+	m.UpdatedAt = durable.Now()
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE replication_queue SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ?, updated_at = ?
+		WHERE id = ?`,
+		m.Status, m.Attempts, m.LastError, m.NextAttemptAt.UTC(), m.UpdatedAt, m.ID)
+	return err
+}
@@ -0,0 +1,290 @@
+// internal/repository/memory/memory_product_repository.go
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"product-management/internal/domain"
+	"product-management/internal/tenant"
+	"strconv"
+	"sync"
+)
+
+// ErrNotFound is returned for IDs that are not stored in the tenant. It is
+// of kind domain.ErrNotFound.
+var ErrNotFound = domain.ProductNotFound(nil)
+
+// ErrDuplicateName mirrors the unique (tenant_id, name) index of the
+// database backends. It is of kind domain.ErrConflict.
+var ErrDuplicateName error = domain.NewError(domain.ErrConflict, "A product with this name already exists", nil)
+
+// MemoryProductRepository keeps products in process memory. It is safe for
+// concurrent use and hands out copies, so callers cannot change stored
+// products except through the repository. IDs are sequential decimals like
+// the SQL backends. Everything is lost when the process exits.
+type MemoryProductRepository struct {
+	mu       sync.RWMutex
+	nextID   int64
+	products map[string]domain.Product
+	// order holds IDs in creation order for listing and paging.
+	order []string
+}
+
+func NewMemoryProductRepository() *MemoryProductRepository {
+	return &MemoryProductRepository{products: make(map[string]domain.Product)}
+}
+
+// EnsureSchema has nothing to prepare; it validates defaultTenant for
+// parity with the other repositories.
+func (r *MemoryProductRepository) EnsureSchema(ctx context.Context, defaultTenant string) error {
+	if err := tenant.Validate(defaultTenant); err != nil {
+		return fmt.Errorf("default tenant %q: %w", defaultTenant, err)
+	}
+	return nil
+}
+
+// visible reports whether p belongs to the tenant scope of ctx.
+func visible(p domain.Product, tenantID string, all bool) bool {
+	return all || p.TenantID == tenantID
+}
+
+// nameTaken reports whether another product of tenantID is called name.
+// The caller holds the lock.
+func (r *MemoryProductRepository) nameTaken(tenantID, name, exceptID string) bool {
+	for id, p := range r.products {
+		if id != exceptID && p.TenantID == tenantID && p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Create method. The generated ID is written back to product.ID.
+func (r *MemoryProductRepository) Create(ctx context.Context, product *domain.Product) error {
+	id, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return err
+	}
+	if !all {
+		product.TenantID = id
+	} else if product.TenantID == "" {
+		return errors.New("product has no tenant")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.nameTaken(product.TenantID, product.Name, "") {
+		return ErrDuplicateName
+	}
+	r.nextID++
+	product.ID = strconv.FormatInt(r.nextID, 10)
+	r.products[product.ID] = *product
+	r.order = append(r.order, product.ID)
+	return nil
+}
+
+// UpsertProducts creates products or updates the tenant's product of the
+// same name, under one lock.
+func (r *MemoryProductRepository) UpsertProducts(ctx context.Context, products []domain.Product) error {
+	id, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		if !all {
+			products[i].TenantID = id
+		} else if products[i].TenantID == "" {
+			return errors.New("product has no tenant")
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range products {
+		existing, ok := r.byName(p.TenantID, p.Name)
+		if !ok {
+			r.nextID++
+			created := domain.Product{
+				ID:       strconv.FormatInt(r.nextID, 10),
+				TenantID: p.TenantID, Name: p.Name, Description: p.Description, Price: p.Price, Stock: p.Stock,
+			}
+			r.products[created.ID] = created
+			r.order = append(r.order, created.ID)
+			continue
+		}
+		existing.Description, existing.Price, existing.Stock = p.Description, p.Price, p.Stock
+		r.products[existing.ID] = existing
+	}
+	return nil
+}
+
+// byName returns the product of tenantID called name. The caller holds the
+// lock.
+func (r *MemoryProductRepository) byName(tenantID, name string) (domain.Product, bool) {
+	for _, p := range r.products {
+		if p.TenantID == tenantID && p.Name == name {
+			return p, true
+		}
+	}
+	return domain.Product{}, false
+}
+
+// GetAllProducts method
+func (r *MemoryProductRepository) GetAllProducts(ctx context.Context) ([]domain.Product, error) {
+	return r.SearchProducts(ctx, domain.ProductFilter{}, domain.Page{})
+}
+
+// CountProducts implements domain.ProductCounter.
+func (r *MemoryProductRepository) CountProducts(ctx context.Context) (domain.ProductStats, error) {
+	tenantID, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return domain.ProductStats{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var stats domain.ProductStats
+	for _, p := range r.products {
+		if visible(p, tenantID, all) {
+			stats.Count++
+			stats.Stock += p.Stock
+		}
+	}
+	return stats, nil
+}
+
+// SearchProducts returns the products matching filter in creation order,
+// which is also ID order.
+func (r *MemoryProductRepository) SearchProducts(ctx context.Context, filter domain.ProductFilter, page domain.Page) ([]domain.Product, error) {
+	tenantID, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var after int64
+	if page.After != "" {
+		if after, err = strconv.ParseInt(page.After, 10, 64); err != nil {
+			return nil, domain.InvalidCursor(err)
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var products []domain.Product
+	skipped := 0
+	for _, id := range r.order {
+		p := r.products[id]
+		if !visible(p, tenantID, all) || !filter.Matches(p) {
+			continue
+		}
+		if n, _ := strconv.ParseInt(id, 10, 64); n <= after {
+			continue
+		}
+		if skipped < page.Offset {
+			skipped++
+			continue
+		}
+		if page.Limit > 0 && len(products) == page.Limit {
+			break
+		}
+		products = append(products, p)
+	}
+	return products, nil
+}
+
+// GetProductById method
+func (r *MemoryProductRepository) GetProductById(ctx context.Context, id string) (*domain.Product, error) {
+	tenantID, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.products[id]
+	if !ok || !visible(p, tenantID, all) {
+		return nil, ErrNotFound
+	}
+	return &p, nil
+}
+
+// GetProductsByIds method
+func (r *MemoryProductRepository) GetProductsByIds(ctx context.Context, ids []string) ([]domain.Product, error) {
+	tenantID, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var products []domain.Product
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		p, ok := r.products[id]
+		if ok && !seen[id] && visible(p, tenantID, all) {
+			seen[id] = true
+			products = append(products, p)
+		}
+	}
+	return products, nil
+}
+
+// UpdateProduct method. Like the database backends, IDs it could not have
+// generated report not found and updating an ID that is not stored is not
+// an error.
+func (r *MemoryProductRepository) UpdateProduct(ctx context.Context, id string, product *domain.Product) error {
+	tenantID, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return err
+	}
+	if !wellFormed(id) {
+		return ErrNotFound
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[id]
+	if !ok || !visible(p, tenantID, all) {
+		return nil
+	}
+	if r.nameTaken(p.TenantID, product.Name, id) {
+		return ErrDuplicateName
+	}
+	p.Name, p.Description, p.Price, p.Stock = product.Name, product.Description, product.Price, product.Stock
+	r.products[id] = p
+	return nil
+}
+
+// DeleteProduct method. IDs it could not have generated report not found;
+// deleting an ID that is not stored is not an error.
+func (r *MemoryProductRepository) DeleteProduct(ctx context.Context, id string) error {
+	tenantID, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return err
+	}
+	if !wellFormed(id) {
+		return ErrNotFound
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[id]
+	if !ok || !visible(p, tenantID, all) {
+		return nil
+	}
+	delete(r.products, id)
+	for i, ordered := range r.order {
+		if ordered == id {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// wellFormed reports whether id could be one of the sequential IDs the
+// repository generates.
+func wellFormed(id string) bool {
+	_, err := strconv.ParseUint(id, 10, 64)
+	return err == nil
+}
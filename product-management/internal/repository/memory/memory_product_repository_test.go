@@ -0,0 +1,140 @@
+package memory_test
+
+import (
+	"context"
+	"fmt"
+	"product-management/internal/domain"
+	"product-management/internal/repository/memory"
+	"product-management/internal/repository/repositorytest"
+	"product-management/internal/tenant"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCRUD(t *testing.T) {
+	repo := memory.NewMemoryProductRepository()
+	ctx := tenant.WithTenant(context.Background(), "shop-a")
+
+	product := &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1}
+	require.NoError(t, repo.Create(ctx, product))
+	assert.Equal(t, "1", product.ID)
+	assert.Equal(t, "shop-a", product.TenantID)
+
+	require.NoError(t, repo.UpdateProduct(ctx, product.ID, &domain.Product{Name: "kecap manis", Description: "d2", Price: 2, Stock: 3}))
+	found, err := repo.GetProductById(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.Product{ID: "1", TenantID: "shop-a", Name: "kecap manis", Description: "d2", Price: 2, Stock: 3}, *found)
+
+	require.NoError(t, repo.DeleteProduct(ctx, product.ID))
+	_, err = repo.GetProductById(ctx, product.ID)
+	assert.ErrorIs(t, err, memory.ErrNotFound)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.NoError(t, repo.DeleteProduct(ctx, product.ID))
+}
+
+func TestReadsReturnCopies(t *testing.T) {
+	repo := memory.NewMemoryProductRepository()
+	ctx := tenant.WithTenant(context.Background(), "shop-a")
+	product := &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1}
+	require.NoError(t, repo.Create(ctx, product))
+
+	product.Stock = 99
+	found, err := repo.GetProductById(ctx, product.ID)
+	require.NoError(t, err)
+	found.Name = "changed"
+	all, err := repo.GetAllProducts(ctx)
+	require.NoError(t, err)
+	all[0].Price = 99
+
+	found, err = repo.GetProductById(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "kecap", found.Name)
+	assert.Equal(t, 1, found.Stock)
+	assert.Equal(t, 1.0, found.Price)
+}
+
+func TestTenantIsolation(t *testing.T) {
+	repo := memory.NewMemoryProductRepository()
+	shopA := tenant.WithTenant(context.Background(), "shop-a")
+	shopB := tenant.WithTenant(context.Background(), "shop-b")
+
+	kecap := &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1}
+	require.NoError(t, repo.Create(shopA, kecap))
+	require.NoError(t, repo.Create(shopB, &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1}))
+	assert.ErrorIs(t, repo.Create(shopA, &domain.Product{Name: "kecap"}), memory.ErrDuplicateName)
+
+	_, err := repo.GetProductById(shopB, kecap.ID)
+	assert.ErrorIs(t, err, memory.ErrNotFound)
+	batch, err := repo.GetProductsByIds(shopB, []string{kecap.ID})
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+
+	require.NoError(t, repo.UpdateProduct(shopB, kecap.ID, &domain.Product{Name: "hijacked"}))
+	require.NoError(t, repo.DeleteProduct(shopB, kecap.ID))
+	found, err := repo.GetProductById(shopA, kecap.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "kecap", found.Name)
+
+	all, err := repo.GetAllProducts(tenant.WithAllTenants(context.Background()))
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+	_, err = repo.GetAllProducts(context.Background())
+	assert.ErrorIs(t, err, tenant.ErrMissingTenant)
+}
+
+func TestSearchFiltersAndPages(t *testing.T) {
+	repo := memory.NewMemoryProductRepository()
+	ctx := tenant.WithTenant(context.Background(), "shop-a")
+	for i := 1; i <= 10; i++ {
+		require.NoError(t, repo.Create(ctx, &domain.Product{Name: fmt.Sprintf("Kecap %d", i), Description: "d", Price: float64(i), Stock: i % 2}))
+	}
+	require.NoError(t, repo.Create(ctx, &domain.Product{Name: "sambal", Description: "d", Price: 5, Stock: 1}))
+
+	products, err := repo.SearchProducts(ctx, domain.ProductFilter{NameContains: "kecap", MinPrice: 3, InStock: true}, domain.Page{Offset: 1, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, products, 2)
+	assert.Equal(t, "Kecap 5", products[0].Name)
+	assert.Equal(t, "Kecap 7", products[1].Name)
+
+	products, err = repo.SearchProducts(ctx, domain.ProductFilter{MaxPrice: 2}, domain.Page{})
+	require.NoError(t, err)
+	assert.Len(t, products, 2)
+}
+
+func TestConcurrentWriters(t *testing.T) {
+	repo := memory.NewMemoryProductRepository()
+	ctx := tenant.WithTenant(context.Background(), "shop-a")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			product := &domain.Product{Name: fmt.Sprintf("product-%d", i), Description: "d", Price: 1, Stock: 1}
+			assert.NoError(t, repo.Create(ctx, product))
+			assert.NoError(t, repo.UpdateProduct(ctx, product.ID, &domain.Product{Name: product.Name, Stock: 2}))
+			_, err := repo.GetAllProducts(ctx)
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	products, err := repo.GetAllProducts(ctx)
+	require.NoError(t, err)
+	assert.Len(t, products, 50)
+	ids := make(map[string]bool)
+	for _, p := range products {
+		ids[p.ID] = true
+		assert.Equal(t, 2, p.Stock)
+	}
+	assert.Len(t, ids, 50)
+}
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repository {
+		return memory.NewMemoryProductRepository()
+	})
+}
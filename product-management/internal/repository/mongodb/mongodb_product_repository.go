@@ -3,13 +3,26 @@ package mongodb
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"product-management/internal/domain"
+	"product-management/internal/tenant"
+	"product-management/internal/tracing"
+	"regexp"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("product-management/internal/repository/mongodb")
+
 // MongoDBProductRepository struct
 type MongoDBProductRepository struct {
 	db *mongo.Collection // Menggunakan field db
@@ -20,43 +33,283 @@ func NewMongoDBProductRepository(db *mongo.Collection) *MongoDBProductRepository
 	return &MongoDBProductRepository{db: db}
 }
 
-// Create method
-func (r *MongoDBProductRepository) Create(product *domain.Product) error {
-	_, err := r.db.InsertOne(context.TODO(), product)
+// EnsureSchema assigns documents written before tenants existed to
+// defaultTenant and creates the tenant-scoped indexes.
+func (r *MongoDBProductRepository) EnsureSchema(ctx context.Context, defaultTenant string) error {
+	if err := tenant.Validate(defaultTenant); err != nil {
+		return fmt.Errorf("default tenant %q: %w", defaultTenant, err)
+	}
+	res, err := r.db.UpdateMany(ctx,
+		bson.M{"tenant_id": bson.M{"$in": bson.A{nil, ""}}},
+		bson.M{"$set": bson.M{"tenant_id": defaultTenant}})
+	if err != nil {
+		return err
+	}
+	if res.ModifiedCount > 0 {
+		slog.InfoContext(ctx, "Assigned MongoDB products to default tenant", "count", res.ModifiedCount, "default_tenant", defaultTenant)
+	}
+
+	_, err = r.db.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tenant_id", Value: 1}, {Key: "_id", Value: 1}},
+			Options: options.Index().SetName("idx_products_tenant_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "tenant_id", Value: 1}, {Key: "name", Value: 1}},
+			Options: options.Index().SetName("uq_products_tenant_name").SetUnique(true),
+		},
+	})
+	return err
+}
+
+// scoped adds the tenant condition to a filter. Contexts spanning all
+// tenants (background jobs) are not filtered.
+func scoped(ctx context.Context, filter bson.M) (bson.M, error) {
+	id, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !all {
+		filter["tenant_id"] = id
+	}
+	return filter, nil
+}
+
+// translate turns driver errors into domain errors. Others are returned
+// unchanged.
+func translate(err error) error {
+	var selectionErr topology.ServerSelectionError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return domain.ProductNotFound(err)
+	case mongo.IsDuplicateKeyError(err):
+		return domain.NewError(domain.ErrConflict, "A product with this name already exists", err)
+	case mongo.IsNetworkError(err), errors.As(err, &selectionErr), errors.Is(err, mongo.ErrClientDisconnected):
+		return domain.NewError(domain.ErrUnavailable, "MongoDB is unavailable", err)
+	}
+	return err
+}
+
+// startSpan starts a client span describing a single collection command.
+// The statement is a shell-style rendering of the command without values.
+func (r *MongoDBProductRepository) startSpan(ctx context.Context, operation, statement string) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(ctx, "mongodb."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "mongodb"),
+			attribute.String("db.operation", operation),
+			attribute.String("db.mongodb.collection", r.db.Name()),
+			attribute.String("db.statement", r.db.Name()+"."+statement),
+		),
+	)
+	slog.DebugContext(ctx, "Executing MongoDB command", "operation", operation, "statement", r.db.Name()+"."+statement)
+	return ctx, span
+}
+
+// Create method. The generated ObjectID is written back to product.MongoID.
+func (r *MongoDBProductRepository) Create(ctx context.Context, product *domain.Product) (err error) {
+	id, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return err
+	}
+	if !all {
+		product.TenantID = id
+	} else if product.TenantID == "" {
+		return errors.New("product has no tenant")
+	}
+
+	ctx, span := r.startSpan(ctx, "Create", "insertOne(?)")
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	res, err := r.db.InsertOne(ctx, product)
+	if err != nil {
+		return err
+	}
+	if objID, ok := res.InsertedID.(primitive.ObjectID); ok {
+		product.MongoID = objID
+	}
+	return nil
+}
+
+// UpsertProducts writes products with one ordered bulk write of upserts
+// matched on tenant_id and name.
+func (r *MongoDBProductRepository) UpsertProducts(ctx context.Context, products []domain.Product) (err error) {
+	id, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		if !all {
+			products[i].TenantID = id
+		} else if products[i].TenantID == "" {
+			return errors.New("product has no tenant")
+		}
+	}
+	if len(products) == 0 {
+		return nil
+	}
+
+	ctx, span := r.startSpan(ctx, "UpsertProducts", `bulkWrite([{updateOne: {filter: {"tenant_id": ?, "name": ?}, upsert: true}}, ...])`)
+	span.SetAttributes(attribute.Int("db.rows", len(products)))
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	models := make([]mongo.WriteModel, len(products))
+	for i, p := range products {
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"tenant_id": p.TenantID, "name": p.Name}).
+			SetUpdate(bson.M{
+				"$set":         bson.M{"description": p.Description, "price": p.Price, "stock": p.Stock},
+				"$setOnInsert": bson.M{"id": p.ID},
+			}).
+			SetUpsert(true)
+	}
+	_, err = r.db.BulkWrite(ctx, models)
 	return err
 }
 
 // GetAllProducts method
-func (r *MongoDBProductRepository) GetAllProducts() ([]domain.Product, error) {
-	var products []domain.Product
-	cursor, err := r.db.Find(context.TODO(), bson.M{})
+func (r *MongoDBProductRepository) GetAllProducts(ctx context.Context) (_ []domain.Product, err error) {
+	filter, err := scoped(ctx, bson.M{})
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.TODO())
+	ctx, span := r.startSpan(ctx, "GetAllProducts", `find({"tenant_id": ?}).sort({"_id": 1})`)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	return r.find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+}
+
+// CountProducts implements domain.ProductCounter.
+func (r *MongoDBProductRepository) CountProducts(ctx context.Context) (_ domain.ProductStats, err error) {
+	filter, err := scoped(ctx, bson.M{})
+	if err != nil {
+		return domain.ProductStats{}, err
+	}
+	ctx, span := r.startSpan(ctx, "CountProducts", `aggregate([{"$match": {"tenant_id": ?}}, {"$group": ...}])`)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	cursor, err := r.db.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "count": bson.M{"$sum": 1}, "stock": bson.M{"$sum": "$stock"}}}},
+	})
+	if err != nil {
+		return domain.ProductStats{}, err
+	}
+	defer cursor.Close(ctx)
+	var totals []struct {
+		Count int `bson:"count"`
+		Stock int `bson:"stock"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil {
+		return domain.ProductStats{}, err
+	}
+	if len(totals) == 0 {
+		return domain.ProductStats{}, nil
+	}
+	return domain.ProductStats{Count: totals[0].Count, Stock: totals[0].Stock}, nil
+}
 
-	for cursor.Next(context.TODO()) {
-		var product domain.Product
-		if err := cursor.Decode(&product); err != nil {
-			return nil, err
+// SearchProducts returns the products matching filter in ObjectID order,
+// which is creation order for IDs generated by one client. Page.After is an
+// ObjectID in hex.
+func (r *MongoDBProductRepository) SearchProducts(ctx context.Context, filter domain.ProductFilter, page domain.Page) (_ []domain.Product, err error) {
+	query := bson.M{}
+	if filter.NameContains != "" {
+		query["name"] = primitive.Regex{Pattern: regexp.QuoteMeta(filter.NameContains), Options: "i"}
+	}
+	if len(filter.Names) > 0 {
+		// Under $and, so it does not replace a NameContains regex.
+		query["$and"] = bson.A{bson.M{"name": bson.M{"$in": filter.Names}}}
+	}
+	price := bson.M{}
+	if filter.MinPrice > 0 {
+		price["$gte"] = filter.MinPrice
+	}
+	if filter.MaxPrice > 0 {
+		price["$lte"] = filter.MaxPrice
+	}
+	if len(price) > 0 {
+		query["price"] = price
+	}
+	if filter.InStock {
+		query["stock"] = bson.M{"$gt": 0}
+	}
+	if page.After != "" {
+		after, err := primitive.ObjectIDFromHex(page.After)
+		if err != nil {
+			return nil, domain.InvalidCursor(err)
 		}
-		products = append(products, product)
+		query["_id"] = bson.M{"$gt": after}
+	}
+	query, err = scoped(ctx, query)
+	if err != nil {
+		return nil, err
 	}
+	ctx, span := r.startSpan(ctx, "SearchProducts", `find({"tenant_id": ?, ...}).sort({"_id": 1}).skip(?).limit(?)`)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
 
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	if page.Offset > 0 {
+		opts.SetSkip(int64(page.Offset))
+	}
+	if page.Limit > 0 {
+		opts.SetLimit(int64(page.Limit))
+	}
+	return r.find(ctx, query, opts)
+}
+
+// find decodes every document matching filter.
+func (r *MongoDBProductRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]domain.Product, error) {
+	cursor, err := r.db.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var products []domain.Product
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
 	return products, nil
 }
 
-// GetProductById method
-// Mengubah penggunaan r.collection menjadi r.db
-func (r *MongoDBProductRepository) GetProductById(id string) (*domain.Product, error) {
-	objID, err := primitive.ObjectIDFromHex(id)
+// GetProductById method. IDs that are not ObjectIDs cannot exist, so they
+// report not found.
+func (r *MongoDBProductRepository) GetProductById(ctx context.Context, id string) (_ *domain.Product, err error) {
+	objID, parseErr := primitive.ObjectIDFromHex(id)
+	filter, err := scoped(ctx, bson.M{"_id": objID})
 	if err != nil {
 		return nil, err
 	}
+	if parseErr != nil {
+		return nil, domain.ProductNotFound(mongo.ErrNoDocuments)
+	}
+	ctx, span := r.startSpan(ctx, "GetProductById", `findOne({"_id": ?, "tenant_id": ?})`)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
 
 	var product domain.Product
 	// Mengganti r.collection dengan r.db
-	err = r.db.FindOne(context.TODO(), bson.M{"_id": objID}).Decode(&product)
+	err = r.db.FindOne(ctx, filter).Decode(&product)
 	if err != nil {
 		return nil, err
 	}
@@ -64,9 +317,50 @@ func (r *MongoDBProductRepository) GetProductById(id string) (*domain.Product, e
 	return &product, nil
 }
 
-// UpdateProduct method
-func (r *MongoDBProductRepository) UpdateProduct(id string, product *domain.Product) error {
-	_, err := r.db.UpdateOne(context.TODO(), bson.M{"id": product.ID}, bson.M{
+// GetProductsByIds method. IDs that are not ObjectIDs cannot match and are
+// skipped.
+func (r *MongoDBProductRepository) GetProductsByIds(ctx context.Context, ids []string) (_ []domain.Product, err error) {
+	objIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if objID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objIDs = append(objIDs, objID)
+		}
+	}
+	if len(objIDs) == 0 {
+		return nil, nil
+	}
+	filter, err := scoped(ctx, bson.M{"_id": bson.M{"$in": objIDs}})
+	if err != nil {
+		return nil, err
+	}
+	ctx, span := r.startSpan(ctx, "GetProductsByIds", `find({"_id": {"$in": ?}, "tenant_id": ?}).sort({"_id": 1})`)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	return r.find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+}
+
+// UpdateProduct method. IDs that are not ObjectIDs cannot exist, so they
+// report not found; updating an ObjectID that does not exist is not an
+// error.
+func (r *MongoDBProductRepository) UpdateProduct(ctx context.Context, id string, product *domain.Product) (err error) {
+	objID, parseErr := primitive.ObjectIDFromHex(id)
+	filter, err := scoped(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return err
+	}
+	if parseErr != nil {
+		return domain.ProductNotFound(mongo.ErrNoDocuments)
+	}
+	ctx, span := r.startSpan(ctx, "UpdateProduct", `updateOne({"_id": ?, "tenant_id": ?}, {"$set": ?})`)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	_, err = r.db.UpdateOne(ctx, filter, bson.M{
 		"$set": bson.M{
 			"name":        product.Name,
 			"description": product.Description,
@@ -77,8 +371,23 @@ func (r *MongoDBProductRepository) UpdateProduct(id string, product *domain.Prod
 	return err
 }
 
-// DeleteProduct method
-func (r *MongoDBProductRepository) DeleteProduct(id string) error {
-	_, err := r.db.DeleteOne(context.TODO(), bson.M{"id": id})
+// DeleteProduct method. IDs that are not ObjectIDs report not found;
+// deleting an ObjectID that does not exist is not an error.
+func (r *MongoDBProductRepository) DeleteProduct(ctx context.Context, id string) (err error) {
+	objID, parseErr := primitive.ObjectIDFromHex(id)
+	filter, err := scoped(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return err
+	}
+	if parseErr != nil {
+		return domain.ProductNotFound(mongo.ErrNoDocuments)
+	}
+	ctx, span := r.startSpan(ctx, "DeleteProduct", `deleteOne({"_id": ?, "tenant_id": ?})`)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	_, err = r.db.DeleteOne(ctx, filter)
 	return err
 }
@@ -0,0 +1,52 @@
+package mongodb_test
+
+import (
+	"context"
+	"product-management/internal/domain"
+	"product-management/internal/repository/mongodb"
+	"product-management/internal/repository/mongodb/mongotest"
+	"product-management/internal/repository/repositorytest"
+	"product-management/internal/tenant"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestTenantIsolation(t *testing.T) {
+	repo := mongodb.NewMongoDBProductRepository(mongotest.New(t))
+	require.NoError(t, repo.EnsureSchema(context.Background(), "default"))
+	shopA := tenant.WithTenant(context.Background(), "shop-a")
+	shopB := tenant.WithTenant(context.Background(), "shop-b")
+
+	require.NoError(t, repo.Create(shopA, &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1}))
+	require.NoError(t, repo.Create(shopB, &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1}))
+	assert.Error(t, repo.Create(shopA, &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1}))
+
+	productsA, err := repo.GetAllProducts(shopA)
+	require.NoError(t, err)
+	require.Len(t, productsA, 1)
+	idA := productsA[0].MongoID.Hex()
+
+	_, err = repo.GetProductById(shopB, idA)
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+
+	batch, err := repo.GetProductsByIds(shopB, []string{idA, "not-an-object-id"})
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+	batch, err = repo.GetProductsByIds(shopA, []string{idA})
+	require.NoError(t, err)
+	assert.Len(t, batch, 1)
+
+	_, err = repo.GetAllProducts(context.Background())
+	assert.ErrorIs(t, err, tenant.ErrMissingTenant)
+}
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repository {
+		repo := mongodb.NewMongoDBProductRepository(mongotest.New(t))
+		require.NoError(t, repo.EnsureSchema(context.Background(), "default"))
+		return repo
+	})
+}
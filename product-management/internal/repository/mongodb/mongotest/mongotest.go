@@ -0,0 +1,46 @@
+// internal/repository/mongodb/mongotest/mongotest.go
+package mongotest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// URIEnv names the MongoDB server used by tests. There is no in-process
+// MongoDB, so tests that need one are skipped when it is unset.
+const URIEnv = "MONGODB_TEST_URI"
+
+// New returns a fresh collection in a throwaway database on the server
+// named by MONGODB_TEST_URI. The database is dropped when the test ends.
+func New(t testing.TB) *mongo.Collection {
+	t.Helper()
+	uri := os.Getenv(URIEnv)
+	if uri == "" {
+		t.Skipf("%s is not set; skipping MongoDB test", URIEnv)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect to MongoDB: %v", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatalf("ping MongoDB: %v", err)
+	}
+
+	db := client.Database(fmt.Sprintf("producttest_%d", time.Now().UnixNano()))
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+	return db.Collection("products")
+}
@@ -2,10 +2,28 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
 	"product-management/internal/domain"
+	"product-management/internal/tenant"
+	"product-management/internal/tracing"
+	"strconv"
+	"strings"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("product-management/internal/repository/mysql")
+
 type MySQLProductRepository struct {
 	db *sql.DB
 }
@@ -14,56 +32,427 @@ func NewMySQLProductRepository(db *sql.DB) *MySQLProductRepository {
 	return &MySQLProductRepository{db: db}
 }
 
-// Create method
-func (r *MySQLProductRepository) Create(product *domain.Product) error {
-	query := "INSERT INTO products (name, description, price, stock) VALUES (?, ?, ?, ?)"
-	_, err := r.db.Exec(query, product.Name, product.Description, product.Price, product.Stock)
+const productsTable = `
+CREATE TABLE IF NOT EXISTS products (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	tenant_id VARCHAR(64) NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	description TEXT NOT NULL,
+	price DOUBLE NOT NULL,
+	stock INT NOT NULL,
+	UNIQUE KEY uq_products_tenant_name (tenant_id, name),
+	KEY idx_products_tenant_id (tenant_id, id)
+)`
+
+// EnsureSchema creates the products table if it does not exist. Tables
+// created before tenants existed get a tenant_id column, with existing rows
+// assigned to defaultTenant, and the tenant-scoped indexes. MySQL DDL is
+// not transactional, so every step is checked on its own and a migration
+// that stopped halfway is finished on the next start.
+func (r *MySQLProductRepository) EnsureSchema(ctx context.Context, defaultTenant string) error {
+	if err := tenant.Validate(defaultTenant); err != nil {
+		return fmt.Errorf("default tenant %q: %w", defaultTenant, err)
+	}
+	if _, err := r.db.ExecContext(ctx, productsTable); err != nil {
+		return err
+	}
+
+	var columns int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = 'products' AND column_name = 'tenant_id'`).Scan(&columns)
+	if err != nil {
+		return err
+	}
+	if columns == 0 {
+		// defaultTenant is validated above, so it is safe to inline into DDL.
+		for _, stmt := range []string{
+			"ALTER TABLE products ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT '" + defaultTenant + "'",
+			"ALTER TABLE products ALTER COLUMN tenant_id DROP DEFAULT",
+		} {
+			if _, err := r.db.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("migrate products table: %w", err)
+			}
+		}
+		slog.InfoContext(ctx, "Added tenant_id to products table", "default_tenant", defaultTenant)
+	}
+
+	for _, index := range []struct{ name, stmt string }{
+		{"idx_products_tenant_id", "CREATE INDEX idx_products_tenant_id ON products (tenant_id, id)"},
+		{"uq_products_tenant_name", "CREATE UNIQUE INDEX uq_products_tenant_name ON products (tenant_id, name)"},
+	} {
+		var found int
+		err := r.db.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM information_schema.statistics
+			WHERE table_schema = DATABASE() AND table_name = 'products' AND index_name = ?`, index.name).Scan(&found)
+		if err != nil {
+			return err
+		}
+		if found > 0 {
+			continue
+		}
+		if _, err := r.db.ExecContext(ctx, index.stmt); err != nil {
+			return fmt.Errorf("migrate products table: %w", err)
+		}
+		slog.InfoContext(ctx, "Added index to products table", "index", index.name)
+	}
+	return nil
+}
+
+// scoped appends the tenant condition to a WHERE clause. Contexts spanning
+// all tenants (background jobs) are not filtered.
+func scoped(ctx context.Context, where string, args ...any) (string, []any, error) {
+	id, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	if !all {
+		if where != "" {
+			where += " AND "
+		}
+		where += "tenant_id = ?"
+		args = append(args, id)
+	}
+	if where == "" {
+		return "", args, nil
+	}
+	return " WHERE " + where, args, nil
+}
+
+// parseID converts a product ID to the column type. MySQL would coerce a
+// string like "1abc" to 1, so IDs that are not integers are rejected here
+// and reported as not found.
+func parseID(id string) (int64, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, domain.ProductNotFound(sql.ErrNoRows)
+	}
+	return n, nil
+}
+
+// errDuplicateEntry is the MySQL error number of a unique key violation.
+const errDuplicateEntry = 1062
+
+// translate turns driver errors into domain errors. Others are returned
+// unchanged.
+func translate(err error) error {
+	var mysqlErr *mysqldriver.MySQLError
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return domain.ProductNotFound(err)
+	case errors.As(err, &mysqlErr) && mysqlErr.Number == errDuplicateEntry:
+		return domain.NewError(domain.ErrConflict, "A product with this name already exists", err)
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, mysqldriver.ErrInvalidConn),
+		errors.Is(err, sql.ErrConnDone), errors.As(err, &netErr):
+		return domain.NewError(domain.ErrUnavailable, "MySQL is unavailable", err)
+	}
+	return err
+}
+
+// startSpan starts a client span describing a single MySQL statement.
+func startSpan(ctx context.Context, operation, query string) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(ctx, "mysql."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "mysql"),
+			attribute.String("db.operation", operation),
+			attribute.String("db.statement", query),
+		),
+	)
+	slog.DebugContext(ctx, "Executing MySQL statement", "operation", operation, "statement", query)
+	return ctx, span
+}
+
+// Create method. The generated ID is written back to product.ID.
+func (r *MySQLProductRepository) Create(ctx context.Context, product *domain.Product) (err error) {
+	id, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return err
+	}
+	if !all {
+		product.TenantID = id
+	} else if product.TenantID == "" {
+		return errors.New("product has no tenant")
+	}
+
+	query := "INSERT INTO products (tenant_id, name, description, price, stock) VALUES (?, ?, ?, ?, ?)"
+	ctx, span := startSpan(ctx, "Create", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	res, err := r.db.ExecContext(ctx, query, product.TenantID, product.Name, product.Description, product.Price, product.Stock)
+	if err != nil {
+		return err
+	}
+	newID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	product.ID = strconv.FormatInt(newID, 10)
+	return nil
+}
+
+// UpsertProducts writes products with one INSERT that updates rows clashing
+// on the unique (tenant_id, name) key.
+func (r *MySQLProductRepository) UpsertProducts(ctx context.Context, products []domain.Product) (err error) {
+	id, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		if !all {
+			products[i].TenantID = id
+		} else if products[i].TenantID == "" {
+			return errors.New("product has no tenant")
+		}
+	}
+	if len(products) == 0 {
+		return nil
+	}
+
+	const row = "(?, ?, ?, ?, ?)"
+	const update = " ON DUPLICATE KEY UPDATE description = VALUES(description), price = VALUES(price), stock = VALUES(stock)"
+	const insert = "INSERT INTO products (tenant_id, name, description, price, stock) VALUES "
+	ctx, span := startSpan(ctx, "UpsertProducts", insert+row+", ..."+update)
+	span.SetAttributes(attribute.Int("db.rows", len(products)))
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	args := make([]any, 0, 5*len(products))
+	for _, p := range products {
+		args = append(args, p.TenantID, p.Name, p.Description, p.Price, p.Stock)
+	}
+	query := insert + strings.Repeat(row+", ", len(products)-1) + row + update
+	_, err = r.db.ExecContext(ctx, query, args...)
 	return err
 }
 
 // GetAllProducts method
-// GetAllProducts method
-func (r *MySQLProductRepository) GetAllProducts() ([]domain.Product, error) {
-	rows, err := r.db.Query("SELECT id, name, description, price, stock FROM products")
+func (r *MySQLProductRepository) GetAllProducts(ctx context.Context) (_ []domain.Product, err error) {
+	where, args, err := scoped(ctx, "")
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	query := "SELECT id, tenant_id, name, description, price, stock FROM products" + where + " ORDER BY id"
+	ctx, span := startSpan(ctx, "GetAllProducts", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
 
-	var products []domain.Product
-	for rows.Next() {
-		var product domain.Product
-		if err := rows.Scan(&product.ID, &product.Name, &product.Description, &product.Price, &product.Stock); err != nil {
-			return nil, err
-		}
-		products = append(products, product) // Menggunakan product bukan &product
+	return r.query(ctx, query, args...)
+}
+
+// CountProducts implements domain.ProductCounter.
+func (r *MySQLProductRepository) CountProducts(ctx context.Context) (_ domain.ProductStats, err error) {
+	where, args, err := scoped(ctx, "")
+	if err != nil {
+		return domain.ProductStats{}, err
 	}
+	query := "SELECT COUNT(*), COALESCE(SUM(stock), 0) FROM products" + where
+	ctx, span := startSpan(ctx, "CountProducts", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
 
-	return products, nil
+	var stats domain.ProductStats
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&stats.Count, &stats.Stock)
+	return stats, err
 }
 
 // GetProductById method
-func (r *MySQLProductRepository) GetProductById(id string) (*domain.Product, error) {
+func (r *MySQLProductRepository) GetProductById(ctx context.Context, id string) (_ *domain.Product, err error) {
+	n, parseErr := parseID(id)
+	where, args, err := scoped(ctx, "id = ?", n)
+	if err != nil {
+		return nil, err
+	}
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	query := "SELECT id, tenant_id, name, description, price, stock FROM products" + where
+	ctx, span := startSpan(ctx, "GetProductById", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
 	var product domain.Product
-	err := r.db.QueryRow("SELECT id, name, price FROM products WHERE id = ?", id).Scan(&product.ID, &product.Name, &product.Price)
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&product.ID, &product.TenantID, &product.Name, &product.Description, &product.Price, &product.Stock)
 	if err != nil {
 		return nil, err
 	}
 	return &product, nil
 }
 
+// GetProductsByIds method
+func (r *MySQLProductRepository) GetProductsByIds(ctx context.Context, ids []string) (_ []domain.Product, err error) {
+	args := make([]any, 0, len(ids))
+	for _, id := range ids {
+		if n, err := parseID(id); err == nil {
+			args = append(args, n)
+		}
+	}
+	if len(args) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.Repeat("?, ", len(args)-1) + "?"
+	where, args, err := scoped(ctx, "id IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT id, tenant_id, name, description, price, stock FROM products" + where + " ORDER BY id"
+	ctx, span := startSpan(ctx, "GetProductsByIds", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	return r.query(ctx, query, args...)
+}
+
+// SearchProducts returns the products matching filter in ID order.
+func (r *MySQLProductRepository) SearchProducts(ctx context.Context, filter domain.ProductFilter, page domain.Page) (_ []domain.Product, err error) {
+	conditions, args, err := filtered(filter, page)
+	if err != nil {
+		return nil, err
+	}
+	where, args, err := scoped(ctx, conditions, args...)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT id, tenant_id, name, description, price, stock FROM products" + where + " ORDER BY id"
+	if page.Limit > 0 || page.Offset > 0 {
+		// MySQL has no OFFSET without LIMIT, so use one larger than any
+		// catalog. It stays well clear of overflowing when added to offset.
+		limit := int64(math.MaxInt32)
+		if page.Limit > 0 {
+			limit = int64(page.Limit)
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, max(page.Offset, 0))
+	}
+	ctx, span := startSpan(ctx, "SearchProducts", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	return r.query(ctx, query, args...)
+}
+
+// likeEscaper escapes LIKE wildcards for patterns written with ESCAPE '!'.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// filtered turns a product filter and the start of a page into WHERE
+// conditions.
+func filtered(filter domain.ProductFilter, page domain.Page) (string, []any, error) {
+	var conditions []string
+	var args []any
+	if filter.NameContains != "" {
+		conditions = append(conditions, "LOWER(name) LIKE ? ESCAPE '!'")
+		args = append(args, "%"+likeEscaper.Replace(strings.ToLower(filter.NameContains))+"%")
+	}
+	if len(filter.Names) > 0 {
+		conditions = append(conditions, "name IN (?"+strings.Repeat(", ?", len(filter.Names)-1)+")")
+		for _, name := range filter.Names {
+			args = append(args, name)
+		}
+	}
+	if filter.MinPrice > 0 {
+		conditions = append(conditions, "price >= ?")
+		args = append(args, filter.MinPrice)
+	}
+	if filter.MaxPrice > 0 {
+		conditions = append(conditions, "price <= ?")
+		args = append(args, filter.MaxPrice)
+	}
+	if filter.InStock {
+		conditions = append(conditions, "stock > 0")
+	}
+	if page.After != "" {
+		after, err := parseID(page.After)
+		if err != nil {
+			return "", nil, domain.InvalidCursor(err)
+		}
+		conditions = append(conditions, "id > ?")
+		args = append(args, after)
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// query runs a SELECT of all product columns.
+func (r *MySQLProductRepository) query(ctx context.Context, query string, args ...any) ([]domain.Product, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []domain.Product
+	for rows.Next() {
+		var product domain.Product
+		if err := rows.Scan(&product.ID, &product.TenantID, &product.Name, &product.Description, &product.Price, &product.Stock); err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	return products, rows.Err()
+}
+
 // UpdateProduct method
-func (r *MySQLProductRepository) UpdateProduct(id string, product *domain.Product) error {
-	_, err := r.db.Exec(`
+// IDs that are not integers report not found; updating an ID that does not
+// exist is not an error.
+func (r *MySQLProductRepository) UpdateProduct(ctx context.Context, id string, product *domain.Product) (err error) {
+	n, parseErr := parseID(id)
+	where, args, err := scoped(ctx, "id = ?", n)
+	if err != nil {
+		return err
+	}
+	if parseErr != nil {
+		return parseErr
+	}
+	query := `
 		UPDATE products
-		SET name = ?, description = ?, price = ?, stock = ?
-		WHERE id = ?`,
-		product.Name, product.Description, product.Price, product.Stock, id)
+		SET name = ?, description = ?, price = ?, stock = ?` + where
+	ctx, span := startSpan(ctx, "UpdateProduct", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	args = append([]any{product.Name, product.Description, product.Price, product.Stock}, args...)
+	_, err = r.db.ExecContext(ctx, query, args...)
 	return err
 }
 
 // DeleteProduct method
-func (r *MySQLProductRepository) DeleteProduct(id string) error {
-	_, err := r.db.Exec("DELETE FROM products WHERE id=?", id)
+// IDs that are not integers report not found; deleting an ID that does not
+// exist is not an error.
+func (r *MySQLProductRepository) DeleteProduct(ctx context.Context, id string) (err error) {
+	n, parseErr := parseID(id)
+	where, args, err := scoped(ctx, "id = ?", n)
+	if err != nil {
+		return err
+	}
+	if parseErr != nil {
+		return parseErr
+	}
+	query := "DELETE FROM products" + where
+	ctx, span := startSpan(ctx, "DeleteProduct", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	_, err = r.db.ExecContext(ctx, query, args...)
 	return err
 }
@@ -0,0 +1,167 @@
+package mysql_test
+
+import (
+	"context"
+	"database/sql"
+	"product-management/internal/domain"
+	"product-management/internal/repository/mysql"
+	"product-management/internal/repository/mysql/mysqltest"
+	"product-management/internal/repository/repositorytest"
+	"product-management/internal/tenant"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRepo(t *testing.T) (*mysql.MySQLProductRepository, *sql.DB) {
+	db := mysqltest.New(t)
+	repo := mysql.NewMySQLProductRepository(db)
+	require.NoError(t, repo.EnsureSchema(context.Background(), "default"))
+	return repo, db
+}
+
+// createIn creates a product in the tenant and returns its ID.
+func createIn(t *testing.T, repo *mysql.MySQLProductRepository, tenantID, name string) string {
+	ctx := tenant.WithTenant(context.Background(), tenantID)
+	require.NoError(t, repo.Create(ctx, &domain.Product{Name: name, Description: "d", Price: 1, Stock: 1}))
+	products, err := repo.GetAllProducts(ctx)
+	require.NoError(t, err)
+	for _, p := range products {
+		if p.Name == name {
+			return p.ID
+		}
+	}
+	t.Fatalf("product %q not found in tenant %q", name, tenantID)
+	return ""
+}
+
+func TestTenantsCannotReadEachOther(t *testing.T) {
+	repo, _ := newRepo(t)
+	shopA := tenant.WithTenant(context.Background(), "shop-a")
+	shopB := tenant.WithTenant(context.Background(), "shop-b")
+
+	idA := createIn(t, repo, "shop-a", "kecap")
+	createIn(t, repo, "shop-b", "sambal")
+
+	products, err := repo.GetAllProducts(shopB)
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+	assert.Equal(t, "sambal", products[0].Name)
+	assert.Equal(t, "shop-b", products[0].TenantID)
+
+	_, err = repo.GetProductById(shopB, idA)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	product, err := repo.GetProductById(shopA, idA)
+	require.NoError(t, err)
+	assert.Equal(t, "kecap", product.Name)
+
+	products, err = repo.GetProductsByIds(shopB, []string{idA})
+	require.NoError(t, err)
+	assert.Empty(t, products)
+
+	products, err = repo.GetProductsByIds(shopA, []string{idA, "999"})
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+	assert.Equal(t, "kecap", products[0].Name)
+}
+
+func TestTenantsCannotWriteEachOther(t *testing.T) {
+	repo, _ := newRepo(t)
+	shopA := tenant.WithTenant(context.Background(), "shop-a")
+	shopB := tenant.WithTenant(context.Background(), "shop-b")
+	idA := createIn(t, repo, "shop-a", "kecap")
+
+	require.NoError(t, repo.UpdateProduct(shopB, idA, &domain.Product{Name: "hijacked", Description: "x", Price: 9, Stock: 9}))
+	require.NoError(t, repo.DeleteProduct(shopB, idA))
+
+	product, err := repo.GetProductById(shopA, idA)
+	require.NoError(t, err)
+	assert.Equal(t, "kecap", product.Name)
+
+	// A tenant_id in the payload is ignored in favour of the context.
+	spoofed := &domain.Product{TenantID: "shop-a", Name: "spoof", Description: "d", Price: 1, Stock: 1}
+	require.NoError(t, repo.Create(shopB, spoofed))
+	assert.Equal(t, "shop-b", spoofed.TenantID)
+	products, err := repo.GetAllProducts(shopA)
+	require.NoError(t, err)
+	assert.Len(t, products, 1)
+}
+
+func TestUnscopedContextIsRejected(t *testing.T) {
+	repo, _ := newRepo(t)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, repo.Create(ctx, &domain.Product{Name: "x"}), tenant.ErrMissingTenant)
+	_, err := repo.GetAllProducts(ctx)
+	assert.ErrorIs(t, err, tenant.ErrMissingTenant)
+	_, err = repo.GetProductById(ctx, "1")
+	assert.ErrorIs(t, err, tenant.ErrMissingTenant)
+	assert.ErrorIs(t, repo.UpdateProduct(ctx, "1", &domain.Product{}), tenant.ErrMissingTenant)
+	assert.ErrorIs(t, repo.DeleteProduct(ctx, "1"), tenant.ErrMissingTenant)
+}
+
+func TestNamesAreUniquePerTenant(t *testing.T) {
+	repo, _ := newRepo(t)
+	createIn(t, repo, "shop-a", "kecap")
+	createIn(t, repo, "shop-b", "kecap")
+
+	err := repo.Create(tenant.WithTenant(context.Background(), "shop-a"), &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1})
+	assert.Error(t, err)
+
+	all, err := repo.GetAllProducts(tenant.WithAllTenants(context.Background()))
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+}
+
+func TestEnsureSchemaMigratesLegacyTable(t *testing.T) {
+	db := mysqltest.New(t)
+	_, err := db.Exec(`CREATE TABLE products (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		description TEXT NOT NULL,
+		price DOUBLE NOT NULL,
+		stock INT NOT NULL)`)
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO products (name, description, price, stock) VALUES ('kecap', 'asus', 10000, 20)")
+	require.NoError(t, err)
+
+	repo := mysql.NewMySQLProductRepository(db)
+	require.NoError(t, repo.EnsureSchema(context.Background(), "legacy"))
+	require.NoError(t, repo.EnsureSchema(context.Background(), "legacy"))
+
+	products, err := repo.GetAllProducts(tenant.WithTenant(context.Background(), "legacy"))
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+	assert.Equal(t, "kecap", products[0].Name)
+}
+
+func TestEnsureSchemaFinishesInterruptedMigration(t *testing.T) {
+	db := mysqltest.New(t)
+	// The column was added but the migration stopped before the indexes.
+	_, err := db.Exec(`CREATE TABLE products (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		tenant_id VARCHAR(64) NOT NULL,
+		name VARCHAR(100) NOT NULL,
+		description TEXT NOT NULL,
+		price DOUBLE NOT NULL,
+		stock INT NOT NULL,
+		KEY idx_products_tenant_id (tenant_id, id))`)
+	require.NoError(t, err)
+
+	repo := mysql.NewMySQLProductRepository(db)
+	require.NoError(t, repo.EnsureSchema(context.Background(), "legacy"))
+
+	ctx := tenant.WithTenant(context.Background(), "shop-a")
+	require.NoError(t, repo.Create(ctx, &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1}))
+	err = repo.Create(ctx, &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1})
+	assert.ErrorIs(t, err, domain.ErrConflict)
+}
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repository {
+		repo, _ := newRepo(t)
+		return repo
+	})
+}
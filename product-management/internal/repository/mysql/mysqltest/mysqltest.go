@@ -0,0 +1,48 @@
+// internal/repository/mysql/mysqltest/mysqltest.go
+package mysqltest
+
+import (
+	"database/sql"
+	"testing"
+
+	sqle "github.com/dolthub/go-mysql-server"
+	"github.com/dolthub/go-mysql-server/memory"
+	"github.com/dolthub/go-mysql-server/server"
+	gmssql "github.com/dolthub/go-mysql-server/sql"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+)
+
+// New starts an in-process MySQL-compatible server with an empty database
+// and returns a pool connected to it. The server is stopped when the test
+// finishes. Each call gets its own server, so tests can run in parallel.
+func New(t testing.TB) *sql.DB {
+	t.Helper()
+	logrus.SetLevel(logrus.ErrorLevel)
+
+	name := "produk"
+	db := memory.NewDatabase(name)
+	db.BaseDatabase.EnablePrimaryKeyIndexes()
+	provider := memory.NewDBProvider(db)
+	engine := sqle.NewDefault(provider)
+
+	srv, err := server.NewServer(server.Config{
+		Protocol: "tcp",
+		Address:  "127.0.0.1:0",
+	}, engine, gmssql.NewContext, memory.NewSessionBuilder(provider), nil)
+	if err != nil {
+		t.Fatalf("start MySQL test server: %v", err)
+	}
+	go srv.Start()
+	t.Cleanup(func() { srv.Close() })
+
+	pool, err := sql.Open("mysql", "root:@tcp("+srv.Listener.Addr().String()+")/"+name+"?parseTime=true")
+	if err != nil {
+		t.Fatalf("open MySQL test server: %v", err)
+	}
+	t.Cleanup(func() { pool.Close() })
+	if err := pool.Ping(); err != nil {
+		t.Fatalf("ping MySQL test server: %v", err)
+	}
+	return pool
+}
@@ -0,0 +1,115 @@
+// internal/repository/postgres/migrate.go
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLock is the advisory lock key that serialises migrations when
+// several instances start at once.
+const migrationLock = 7_319_024_611
+
+const migrationsTable = `
+CREATE TABLE IF NOT EXISTS product_schema_migrations (
+	version INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`
+
+// migration is one file in migrations/, named <version>_<name>.sql.
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []migration
+	for _, entry := range entries {
+		prefix, name, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("migration %s: name must be <version>_<name>.sql", entry.Name())
+		}
+		body, err := migrationFiles.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{version: version, name: name, sql: string(body)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// Migrate applies the migrations that have not run yet, each in its own
+// transaction, and returns how many were applied.
+func Migrate(ctx context.Context, db *sql.DB) (int, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := db.ExecContext(ctx, migrationsTable); err != nil {
+		return 0, fmt.Errorf("create migrations table: %w", err)
+	}
+
+	applied := 0
+	for _, m := range migrations {
+		ok, err := apply(ctx, db, m)
+		if err != nil {
+			return applied, fmt.Errorf("migration %d_%s: %w", m.version, m.name, err)
+		}
+		if ok {
+			applied++
+			slog.InfoContext(ctx, "Applied PostgreSQL migration", "version", m.version, "name", m.name)
+		}
+	}
+	return applied, nil
+}
+
+// apply runs m unless another instance already has. DDL is transactional
+// in PostgreSQL, so a failed migration leaves no trace.
+func apply(ctx context.Context, db *sql.DB, m migration) (_ bool, err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", migrationLock); err != nil {
+		return false, err
+	}
+	var done bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM product_schema_migrations WHERE version = $1)", m.version).Scan(&done)
+	if err != nil {
+		return false, err
+	}
+	if done {
+		return false, tx.Rollback()
+	}
+	if _, err = tx.ExecContext(ctx, m.sql); err != nil {
+		return false, err
+	}
+	if _, err = tx.ExecContext(ctx, "INSERT INTO product_schema_migrations (version, name) VALUES ($1, $2)", m.version, m.name); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
@@ -0,0 +1,11 @@
+CREATE TABLE products (
+	id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	tenant_id VARCHAR(64) NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	description TEXT NOT NULL,
+	price DOUBLE PRECISION NOT NULL,
+	stock INTEGER NOT NULL,
+	CONSTRAINT uq_products_tenant_name UNIQUE (tenant_id, name)
+);
+
+CREATE INDEX idx_products_tenant_id ON products (tenant_id, id);
@@ -0,0 +1,409 @@
+// internal/repository/postgres/postgres_product_repository.go
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"product-management/internal/domain"
+	"product-management/internal/tenant"
+	"product-management/internal/tracing"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("product-management/internal/repository/postgres")
+
+// PostgresProductRepository stores products in PostgreSQL. IDs are the
+// decimal form of a BIGINT identity column, like the MySQL repository.
+type PostgresProductRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresProductRepository(db *sql.DB) *PostgresProductRepository {
+	return &PostgresProductRepository{db: db}
+}
+
+// EnsureSchema applies pending migrations. The PostgreSQL schema had tenants
+// from the start, so defaultTenant is only validated for parity with the
+// other repositories.
+func (r *PostgresProductRepository) EnsureSchema(ctx context.Context, defaultTenant string) error {
+	if err := tenant.Validate(defaultTenant); err != nil {
+		return fmt.Errorf("default tenant %q: %w", defaultTenant, err)
+	}
+	_, err := Migrate(ctx, r.db)
+	return err
+}
+
+// parseID converts a product ID to the column type. IDs that are not
+// integers cannot exist, so they report not found.
+func parseID(id string) (int64, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, domain.ProductNotFound(sql.ErrNoRows)
+	}
+	return n, nil
+}
+
+// PostgreSQL error codes of a unique key violation and of the connection
+// exception class.
+const (
+	uniqueViolation     = "23505"
+	connectionException = "08"
+)
+
+// translate turns driver errors into domain errors. Others are returned
+// unchanged.
+func translate(err error) error {
+	var pgErr *pgconn.PgError
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return domain.ProductNotFound(err)
+	case errors.As(err, &pgErr) && pgErr.Code == uniqueViolation:
+		return domain.NewError(domain.ErrConflict, "A product with this name already exists", err)
+	case errors.As(err, &pgErr) && strings.HasPrefix(pgErr.Code, connectionException),
+		errors.As(err, &connectErr), errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, sql.ErrConnDone), errors.As(err, &netErr):
+		return domain.NewError(domain.ErrUnavailable, "PostgreSQL is unavailable", err)
+	}
+	return err
+}
+
+// scoped appends the tenant condition to a WHERE clause whose placeholders
+// are numbered after args. Contexts spanning all tenants (background jobs)
+// are not filtered.
+func scoped(ctx context.Context, where string, args ...any) (string, []any, error) {
+	id, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	if !all {
+		if where != "" {
+			where += " AND "
+		}
+		args = append(args, id)
+		where += "tenant_id = $" + strconv.Itoa(len(args))
+	}
+	if where == "" {
+		return "", args, nil
+	}
+	return " WHERE " + where, args, nil
+}
+
+// startSpan starts a client span describing a single PostgreSQL statement.
+func startSpan(ctx context.Context, operation, query string) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(ctx, "postgres."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", operation),
+			attribute.String("db.statement", query),
+		),
+	)
+	slog.DebugContext(ctx, "Executing PostgreSQL statement", "operation", operation, "statement", query)
+	return ctx, span
+}
+
+// Create method. The generated ID is written back to product.ID.
+func (r *PostgresProductRepository) Create(ctx context.Context, product *domain.Product) (err error) {
+	id, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return err
+	}
+	if !all {
+		product.TenantID = id
+	} else if product.TenantID == "" {
+		return errors.New("product has no tenant")
+	}
+
+	query := "INSERT INTO products (tenant_id, name, description, price, stock) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	ctx, span := startSpan(ctx, "Create", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	var newID int64
+	err = r.db.QueryRowContext(ctx, query, product.TenantID, product.Name, product.Description, product.Price, product.Stock).Scan(&newID)
+	if err != nil {
+		return err
+	}
+	product.ID = strconv.FormatInt(newID, 10)
+	return nil
+}
+
+// UpsertProducts writes products with one INSERT that updates rows clashing
+// on the unique (tenant_id, name) constraint. One statement cannot update a
+// row twice, so only the last product of each name is sent.
+func (r *PostgresProductRepository) UpsertProducts(ctx context.Context, products []domain.Product) (err error) {
+	id, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		if !all {
+			products[i].TenantID = id
+		} else if products[i].TenantID == "" {
+			return errors.New("product has no tenant")
+		}
+	}
+	if len(products) == 0 {
+		return nil
+	}
+
+	const update = " ON CONFLICT (tenant_id, name) DO UPDATE SET description = EXCLUDED.description, price = EXCLUDED.price, stock = EXCLUDED.stock"
+	const insert = "INSERT INTO products (tenant_id, name, description, price, stock) VALUES "
+	ctx, span := startSpan(ctx, "UpsertProducts", insert+"($1, $2, $3, $4, $5), ..."+update)
+	span.SetAttributes(attribute.Int("db.rows", len(products)))
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	type key struct{ tenantID, name string }
+	last := make(map[key]int, len(products))
+	for i, p := range products {
+		last[key{p.TenantID, p.Name}] = i
+	}
+	rows := make([]string, 0, len(last))
+	args := make([]any, 0, 5*len(last))
+	for i, p := range products {
+		if last[key{p.TenantID, p.Name}] != i {
+			continue
+		}
+		n := len(args)
+		rows = append(rows, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5))
+		args = append(args, p.TenantID, p.Name, p.Description, p.Price, p.Stock)
+	}
+	_, err = r.db.ExecContext(ctx, insert+strings.Join(rows, ", ")+update, args...)
+	return err
+}
+
+// GetAllProducts method
+func (r *PostgresProductRepository) GetAllProducts(ctx context.Context) (_ []domain.Product, err error) {
+	where, args, err := scoped(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT id, tenant_id, name, description, price, stock FROM products" + where + " ORDER BY id"
+	ctx, span := startSpan(ctx, "GetAllProducts", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	return r.query(ctx, query, args...)
+}
+
+// CountProducts implements domain.ProductCounter.
+func (r *PostgresProductRepository) CountProducts(ctx context.Context) (_ domain.ProductStats, err error) {
+	where, args, err := scoped(ctx, "")
+	if err != nil {
+		return domain.ProductStats{}, err
+	}
+	query := "SELECT COUNT(*), COALESCE(SUM(stock), 0) FROM products" + where
+	ctx, span := startSpan(ctx, "CountProducts", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	var stats domain.ProductStats
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&stats.Count, &stats.Stock)
+	return stats, err
+}
+
+// GetProductById method
+func (r *PostgresProductRepository) GetProductById(ctx context.Context, id string) (_ *domain.Product, err error) {
+	n, parseErr := parseID(id)
+	where, args, err := scoped(ctx, "id = $1", n)
+	if err != nil {
+		return nil, err
+	}
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	query := "SELECT id, tenant_id, name, description, price, stock FROM products" + where
+	ctx, span := startSpan(ctx, "GetProductById", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	var product domain.Product
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&product.ID, &product.TenantID, &product.Name, &product.Description, &product.Price, &product.Stock)
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// GetProductsByIds method. IDs that are not integers cannot match and are
+// skipped.
+func (r *PostgresProductRepository) GetProductsByIds(ctx context.Context, ids []string) (_ []domain.Product, err error) {
+	ns := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if n, err := parseID(id); err == nil {
+			ns = append(ns, n)
+		}
+	}
+	if len(ns) == 0 {
+		return nil, nil
+	}
+	where, args, err := scoped(ctx, "id = ANY($1)", ns)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT id, tenant_id, name, description, price, stock FROM products" + where + " ORDER BY id"
+	ctx, span := startSpan(ctx, "GetProductsByIds", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	return r.query(ctx, query, args...)
+}
+
+// SearchProducts returns the products matching filter in ID order.
+func (r *PostgresProductRepository) SearchProducts(ctx context.Context, filter domain.ProductFilter, page domain.Page) (_ []domain.Product, err error) {
+	conditions, args, err := filtered(filter, page)
+	if err != nil {
+		return nil, err
+	}
+	where, args, err := scoped(ctx, conditions, args...)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT id, tenant_id, name, description, price, stock FROM products" + where + " ORDER BY id"
+	if page.Limit > 0 {
+		args = append(args, page.Limit)
+		query += " LIMIT $" + strconv.Itoa(len(args))
+	}
+	if page.Offset > 0 {
+		args = append(args, page.Offset)
+		query += " OFFSET $" + strconv.Itoa(len(args))
+	}
+	ctx, span := startSpan(ctx, "SearchProducts", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	return r.query(ctx, query, args...)
+}
+
+// likeEscaper escapes LIKE wildcards for patterns written with ESCAPE '!'.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// filtered turns a product filter and the start of a page into WHERE
+// conditions numbered from $1.
+func filtered(filter domain.ProductFilter, page domain.Page) (string, []any, error) {
+	var conditions []string
+	var args []any
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.Replace(condition, "?", "$"+strconv.Itoa(len(args)), 1))
+	}
+	if filter.NameContains != "" {
+		add("LOWER(name) LIKE ? ESCAPE '!'", "%"+likeEscaper.Replace(strings.ToLower(filter.NameContains))+"%")
+	}
+	if len(filter.Names) > 0 {
+		add("name = ANY(?)", filter.Names)
+	}
+	if filter.MinPrice > 0 {
+		add("price >= ?", filter.MinPrice)
+	}
+	if filter.MaxPrice > 0 {
+		add("price <= ?", filter.MaxPrice)
+	}
+	if filter.InStock {
+		conditions = append(conditions, "stock > 0")
+	}
+	if page.After != "" {
+		after, err := parseID(page.After)
+		if err != nil {
+			return "", nil, domain.InvalidCursor(err)
+		}
+		add("id > ?", after)
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// query runs a SELECT of all product columns.
+func (r *PostgresProductRepository) query(ctx context.Context, query string, args ...any) ([]domain.Product, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []domain.Product
+	for rows.Next() {
+		var product domain.Product
+		if err := rows.Scan(&product.ID, &product.TenantID, &product.Name, &product.Description, &product.Price, &product.Stock); err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	return products, rows.Err()
+}
+
+// UpdateProduct method. Like the MySQL repository, IDs that are not
+// integers report not found and updating an ID that does not exist is not
+// an error.
+func (r *PostgresProductRepository) UpdateProduct(ctx context.Context, id string, product *domain.Product) (err error) {
+	n, parseErr := parseID(id)
+	where, args, err := scoped(ctx, "id = $5", product.Name, product.Description, product.Price, product.Stock, n)
+	if err != nil {
+		return err
+	}
+	if parseErr != nil {
+		return parseErr
+	}
+	query := `
+		UPDATE products
+		SET name = $1, description = $2, price = $3, stock = $4` + where
+	ctx, span := startSpan(ctx, "UpdateProduct", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// DeleteProduct method. IDs that are not integers report not found;
+// deleting an ID that does not exist is not an error.
+func (r *PostgresProductRepository) DeleteProduct(ctx context.Context, id string) (err error) {
+	n, parseErr := parseID(id)
+	where, args, err := scoped(ctx, "id = $1", n)
+	if err != nil {
+		return err
+	}
+	if parseErr != nil {
+		return parseErr
+	}
+	query := "DELETE FROM products" + where
+	ctx, span := startSpan(ctx, "DeleteProduct", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
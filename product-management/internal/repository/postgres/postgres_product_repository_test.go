@@ -0,0 +1,102 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"product-management/internal/domain"
+	"product-management/internal/repository/postgres"
+	"product-management/internal/repository/postgres/postgrestest"
+	"product-management/internal/repository/repositorytest"
+	"product-management/internal/tenant"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRepo(t *testing.T) (*postgres.PostgresProductRepository, *sql.DB) {
+	db := postgrestest.New(t)
+	repo := postgres.NewPostgresProductRepository(db)
+	require.NoError(t, repo.EnsureSchema(context.Background(), "default"))
+	return repo, db
+}
+
+func TestCreateReturnsGeneratedID(t *testing.T) {
+	repo, _ := newRepo(t)
+	ctx := tenant.WithTenant(context.Background(), "shop-a")
+
+	product := &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1}
+	require.NoError(t, repo.Create(ctx, product))
+	require.NotEmpty(t, product.ID)
+
+	found, err := repo.GetProductById(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, product.ID, found.ID)
+	assert.Equal(t, "d", found.Description)
+	assert.Equal(t, 1, found.Stock)
+}
+
+func TestTenantIsolation(t *testing.T) {
+	repo, _ := newRepo(t)
+	shopA := tenant.WithTenant(context.Background(), "shop-a")
+	shopB := tenant.WithTenant(context.Background(), "shop-b")
+
+	kecap := &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1}
+	require.NoError(t, repo.Create(shopA, kecap))
+	require.NoError(t, repo.Create(shopB, &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1}))
+	assert.Error(t, repo.Create(shopA, &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1}))
+
+	_, err := repo.GetProductById(shopB, kecap.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	batch, err := repo.GetProductsByIds(shopB, []string{kecap.ID, "not-a-number"})
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+	batch, err = repo.GetProductsByIds(shopA, []string{kecap.ID, "999999"})
+	require.NoError(t, err)
+	assert.Len(t, batch, 1)
+
+	require.NoError(t, repo.UpdateProduct(shopB, kecap.ID, &domain.Product{Name: "hijacked", Description: "x", Price: 9, Stock: 9}))
+	require.NoError(t, repo.DeleteProduct(shopB, kecap.ID))
+	product, err := repo.GetProductById(shopA, kecap.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "kecap", product.Name)
+
+	_, err = repo.GetAllProducts(context.Background())
+	assert.ErrorIs(t, err, tenant.ErrMissingTenant)
+}
+
+func TestUpdateAndDelete(t *testing.T) {
+	repo, _ := newRepo(t)
+	ctx := tenant.WithTenant(context.Background(), "shop-a")
+	product := &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1}
+	require.NoError(t, repo.Create(ctx, product))
+
+	require.NoError(t, repo.UpdateProduct(ctx, product.ID, &domain.Product{Name: "kecap manis", Description: "d2", Price: 2, Stock: 3}))
+	updated, err := repo.GetProductById(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "kecap manis", updated.Name)
+	assert.Equal(t, 3, updated.Stock)
+
+	require.NoError(t, repo.DeleteProduct(ctx, product.ID))
+	_, err = repo.GetProductById(ctx, product.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = repo.GetProductById(ctx, "not-a-number")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	_, db := newRepo(t)
+
+	applied, err := postgres.Migrate(context.Background(), db)
+	require.NoError(t, err)
+	assert.Zero(t, applied)
+}
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repository {
+		repo, _ := newRepo(t)
+		return repo
+	})
+}
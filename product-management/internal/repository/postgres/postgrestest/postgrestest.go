@@ -0,0 +1,51 @@
+// internal/repository/postgres/postgrestest/postgrestest.go
+package postgrestest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// DSNEnv names the PostgreSQL server used by tests. There is no in-process
+// PostgreSQL, so tests that need one are skipped when it is unset.
+const DSNEnv = "POSTGRES_TEST_DSN"
+
+// New returns a pool whose connections use a fresh schema on the server
+// named by POSTGRES_TEST_DSN. The schema is dropped when the test ends.
+func New(t testing.TB) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv(DSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set; skipping PostgreSQL test", DSNEnv)
+	}
+
+	cfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("parse %s: %v", DSNEnv, err)
+	}
+	admin := stdlib.OpenDB(*cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	schema := fmt.Sprintf("producttest_%d", time.Now().UnixNano())
+	if _, err := admin.ExecContext(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+
+	cfg.RuntimeParams["search_path"] = schema
+	db := stdlib.OpenDB(*cfg)
+	t.Cleanup(func() {
+		db.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		admin.ExecContext(ctx, "DROP SCHEMA "+schema+" CASCADE")
+		admin.Close()
+	})
+	return db
+}
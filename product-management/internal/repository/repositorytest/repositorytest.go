@@ -0,0 +1,422 @@
+// internal/repository/repositorytest/repositorytest.go
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"product-management/internal/domain"
+	"product-management/internal/tenant"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Repository is the contract every product backend implements.
+type Repository interface {
+	domain.ProductRepository
+	domain.ProductSearcher
+	domain.ProductUpserter
+	domain.ProductCounter
+}
+
+// Run checks repo against the ProductRepository contract. newRepo must
+// return an empty repository with its schema prepared; it is called once
+// per contract test, so backends that share a server need a fresh database
+// or schema each time.
+func Run(t *testing.T, newRepo func(t *testing.T) Repository) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo Repository)
+	}{
+		{"CreateAndGetRoundTrip", testCreateAndGetRoundTrip},
+		{"NamesAreUniquePerTenant", testNamesAreUniquePerTenant},
+		{"UnknownIDsAreNotFound", testUnknownIDsAreNotFound},
+		{"UpdateChangesOnlyTarget", testUpdateChangesOnlyTarget},
+		{"Delete", testDelete},
+		{"TenantsAreIsolated", testTenantsAreIsolated},
+		{"ReadsReturnCopies", testReadsReturnCopies},
+		{"ConcurrentWriters", testConcurrentWriters},
+		{"Pagination", testPagination},
+		{"KeysetPagination", testKeysetPagination},
+		{"Filtering", testFiltering},
+		{"UpsertMatchesByName", testUpsertMatchesByName},
+		{"CountSumsStockPerTenant", testCountSumsStockPerTenant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepo(t))
+		})
+	}
+}
+
+// ID returns the identifier a product is looked up by: the ObjectID of
+// documents, the row ID otherwise.
+func ID(p *domain.Product) string {
+	if !p.MongoID.IsZero() {
+		return p.MongoID.Hex()
+	}
+	return p.ID
+}
+
+// IsNotFound reports whether err is a not-found error.
+func IsNotFound(err error) bool {
+	return errors.Is(err, domain.ErrNotFound)
+}
+
+// unknownIDs cannot name a product in any backend: a row ID that was never
+// generated, an ObjectID that was never generated and a malformed ID.
+var unknownIDs = []string{"999999", "ffffffffffffffffffffffff", "not-an-id"}
+
+func shop(id string) context.Context {
+	return tenant.WithTenant(context.Background(), id)
+}
+
+func create(t *testing.T, repo Repository, ctx context.Context, name string, price float64, stock int) *domain.Product {
+	t.Helper()
+	product := &domain.Product{Name: name, Description: "about " + name, Price: price, Stock: stock}
+	require.NoError(t, repo.Create(ctx, product))
+	require.NotEmpty(t, ID(product), "Create must report the generated ID")
+	return product
+}
+
+func get(t *testing.T, repo Repository, ctx context.Context, id string) *domain.Product {
+	t.Helper()
+	product, err := repo.GetProductById(ctx, id)
+	require.NoError(t, err)
+	require.NotNil(t, product)
+	return product
+}
+
+func names(products []domain.Product) []string {
+	var out []string
+	for _, p := range products {
+		out = append(out, p.Name)
+	}
+	return out
+}
+
+func testCreateAndGetRoundTrip(t *testing.T, repo Repository) {
+	ctx := shop("shop-a")
+	created := create(t, repo, ctx, "kecap", 12000.5, 3)
+	assert.Equal(t, "shop-a", created.TenantID)
+
+	found := get(t, repo, ctx, ID(created))
+	assert.Equal(t, ID(created), ID(found))
+	assert.Equal(t, "shop-a", found.TenantID)
+	assert.Equal(t, "kecap", found.Name)
+	assert.Equal(t, "about kecap", found.Description)
+	assert.Equal(t, 12000.5, found.Price)
+	assert.Equal(t, 3, found.Stock)
+
+	all, err := repo.GetAllProducts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kecap"}, names(all))
+
+	batch, err := repo.GetProductsByIds(ctx, append([]string{ID(created)}, unknownIDs...))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kecap"}, names(batch))
+	empty, err := repo.GetProductsByIds(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func testNamesAreUniquePerTenant(t *testing.T, repo Repository) {
+	create(t, repo, shop("shop-a"), "kecap", 1, 1)
+	create(t, repo, shop("shop-b"), "kecap", 1, 1)
+
+	assert.ErrorIs(t, repo.Create(shop("shop-a"), &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1}), domain.ErrConflict)
+	sambal := create(t, repo, shop("shop-a"), "sambal", 1, 1)
+	assert.ErrorIs(t, repo.UpdateProduct(shop("shop-a"), ID(sambal), &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1}), domain.ErrConflict)
+}
+
+func testUnknownIDsAreNotFound(t *testing.T, repo Repository) {
+	ctx := shop("shop-a")
+	kept := create(t, repo, ctx, "kecap", 1, 1)
+
+	for _, id := range unknownIDs {
+		_, err := repo.GetProductById(ctx, id)
+		assert.True(t, IsNotFound(err), "GetProductById(%q) = %v, want a not-found error", id, err)
+
+		// Writes to IDs the backend could have generated are no-ops; IDs it
+		// could not have, such as the malformed one, are not found.
+		err = repo.UpdateProduct(ctx, id, &domain.Product{Name: "x", Description: "x", Price: 1, Stock: 1})
+		assert.True(t, err == nil || IsNotFound(err), "UpdateProduct(%q) = %v", id, err)
+		err = repo.DeleteProduct(ctx, id)
+		assert.True(t, err == nil || IsNotFound(err), "DeleteProduct(%q) = %v", id, err)
+	}
+	malformed := unknownIDs[len(unknownIDs)-1]
+	err := repo.UpdateProduct(ctx, malformed, &domain.Product{Name: "x", Description: "x", Price: 1, Stock: 1})
+	assert.True(t, IsNotFound(err), "UpdateProduct(%q) = %v, want a not-found error", malformed, err)
+	assert.True(t, IsNotFound(repo.DeleteProduct(ctx, malformed)), "DeleteProduct(%q) should be not found", malformed)
+	assert.Equal(t, "kecap", get(t, repo, ctx, ID(kept)).Name)
+}
+
+func testUpdateChangesOnlyTarget(t *testing.T, repo Repository) {
+	ctx := shop("shop-a")
+	kecap := create(t, repo, ctx, "kecap", 1, 1)
+	sambal := create(t, repo, ctx, "sambal", 2, 2)
+
+	require.NoError(t, repo.UpdateProduct(ctx, ID(kecap), &domain.Product{
+		ID: "spoofed", TenantID: "shop-b", Name: "kecap manis", Description: "sweet", Price: 5, Stock: 7,
+	}))
+
+	updated := get(t, repo, ctx, ID(kecap))
+	assert.Equal(t, ID(kecap), ID(updated))
+	assert.Equal(t, "shop-a", updated.TenantID)
+	assert.Equal(t, "kecap manis", updated.Name)
+	assert.Equal(t, "sweet", updated.Description)
+	assert.Equal(t, 5.0, updated.Price)
+	assert.Equal(t, 7, updated.Stock)
+
+	untouched := get(t, repo, ctx, ID(sambal))
+	assert.Equal(t, "sambal", untouched.Name)
+	assert.Equal(t, "about sambal", untouched.Description)
+	assert.Equal(t, 2.0, untouched.Price)
+	assert.Equal(t, 2, untouched.Stock)
+}
+
+func testDelete(t *testing.T, repo Repository) {
+	ctx := shop("shop-a")
+	kecap := create(t, repo, ctx, "kecap", 1, 1)
+	sambal := create(t, repo, ctx, "sambal", 1, 1)
+
+	require.NoError(t, repo.DeleteProduct(ctx, ID(kecap)))
+	_, err := repo.GetProductById(ctx, ID(kecap))
+	assert.True(t, IsNotFound(err), "got %v", err)
+	assert.NoError(t, repo.DeleteProduct(ctx, ID(kecap)), "deleting twice is a no-op")
+
+	all, err := repo.GetAllProducts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sambal"}, names(all))
+	get(t, repo, ctx, ID(sambal))
+}
+
+func testTenantsAreIsolated(t *testing.T, repo Repository) {
+	kecap := create(t, repo, shop("shop-a"), "kecap", 1, 1)
+	create(t, repo, shop("shop-b"), "sambal", 1, 1)
+
+	_, err := repo.GetProductById(shop("shop-b"), ID(kecap))
+	assert.True(t, IsNotFound(err), "got %v", err)
+	batch, err := repo.GetProductsByIds(shop("shop-b"), []string{ID(kecap)})
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+	found, err := repo.SearchProducts(shop("shop-b"), domain.ProductFilter{}, domain.Page{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sambal"}, names(found))
+
+	require.NoError(t, repo.UpdateProduct(shop("shop-b"), ID(kecap), &domain.Product{Name: "hijacked", Description: "x", Price: 9, Stock: 9}))
+	require.NoError(t, repo.DeleteProduct(shop("shop-b"), ID(kecap)))
+	assert.Equal(t, "kecap", get(t, repo, shop("shop-a"), ID(kecap)).Name)
+
+	all, err := repo.GetAllProducts(tenant.WithAllTenants(context.Background()))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"kecap", "sambal"}, names(all))
+
+	_, err = repo.GetAllProducts(context.Background())
+	assert.ErrorIs(t, err, tenant.ErrMissingTenant)
+	assert.ErrorIs(t, repo.Create(context.Background(), &domain.Product{Name: "x"}), tenant.ErrMissingTenant)
+}
+
+func testReadsReturnCopies(t *testing.T, repo Repository) {
+	ctx := shop("shop-a")
+	product := create(t, repo, ctx, "kecap", 1, 1)
+	product.Stock = 99
+
+	found := get(t, repo, ctx, ID(product))
+	found.Name = "changed"
+	all, err := repo.GetAllProducts(ctx)
+	require.NoError(t, err)
+	all[0].Price = 99
+
+	again := get(t, repo, ctx, ID(product))
+	assert.Equal(t, "kecap", again.Name)
+	assert.Equal(t, 1.0, again.Price)
+	assert.Equal(t, 1, again.Stock)
+}
+
+func testConcurrentWriters(t *testing.T, repo Repository) {
+	ctx := shop("shop-a")
+	const writers = 16
+
+	var wg sync.WaitGroup
+	created := make([]*domain.Product, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			product := &domain.Product{Name: fmt.Sprintf("product-%02d", i), Description: "d", Price: 1, Stock: i}
+			if assert.NoError(t, repo.Create(ctx, product)) {
+				created[i] = product
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.NotContains(t, created, (*domain.Product)(nil))
+
+	ids := make(map[string]bool, writers)
+	for _, p := range created {
+		ids[ID(p)] = true
+	}
+	assert.Len(t, ids, writers, "generated IDs must be unique")
+
+	// Concurrent updates of one product leave one of the written states.
+	target := ID(created[0])
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, repo.UpdateProduct(ctx, target, &domain.Product{Name: "product-00", Description: "d", Price: float64(i), Stock: i}))
+		}(i)
+	}
+	wg.Wait()
+	final := get(t, repo, ctx, target)
+	assert.Equal(t, float64(final.Stock), final.Price, "an update must not be applied halfway")
+
+	all, err := repo.GetAllProducts(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, writers)
+}
+
+func testPagination(t *testing.T, repo Repository) {
+	ctx := shop("shop-a")
+	var want []string
+	for i := 0; i < 7; i++ {
+		want = append(want, create(t, repo, ctx, fmt.Sprintf("product-%d", i), 1, 1).Name)
+	}
+	create(t, repo, shop("shop-b"), "elsewhere", 1, 1)
+
+	var got []string
+	for offset := 0; ; offset += 3 {
+		page, err := repo.SearchProducts(ctx, domain.ProductFilter{}, domain.Page{Offset: offset, Limit: 3})
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page), 3)
+		if len(page) == 0 {
+			break
+		}
+		got = append(got, names(page)...)
+	}
+	assert.Equal(t, want, got, "pages must cover every product once, in creation order")
+
+	all, err := repo.SearchProducts(ctx, domain.ProductFilter{}, domain.Page{})
+	require.NoError(t, err)
+	assert.Equal(t, want, names(all))
+	rest, err := repo.SearchProducts(ctx, domain.ProductFilter{}, domain.Page{Offset: 5})
+	require.NoError(t, err)
+	assert.Equal(t, want[5:], names(rest))
+}
+
+func testKeysetPagination(t *testing.T, repo Repository) {
+	ctx := shop("shop-a")
+	var created []*domain.Product
+	for i := 0; i < 7; i++ {
+		created = append(created, create(t, repo, ctx, fmt.Sprintf("product-%d", i), 1, 1))
+	}
+	create(t, repo, shop("shop-b"), "elsewhere", 1, 1)
+
+	var got []string
+	after := ""
+	for {
+		page, err := repo.SearchProducts(ctx, domain.ProductFilter{}, domain.Page{After: after, Limit: 3})
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page), 3)
+		if len(page) == 0 {
+			break
+		}
+		got = append(got, names(page)...)
+		after = ID(&page[len(page)-1])
+		if len(got) == 3 {
+			// Deleting a product already seen must not shift the next page.
+			require.NoError(t, repo.DeleteProduct(ctx, ID(created[0])))
+		}
+	}
+	assert.Equal(t, []string{"product-0", "product-1", "product-2", "product-3", "product-4", "product-5", "product-6"}, got,
+		"pages must cover every product once, in creation order")
+
+	_, err := repo.SearchProducts(ctx, domain.ProductFilter{}, domain.Page{After: "not-an-id"})
+	assert.ErrorIs(t, err, domain.ErrValidation)
+}
+
+func testFiltering(t *testing.T, repo Repository) {
+	ctx := shop("shop-a")
+	create(t, repo, ctx, "Kecap Manis", 10, 0)
+	create(t, repo, ctx, "kecap asin", 20, 5)
+	create(t, repo, ctx, "sambal", 30, 5)
+	create(t, repo, ctx, "100%_pedas", 40, 5)
+
+	tests := []struct {
+		filter domain.ProductFilter
+		want   []string
+	}{
+		{domain.ProductFilter{NameContains: "KECAP"}, []string{"Kecap Manis", "kecap asin"}},
+		{domain.ProductFilter{NameContains: "%_"}, []string{"100%_pedas"}},
+		{domain.ProductFilter{MinPrice: 20, MaxPrice: 30}, []string{"kecap asin", "sambal"}},
+		{domain.ProductFilter{InStock: true, NameContains: "kecap"}, []string{"kecap asin"}},
+		{domain.ProductFilter{Names: []string{"sambal", "kecap asin", "kecap"}}, []string{"kecap asin", "sambal"}},
+		{domain.ProductFilter{Names: []string{"sambal", "kecap asin"}, NameContains: "kecap"}, []string{"kecap asin"}},
+		{domain.ProductFilter{NameContains: "tidak ada"}, nil},
+	}
+	for _, tt := range tests {
+		got, err := repo.SearchProducts(ctx, tt.filter, domain.Page{})
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, names(got), "%+v", tt.filter)
+	}
+}
+
+func testUpsertMatchesByName(t *testing.T, repo Repository) {
+	ctx := shop("shop-a")
+	kecap := create(t, repo, ctx, "kecap", 1, 1)
+	create(t, repo, shop("shop-b"), "sambal", 1, 1)
+
+	require.NoError(t, repo.UpsertProducts(ctx, []domain.Product{
+		{Name: "kecap", Description: "baru", Price: 2, Stock: 5},
+		{Name: "sambal", Description: "pedas", Price: 3, Stock: 4},
+		{Name: "sambal", Description: "lebih pedas", Price: 3, Stock: 6},
+	}))
+	updated := get(t, repo, ctx, ID(kecap))
+	assert.Equal(t, []any{"kecap", "baru", 2.0, 5}, []any{updated.Name, updated.Description, updated.Price, updated.Stock})
+	all, err := repo.GetAllProducts(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"kecap", "sambal"}, names(all))
+	assert.Equal(t, "lebih pedas", all[1].Description, "later duplicates win")
+	assert.Equal(t, 6, all[1].Stock)
+	other, err := repo.GetAllProducts(shop("shop-b"))
+	require.NoError(t, err)
+	require.Len(t, other, 1)
+	assert.Equal(t, "about sambal", other[0].Description, "other tenants are untouched")
+
+	// Background contexts write each product to its own tenant.
+	background := tenant.WithAllTenants(context.Background())
+	require.NoError(t, repo.UpsertProducts(background, []domain.Product{
+		{TenantID: "shop-b", Name: "sambal", Description: "about sambal", Price: 1, Stock: 9},
+		{TenantID: "shop-c", Name: "tomat", Description: "about tomat", Price: 1, Stock: 1},
+	}))
+	other, err = repo.GetAllProducts(shop("shop-b"))
+	require.NoError(t, err)
+	assert.Equal(t, 9, other[0].Stock)
+	created, err := repo.GetAllProducts(shop("shop-c"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tomat"}, names(created))
+
+	assert.Error(t, repo.UpsertProducts(background, []domain.Product{{Name: "yatim", Description: "d", Price: 1}}))
+	assert.NoError(t, repo.UpsertProducts(ctx, nil))
+}
+
+func testCountSumsStockPerTenant(t *testing.T, repo Repository) {
+	empty, err := repo.CountProducts(shop("shop-a"))
+	require.NoError(t, err)
+	assert.Zero(t, empty)
+
+	create(t, repo, shop("shop-a"), "kecap", 1, 3)
+	create(t, repo, shop("shop-a"), "sambal", 1, 4)
+	create(t, repo, shop("shop-b"), "tomat", 1, 5)
+
+	stats, err := repo.CountProducts(shop("shop-a"))
+	require.NoError(t, err)
+	assert.Equal(t, domain.ProductStats{Count: 2, Stock: 7}, stats)
+	stats, err = repo.CountProducts(tenant.WithAllTenants(context.Background()))
+	require.NoError(t, err)
+	assert.Equal(t, domain.ProductStats{Count: 3, Stock: 12}, stats)
+}
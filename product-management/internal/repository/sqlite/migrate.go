@@ -0,0 +1,129 @@
+// internal/repository/sqlite/migrate.go
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Open opens the database file at path, creating it if needed. The pool
+// uses WAL so readers do not block the writer, waits up to busyTimeout for
+// locks, and begins transactions IMMEDIATE so concurrent writers queue
+// instead of failing on lock upgrades. Times are written in SQLite's own
+// format so they sort and compare as text.
+func Open(path string) (*sql.DB, error) {
+	const busyTimeout = 5 * time.Second
+	q := url.Values{}
+	q.Add("_pragma", "journal_mode(WAL)")
+	q.Add("_pragma", "busy_timeout("+strconv.Itoa(int(busyTimeout.Milliseconds()))+")")
+	q.Add("_pragma", "foreign_keys(1)")
+	q.Add("_pragma", "synchronous(NORMAL)")
+	q.Set("_txlock", "immediate")
+	q.Set("_time_format", "sqlite")
+	return sql.Open("sqlite", "file:"+path+"?"+q.Encode())
+}
+
+const migrationsTable = `
+CREATE TABLE IF NOT EXISTS product_schema_migrations (
+	version INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+
+// migration is one file in migrations/, named <version>_<name>.sql.
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []migration
+	for _, entry := range entries {
+		prefix, name, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("migration %s: name must be <version>_<name>.sql", entry.Name())
+		}
+		body, err := migrationFiles.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{version: version, name: name, sql: string(body)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// Migrate applies the migrations that have not run yet, each in its own
+// transaction, and returns how many were applied.
+func Migrate(ctx context.Context, db *sql.DB) (int, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := db.ExecContext(ctx, migrationsTable); err != nil {
+		return 0, fmt.Errorf("create migrations table: %w", err)
+	}
+
+	applied := 0
+	for _, m := range migrations {
+		ok, err := apply(ctx, db, m)
+		if err != nil {
+			return applied, fmt.Errorf("migration %d_%s: %w", m.version, m.name, err)
+		}
+		if ok {
+			applied++
+			slog.InfoContext(ctx, "Applied SQLite migration", "version", m.version, "name", m.name)
+		}
+	}
+	return applied, nil
+}
+
+// apply runs m unless another process already has. Transactions begin
+// IMMEDIATE, which takes the write lock before the version check.
+func apply(ctx context.Context, db *sql.DB, m migration) (_ bool, err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var done bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM product_schema_migrations WHERE version = ?)", m.version).Scan(&done)
+	if err != nil {
+		return false, err
+	}
+	if done {
+		return false, tx.Rollback()
+	}
+	if _, err = tx.ExecContext(ctx, m.sql); err != nil {
+		return false, err
+	}
+	if _, err = tx.ExecContext(ctx, "INSERT INTO product_schema_migrations (version, name) VALUES (?, ?)", m.version, m.name); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
@@ -0,0 +1,11 @@
+CREATE TABLE products (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	tenant_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	price REAL NOT NULL,
+	stock INTEGER NOT NULL,
+	CONSTRAINT uq_products_tenant_name UNIQUE (tenant_id, name)
+);
+
+CREATE INDEX idx_products_tenant_id ON products (tenant_id, id);
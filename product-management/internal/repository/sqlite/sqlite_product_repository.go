@@ -0,0 +1,394 @@
+// internal/repository/sqlite/sqlite_product_repository.go
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"product-management/internal/domain"
+	"product-management/internal/tenant"
+	"product-management/internal/tracing"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	sqlitedriver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var tracer = otel.Tracer("product-management/internal/repository/sqlite")
+
+// SQLiteProductRepository stores products in a single SQLite file. IDs are
+// the decimal form of the INTEGER primary key, like the MySQL repository.
+type SQLiteProductRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteProductRepository(db *sql.DB) *SQLiteProductRepository {
+	return &SQLiteProductRepository{db: db}
+}
+
+// EnsureSchema applies pending migrations. The SQLite schema had tenants
+// from the start, so defaultTenant is only validated for parity with the
+// other repositories.
+func (r *SQLiteProductRepository) EnsureSchema(ctx context.Context, defaultTenant string) error {
+	if err := tenant.Validate(defaultTenant); err != nil {
+		return fmt.Errorf("default tenant %q: %w", defaultTenant, err)
+	}
+	_, err := Migrate(ctx, r.db)
+	return err
+}
+
+// parseID converts a product ID to the column type. IDs that are not
+// integers cannot exist, so they report not found.
+func parseID(id string) (int64, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, domain.ProductNotFound(sql.ErrNoRows)
+	}
+	return n, nil
+}
+
+// translate turns driver errors into domain errors. Others are returned
+// unchanged. A busy or locked database outlasted busy_timeout, so it is
+// reported as unavailable.
+func translate(err error) error {
+	var liteErr *sqlitedriver.Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return domain.ProductNotFound(err)
+	case errors.As(err, &liteErr) && (liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY):
+		return domain.NewError(domain.ErrConflict, "A product with this name already exists", err)
+	case errors.As(err, &liteErr) && (liteErr.Code()&0xff == sqlite3.SQLITE_BUSY || liteErr.Code()&0xff == sqlite3.SQLITE_LOCKED),
+		errors.Is(err, sql.ErrConnDone):
+		return domain.NewError(domain.ErrUnavailable, "SQLite is unavailable", err)
+	}
+	return err
+}
+
+// scoped appends the tenant condition to a WHERE clause. Contexts spanning
+// all tenants (background jobs) are not filtered.
+func scoped(ctx context.Context, where string, args ...any) (string, []any, error) {
+	id, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	if !all {
+		if where != "" {
+			where += " AND "
+		}
+		where += "tenant_id = ?"
+		args = append(args, id)
+	}
+	if where == "" {
+		return "", args, nil
+	}
+	return " WHERE " + where, args, nil
+}
+
+// startSpan starts a client span describing a single SQLite statement.
+func startSpan(ctx context.Context, operation, query string) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(ctx, "sqlite."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "sqlite"),
+			attribute.String("db.operation", operation),
+			attribute.String("db.statement", query),
+		),
+	)
+	slog.DebugContext(ctx, "Executing SQLite statement", "operation", operation, "statement", query)
+	return ctx, span
+}
+
+// Create method. The generated ID is written back to product.ID.
+func (r *SQLiteProductRepository) Create(ctx context.Context, product *domain.Product) (err error) {
+	id, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return err
+	}
+	if !all {
+		product.TenantID = id
+	} else if product.TenantID == "" {
+		return errors.New("product has no tenant")
+	}
+
+	query := "INSERT INTO products (tenant_id, name, description, price, stock) VALUES (?, ?, ?, ?, ?) RETURNING id"
+	ctx, span := startSpan(ctx, "Create", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	var newID int64
+	err = r.db.QueryRowContext(ctx, query, product.TenantID, product.Name, product.Description, product.Price, product.Stock).Scan(&newID)
+	if err != nil {
+		return err
+	}
+	product.ID = strconv.FormatInt(newID, 10)
+	return nil
+}
+
+// UpsertProducts writes products with one INSERT that updates rows clashing
+// on the unique (tenant_id, name) constraint.
+func (r *SQLiteProductRepository) UpsertProducts(ctx context.Context, products []domain.Product) (err error) {
+	id, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		if !all {
+			products[i].TenantID = id
+		} else if products[i].TenantID == "" {
+			return errors.New("product has no tenant")
+		}
+	}
+	if len(products) == 0 {
+		return nil
+	}
+
+	const row = "(?, ?, ?, ?, ?)"
+	const update = " ON CONFLICT (tenant_id, name) DO UPDATE SET description = excluded.description, price = excluded.price, stock = excluded.stock"
+	const insert = "INSERT INTO products (tenant_id, name, description, price, stock) VALUES "
+	ctx, span := startSpan(ctx, "UpsertProducts", insert+row+", ..."+update)
+	span.SetAttributes(attribute.Int("db.rows", len(products)))
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	args := make([]any, 0, 5*len(products))
+	for _, p := range products {
+		args = append(args, p.TenantID, p.Name, p.Description, p.Price, p.Stock)
+	}
+	query := insert + strings.Repeat(row+", ", len(products)-1) + row + update
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// GetAllProducts method
+func (r *SQLiteProductRepository) GetAllProducts(ctx context.Context) (_ []domain.Product, err error) {
+	where, args, err := scoped(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT id, tenant_id, name, description, price, stock FROM products" + where + " ORDER BY id"
+	ctx, span := startSpan(ctx, "GetAllProducts", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	return r.query(ctx, query, args...)
+}
+
+// CountProducts implements domain.ProductCounter.
+func (r *SQLiteProductRepository) CountProducts(ctx context.Context) (_ domain.ProductStats, err error) {
+	where, args, err := scoped(ctx, "")
+	if err != nil {
+		return domain.ProductStats{}, err
+	}
+	query := "SELECT COUNT(*), COALESCE(SUM(stock), 0) FROM products" + where
+	ctx, span := startSpan(ctx, "CountProducts", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	var stats domain.ProductStats
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&stats.Count, &stats.Stock)
+	return stats, err
+}
+
+// GetProductById method
+func (r *SQLiteProductRepository) GetProductById(ctx context.Context, id string) (_ *domain.Product, err error) {
+	n, parseErr := parseID(id)
+	where, args, err := scoped(ctx, "id = ?", n)
+	if err != nil {
+		return nil, err
+	}
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	query := "SELECT id, tenant_id, name, description, price, stock FROM products" + where
+	ctx, span := startSpan(ctx, "GetProductById", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	var product domain.Product
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&product.ID, &product.TenantID, &product.Name, &product.Description, &product.Price, &product.Stock)
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// GetProductsByIds method. IDs that are not integers cannot match and are
+// skipped.
+func (r *SQLiteProductRepository) GetProductsByIds(ctx context.Context, ids []string) (_ []domain.Product, err error) {
+	args := make([]any, 0, len(ids))
+	for _, id := range ids {
+		if n, err := parseID(id); err == nil {
+			args = append(args, n)
+		}
+	}
+	if len(args) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.Repeat("?, ", len(args)-1) + "?"
+	where, args, err := scoped(ctx, "id IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT id, tenant_id, name, description, price, stock FROM products" + where + " ORDER BY id"
+	ctx, span := startSpan(ctx, "GetProductsByIds", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	return r.query(ctx, query, args...)
+}
+
+// SearchProducts returns the products matching filter in ID order.
+func (r *SQLiteProductRepository) SearchProducts(ctx context.Context, filter domain.ProductFilter, page domain.Page) (_ []domain.Product, err error) {
+	conditions, args, err := filtered(filter, page)
+	if err != nil {
+		return nil, err
+	}
+	where, args, err := scoped(ctx, conditions, args...)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT id, tenant_id, name, description, price, stock FROM products" + where + " ORDER BY id"
+	if page.Limit > 0 || page.Offset > 0 {
+		// A negative limit means none in SQLite.
+		limit := -1
+		if page.Limit > 0 {
+			limit = page.Limit
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, max(page.Offset, 0))
+	}
+	ctx, span := startSpan(ctx, "SearchProducts", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	return r.query(ctx, query, args...)
+}
+
+// likeEscaper escapes LIKE wildcards for patterns written with ESCAPE '!'.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// filtered turns a product filter and the start of a page into WHERE
+// conditions.
+func filtered(filter domain.ProductFilter, page domain.Page) (string, []any, error) {
+	var conditions []string
+	var args []any
+	if filter.NameContains != "" {
+		conditions = append(conditions, "LOWER(name) LIKE ? ESCAPE '!'")
+		args = append(args, "%"+likeEscaper.Replace(strings.ToLower(filter.NameContains))+"%")
+	}
+	if len(filter.Names) > 0 {
+		conditions = append(conditions, "name IN (?"+strings.Repeat(", ?", len(filter.Names)-1)+")")
+		for _, name := range filter.Names {
+			args = append(args, name)
+		}
+	}
+	if filter.MinPrice > 0 {
+		conditions = append(conditions, "price >= ?")
+		args = append(args, filter.MinPrice)
+	}
+	if filter.MaxPrice > 0 {
+		conditions = append(conditions, "price <= ?")
+		args = append(args, filter.MaxPrice)
+	}
+	if filter.InStock {
+		conditions = append(conditions, "stock > 0")
+	}
+	if page.After != "" {
+		after, err := parseID(page.After)
+		if err != nil {
+			return "", nil, domain.InvalidCursor(err)
+		}
+		conditions = append(conditions, "id > ?")
+		args = append(args, after)
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// query runs a SELECT of all product columns.
+func (r *SQLiteProductRepository) query(ctx context.Context, query string, args ...any) ([]domain.Product, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []domain.Product
+	for rows.Next() {
+		var product domain.Product
+		if err := rows.Scan(&product.ID, &product.TenantID, &product.Name, &product.Description, &product.Price, &product.Stock); err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	return products, rows.Err()
+}
+
+// UpdateProduct method. Like the MySQL repository, IDs that are not
+// integers report not found and updating an ID that does not exist is not
+// an error.
+func (r *SQLiteProductRepository) UpdateProduct(ctx context.Context, id string, product *domain.Product) (err error) {
+	n, parseErr := parseID(id)
+	where, args, err := scoped(ctx, "id = ?", n)
+	if err != nil {
+		return err
+	}
+	if parseErr != nil {
+		return parseErr
+	}
+	query := `
+		UPDATE products
+		SET name = ?, description = ?, price = ?, stock = ?` + where
+	ctx, span := startSpan(ctx, "UpdateProduct", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	args = append([]any{product.Name, product.Description, product.Price, product.Stock}, args...)
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// DeleteProduct method. IDs that are not integers report not found;
+// deleting an ID that does not exist is not an error.
+func (r *SQLiteProductRepository) DeleteProduct(ctx context.Context, id string) (err error) {
+	n, parseErr := parseID(id)
+	where, args, err := scoped(ctx, "id = ?", n)
+	if err != nil {
+		return err
+	}
+	if parseErr != nil {
+		return parseErr
+	}
+	query := "DELETE FROM products" + where
+	ctx, span := startSpan(ctx, "DeleteProduct", query)
+	defer func() {
+		err = translate(err)
+		tracing.End(span, err)
+	}()
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
@@ -0,0 +1,128 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"product-management/internal/domain"
+	"product-management/internal/repository/repositorytest"
+	"product-management/internal/repository/sqlite"
+	"product-management/internal/tenant"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRepo(t *testing.T) (*sqlite.SQLiteProductRepository, *sql.DB) {
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "produk.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	repo := sqlite.NewSQLiteProductRepository(db)
+	require.NoError(t, repo.EnsureSchema(context.Background(), "default"))
+	return repo, db
+}
+
+func TestOpenUsesWAL(t *testing.T) {
+	_, db := newRepo(t)
+
+	var mode string
+	require.NoError(t, db.QueryRow("PRAGMA journal_mode").Scan(&mode))
+	assert.Equal(t, "wal", mode)
+}
+
+func TestCRUD(t *testing.T) {
+	repo, _ := newRepo(t)
+	ctx := tenant.WithTenant(context.Background(), "shop-a")
+
+	product := &domain.Product{Name: "kecap", Description: "d", Price: 1.5, Stock: 1}
+	require.NoError(t, repo.Create(ctx, product))
+	require.NotEmpty(t, product.ID)
+
+	found, err := repo.GetProductById(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, *product, *found)
+
+	require.NoError(t, repo.UpdateProduct(ctx, product.ID, &domain.Product{Name: "kecap manis", Description: "d2", Price: 2, Stock: 3}))
+	found, err = repo.GetProductById(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "kecap manis", found.Name)
+	assert.Equal(t, 3, found.Stock)
+
+	require.NoError(t, repo.DeleteProduct(ctx, product.ID))
+	_, err = repo.GetProductById(ctx, product.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.GetProductById(ctx, "not-a-number")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestTenantIsolation(t *testing.T) {
+	repo, _ := newRepo(t)
+	shopA := tenant.WithTenant(context.Background(), "shop-a")
+	shopB := tenant.WithTenant(context.Background(), "shop-b")
+
+	kecap := &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1}
+	require.NoError(t, repo.Create(shopA, kecap))
+	require.NoError(t, repo.Create(shopB, &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1}))
+	assert.Error(t, repo.Create(shopA, &domain.Product{Name: "kecap", Description: "d", Price: 1, Stock: 1}))
+
+	_, err := repo.GetProductById(shopB, kecap.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	batch, err := repo.GetProductsByIds(shopB, []string{kecap.ID, "not-a-number"})
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+	batch, err = repo.GetProductsByIds(shopA, []string{kecap.ID, "999"})
+	require.NoError(t, err)
+	assert.Len(t, batch, 1)
+
+	require.NoError(t, repo.UpdateProduct(shopB, kecap.ID, &domain.Product{Name: "hijacked", Description: "x", Price: 9, Stock: 9}))
+	require.NoError(t, repo.DeleteProduct(shopB, kecap.ID))
+	product, err := repo.GetProductById(shopA, kecap.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "kecap", product.Name)
+
+	_, err = repo.GetAllProducts(context.Background())
+	assert.ErrorIs(t, err, tenant.ErrMissingTenant)
+}
+
+func TestConcurrentWriters(t *testing.T) {
+	repo, _ := newRepo(t)
+	ctx := tenant.WithTenant(context.Background(), "shop-a")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- repo.Create(ctx, &domain.Product{Name: fmt.Sprintf("product-%d", i), Description: "d", Price: 1, Stock: i})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	products, err := repo.GetAllProducts(ctx)
+	require.NoError(t, err)
+	assert.Len(t, products, 20)
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	_, db := newRepo(t)
+
+	applied, err := sqlite.Migrate(context.Background(), db)
+	require.NoError(t, err)
+	assert.Zero(t, applied)
+}
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repository {
+		repo, _ := newRepo(t)
+		return repo
+	})
+}
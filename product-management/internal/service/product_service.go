@@ -2,14 +2,45 @@
 package service
 
 import (
+	"context"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
+	"product-management/internal/auth"
+	"product-management/internal/config"
+	"product-management/internal/cutover"
 	"product-management/internal/domain"
+	"product-management/internal/events"
+	"product-management/internal/replication"
+	"product-management/internal/tenant"
+	"product-management/internal/tracing"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
 )
 
+var tracer = otel.Tracer("product-management/internal/service")
+
+// ProductService writes to two repository slots and reads from the first
+// that has a product. The slots are named after their default backends;
+// config.RepositoryConfig can fill either with PostgreSQL instead.
 type ProductService struct {
-	mysqlRepo domain.ProductRepository
-	mongoRepo domain.ProductRepository
+	mysqlRepo  domain.ProductRepository
+	mongoRepo  domain.ProductRepository
+	publishers []events.Publisher
+	replicator Replicator
+	shadow     ShadowReader
+	router     Router
+	ids        IDMap
+	list       config.ListConfig
+	// names are the backends in the slots, reported as list sources.
+	names [2]string
 }
 
 // func NewProductService(mysqlRepo, mongoRepo domain.ProductRepository) *ProductService {
@@ -19,82 +50,854 @@ type ProductService struct {
 // 	}
 // }
 
-func NewProductService(mysqlRepo, mongoRepo domain.ProductRepository) *ProductService {
+func NewProductService(mysqlRepo, mongoRepo domain.ProductRepository) (*ProductService, error) {
 	if mysqlRepo == nil {
-		log.Fatal("MySQL repository cannot be nil")
+		return nil, errors.New("MySQL repository cannot be nil")
 	}
 	if mongoRepo == nil {
-		log.Fatal("MongoDB repository cannot be nil")
+		return nil, errors.New("MongoDB repository cannot be nil")
 	}
 	return &ProductService{
 		mysqlRepo: mysqlRepo,
 		mongoRepo: mongoRepo,
+		names:     [2]string{config.BackendMySQL, config.BackendMongoDB},
+	}, nil
+}
+
+// SetListConfig sets how product lists read the slots. primary and
+// secondary name the backends in them; a slot named config.BackendNone is
+// not queried. It must be called before the service handles requests.
+func (s *ProductService) SetListConfig(cfg config.ListConfig, primary, secondary string) {
+	s.list = cfg
+	s.names = [2]string{primary, secondary}
+}
+
+// Backends returns the names of the backends in the primary and secondary
+// slots.
+func (s *ProductService) Backends() (primary, secondary string) {
+	return s.names[0], s.names[1]
+}
+
+// Replicator applies writes to the secondary slot after the primary has
+// committed them, by handing them back to ApplyToSecondary.
+type Replicator interface {
+	Enqueue(ctx context.Context, op, productID, name string, product *domain.Product) error
+}
+
+// SetReplicator makes writes return once the primary has committed them,
+// leaving the secondary to r. It must be called before the service handles
+// requests.
+func (s *ProductService) SetReplicator(r Replicator) {
+	s.replicator = r
+}
+
+// ShadowReader compares reads served by the primary slot with the
+// secondary's answers without delaying the caller.
+type ShadowReader interface {
+	Product(ctx context.Context, id string, primary *domain.Product)
+	Products(ctx context.Context, primary []domain.Product)
+}
+
+// SetShadowReader has lookups served by the primary repeated by r. It must
+// be called before the service handles requests.
+func (s *ProductService) SetShadowReader(r ShadowReader) {
+	s.shadow = r
+}
+
+// Router moves a share of the lookups to the secondary slot, such as while
+// migrating to it. Routed lookups read the secondary's copy by the ID
+// recorded when it was made; see SetIDMap.
+type Router interface {
+	// Secondary reports whether a read of productID goes to the secondary
+	// slot first.
+	Secondary(ctx context.Context, operation, productID string) bool
+	// Observe records the outcome of a read routed to the secondary.
+	Observe(ctx context.Context, operation string, err error)
+}
+
+// SetRouter has r pick the lookups served by the secondary's copy of a
+// product; the primary's copy is served when the secondary fails or has
+// none. It must be called before the service handles requests.
+func (s *ProductService) SetRouter(r Router) {
+	s.router = r
+}
+
+// IDMap records which product of the secondary slot is the copy of which
+// product of the primary; see idmap.Store.
+type IDMap interface {
+	Save(ctx context.Context, primaryID, secondaryID string) error
+	Lookup(ctx context.Context, primaryIDs []string) (map[string]string, error)
+	Delete(ctx context.Context, primaryID string) error
+}
+
+// SetIDMap records the copies the secondary makes in m, so later writes and
+// routed reads find a product's copy by the primary's ID. Without one, and for copies
+// made before it was set, copies are found by name. It must be called
+// before the service handles requests.
+func (s *ProductService) SetIDMap(m IDMap) {
+	s.ids = m
+}
+
+// AddPublisher registers a consumer of product events. It must be called
+// before the service handles requests.
+func (s *ProductService) AddPublisher(p events.Publisher) {
+	s.publishers = append(s.publishers, p)
+}
+
+// publish hands a committed change to every publisher.
+func (s *ProductService) publish(ctx context.Context, eventType, id string, before, after *domain.Product) {
+	if len(s.publishers) == 0 {
+		return
+	}
+	event := events.Event{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		ProductID:  id,
+		Actor:      auth.SubjectFromContext(ctx),
+		OccurredAt: time.Now().UTC(),
+		Before:     before,
+		After:      after,
+	}
+	event.TenantID, _ = tenant.FromContext(ctx)
+	for _, p := range s.publishers {
+		p.Publish(ctx, event)
+	}
+}
+
+// stored returns product id as the primary holds it or, for products only
+// the secondary has, as the secondary does, and reports whether it is the
+// primary's. Writes read the product once, through it rather than through
+// routed or shadowed reads, and publish it as the before state.
+func (s *ProductService) stored(ctx context.Context, id string) (*domain.Product, bool, error) {
+	product, err := s.mysqlRepo.GetProductById(ctx, id)
+	if err == nil && product != nil {
+		return product, true, nil
+	}
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, false, err
+	}
+	product, err = s.mongoRepo.GetProductById(ctx, id)
+	if err == nil && product == nil {
+		err = domain.ProductNotFound(nil)
+	}
+	if err != nil {
+		return nil, false, err
 	}
+	return product, false, nil
 }
 
-func (s *ProductService) CreateProduct(product *domain.Product) error {
+// errNoCopy is returned for products the secondary has no copy of. Routed
+// reads observe it, so a secondary missing data rolls the cutover back.
+var errNoCopy = errors.New("secondary has no copy of the product")
+
+// replicate hands a write committed to the primary's product id to the
+// replicator or, without one, applies it to the secondary before
+// returning. A missing copy only loses that write on the secondary.
+func (s *ProductService) replicate(ctx context.Context, op, id, name string, product *domain.Product) error {
+	if s.replicator != nil {
+		return s.replicator.Enqueue(ctx, op, id, name, product)
+	}
+	err := s.ApplyToSecondary(ctx, op, id, name, product)
+	if errors.Is(err, errNoCopy) {
+		slog.WarnContext(ctx, "Secondary has no copy of the product", "product_id", id, "op", op)
+		return nil
+	}
+	return err
+}
+
+// ApplyToSecondary writes a change committed to the primary's product
+// primaryID to the secondary's copy; writes and the replicator share it.
+// name is the product's name before the change and product its state after
+// it, nil for deletes. Creates make a copy with an ID of the secondary's own
+// and record it; updates and deletes fail when the secondary has no copy.
+func (s *ProductService) ApplyToSecondary(ctx context.Context, op, primaryID, name string, product *domain.Product) error {
+	if s.names[1] == config.BackendNone {
+		return nil
+	}
+	switch op {
+	case replication.OpCreate:
+		copied := secondaryCopy(product)
+		err := s.mongoRepo.Create(ctx, copied)
+		if errors.Is(err, domain.ErrConflict) {
+			return s.adoptCopy(ctx, primaryID, product, err)
+		}
+		if err != nil {
+			return err
+		}
+		s.recordCopy(ctx, primaryID, copied.Key())
+		return nil
+	case replication.OpUpdate:
+		secondaryID, err := s.copyOf(ctx, primaryID, name)
+		if err != nil {
+			return err
+		}
+		err = s.mongoRepo.UpdateProduct(ctx, secondaryID, secondaryCopy(product))
+		if errors.Is(err, domain.ErrNotFound) {
+			// The secondary rejects IDs it could not have generated, such as
+			// one recorded for another backend.
+			return errNoCopy
+		}
+		return err
+	case replication.OpDelete:
+		secondaryID, err := s.copyOf(ctx, primaryID, name)
+		if err != nil {
+			return err
+		}
+		// A copy deleted since it was found is as good as deleted by us.
+		if err := s.mongoRepo.DeleteProduct(ctx, secondaryID); err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+		if s.ids != nil {
+			if err := s.ids.Delete(ctx, primaryID); err != nil {
+				slog.WarnContext(ctx, "Failed to forget the secondary's copy", "product_id", primaryID, "error", err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown replication op %q", op)
+}
+
+// secondaryCopy returns the fields of product the secondary stores, leaving
+// its IDs to the secondary.
+func secondaryCopy(product *domain.Product) *domain.Product {
+	return &domain.Product{
+		TenantID:    product.TenantID,
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		Stock:       product.Stock,
+	}
+}
+
+// copyOf returns the ID of the secondary's copy of the primary's product
+// primaryID: the recorded one, or else that of the copy named name, which
+// is recorded for next time.
+func (s *ProductService) copyOf(ctx context.Context, primaryID, name string) (string, error) {
+	if s.ids != nil {
+		ids, err := s.ids.Lookup(ctx, []string{primaryID})
+		if err != nil {
+			return "", err
+		}
+		if id, ok := ids[primaryID]; ok {
+			return id, nil
+		}
+	}
+	copied, err := s.named(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if copied == nil {
+		return "", errNoCopy
+	}
+	s.recordCopy(ctx, primaryID, copied.Key())
+	return copied.Key(), nil
+}
+
+// adoptCopy settles a create the secondary refused with conflict because it
+// has a product of that name. An earlier attempt may have made the copy
+// without being recorded as applied, so that product is taken as the copy
+// when its ID is the one recorded for primaryID or, with none recorded, it
+// holds what was created. Any other product keeps the conflict.
+func (s *ProductService) adoptCopy(ctx context.Context, primaryID string, product *domain.Product, conflict error) error {
+	existing, err := s.named(ctx, product.Name)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return conflict
+	}
+	if s.ids != nil {
+		ids, err := s.ids.Lookup(ctx, []string{primaryID})
+		if err != nil {
+			return err
+		}
+		if recorded, ok := ids[primaryID]; ok {
+			if recorded == existing.Key() {
+				return nil
+			}
+			return domain.NewError(domain.ErrConflict, "The secondary has another product with this name", conflict)
+		}
+	}
+	if existing.Description != product.Description || existing.Price != product.Price || existing.Stock != product.Stock {
+		return domain.NewError(domain.ErrConflict, "The secondary has another product with this name", conflict)
+	}
+	s.recordCopy(ctx, primaryID, existing.Key())
+	return nil
+}
+
+// named returns the secondary's product called name, or nil.
+func (s *ProductService) named(ctx context.Context, name string) (*domain.Product, error) {
+	searcher, ok := s.mongoRepo.(domain.ProductSearcher)
+	if !ok {
+		return nil, nil
+	}
+	return domain.FindByName(ctx, searcher, name)
+}
+
+// recordCopy records secondaryID as the copy of primaryID. A failure is
+// only logged, since the copy is still found by name.
+func (s *ProductService) recordCopy(ctx context.Context, primaryID, secondaryID string) {
+	if s.ids == nil {
+		return
+	}
+	if err := s.ids.Save(ctx, primaryID, secondaryID); err != nil {
+		slog.WarnContext(ctx, "Failed to record the secondary's copy", "product_id", primaryID, "copy_id", secondaryID, "error", err)
+	}
+}
+
+// audit records a successful mutation together with the caller.
+func (s *ProductService) audit(ctx context.Context, action, id string) {
+	principal := auth.PrincipalFromContext(ctx)
+	attrs := []any{"audit", true, "action", action, "product_id", id, "actor", auth.SubjectFromContext(ctx)}
+	if principal != nil {
+		attrs = append(attrs, "role", string(principal.Role), "auth_method", principal.Method)
+	}
+	slog.InfoContext(ctx, "Product "+action, attrs...)
+}
+
+func (s *ProductService) CreateProduct(ctx context.Context, product *domain.Product) (err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.CreateProduct")
+	defer func() { tracing.End(span, err) }()
+
 	if product == nil {
 		return errors.New("product cannot be nil")
 	}
-	err := s.mysqlRepo.Create(product)
+	if err = product.Validate(); err != nil {
+		return err
+	}
+	err = s.mysqlRepo.Create(ctx, product)
 	if err != nil {
 		return err
 	}
-	return s.mongoRepo.Create(product)
+	// product keeps the primary's ID; the secondary gets a copy.
+	id := product.Key()
+	if err = s.replicate(ctx, replication.OpCreate, id, product.Name, product); err != nil {
+		return err
+	}
+	s.audit(ctx, "created", id)
+	created := *product
+	s.publish(ctx, events.ProductCreated, id, nil, &created)
+	return nil
+}
+
+// Statuses of a backend in the sources of a product list.
+const (
+	SourceOK      = "ok"
+	SourceFailed  = "failed"
+	SourceTimeout = "timeout"
+)
+
+// Source is how one backend answered a product list.
+type Source struct {
+	Backend string `json:"backend"`
+	Status  string `json:"status"`
+	Count   int    `json:"count"`
+}
+
+// String renders the source as "mysql=ok;count=3", the form used in
+// response headers.
+func (s Source) String() string {
+	return s.Backend + "=" + s.Status + ";count=" + strconv.Itoa(s.Count)
 }
 
-func (s *ProductService) GetAllProducts() ([]domain.Product, error) {
-	mysqlProducts, err := s.mysqlRepo.GetAllProducts()
+// ProductList is the products of both slots and the backends they came
+// from.
+type ProductList struct {
+	Products []domain.Product
+	Sources  []Source
+	// Partial is set when a backend failed and its products are missing.
+	Partial bool
+}
+
+// GetAllProducts returns the products of both slots; see ListProducts.
+func (s *ProductService) GetAllProducts(ctx context.Context) ([]domain.Product, error) {
+	list, err := s.ListProducts(ctx)
 	if err != nil {
-		log.Printf("Error getting MySQL products: %v", err)
 		return nil, err
 	}
+	return list.Products, nil
+}
 
-	mongoProducts, err := s.mongoRepo.GetAllProducts()
-	if err != nil {
-		log.Printf("Error getting MongoDB products: %v", err)
+// ListProducts queries both slots concurrently, each within the backend
+// timeout, and returns the primary's products followed by the secondary's.
+// A failing slot fails the list unless partial results are allowed, in
+// which case the list only fails when no slot answered.
+func (s *ProductService) ListProducts(ctx context.Context) (_ *ProductList, err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.ListProducts")
+	defer func() { tracing.End(span, err) }()
+
+	repos := [2]domain.ProductRepository{s.mysqlRepo, s.mongoRepo}
+	var (
+		sources  [2]Source
+		products [2][]domain.Product
+		errs     [2]error
+	)
+	// Without partial results the first failure cancels the other query.
+	g, gctx := errgroup.WithContext(ctx)
+	if s.list.AllowPartial {
+		g, gctx = new(errgroup.Group), ctx
+	}
+	for i, repo := range repos {
+		if s.names[i] == config.BackendNone {
+			continue
+		}
+		g.Go(func() error {
+			sources[i], products[i], errs[i] = s.listSlot(gctx, s.names[i], repo)
+			if s.list.AllowPartial {
+				return nil
+			}
+			return errs[i]
+		})
+	}
+	if err = g.Wait(); err != nil {
 		return nil, err
 	}
 
-	combinedProducts := append(mysqlProducts, mongoProducts...)
-	return combinedProducts, nil
+	list := &ProductList{}
+	var firstErr error
+	answered := 0
+	for i := range repos {
+		if s.names[i] == config.BackendNone {
+			continue
+		}
+		list.Sources = append(list.Sources, sources[i])
+		if errs[i] != nil {
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+			continue
+		}
+		answered++
+		list.Products = append(list.Products, products[i]...)
+	}
+	if answered == 0 {
+		return nil, firstErr
+	}
+	list.Partial = firstErr != nil
+	span.SetAttributes(attribute.Int("product.count", len(list.Products)), attribute.Bool("product.partial", list.Partial))
+	if list.Partial {
+		slog.WarnContext(ctx, "Answering with a partial product list", "sources", list.Sources)
+	}
+	return list, nil
+}
+
+// listSlot lists the products of one slot within the backend timeout.
+func (s *ProductService) listSlot(ctx context.Context, backend string, repo domain.ProductRepository) (Source, []domain.Product, error) {
+	var products []domain.Product
+	source, err := s.inSlot(ctx, backend, func(ctx context.Context) (err error) {
+		products, err = repo.GetAllProducts(ctx)
+		return err
+	})
+	source.Count = len(products)
+	return source, products, err
+}
+
+// inSlot runs query against one slot within the backend timeout and
+// reports how the backend answered.
+func (s *ProductService) inSlot(ctx context.Context, backend string, query func(ctx context.Context) error) (Source, error) {
+	if s.list.BackendTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.list.BackendTimeout)
+		defer cancel()
+	}
+	err := query(ctx)
+	switch {
+	case err == nil:
+		return Source{Backend: backend, Status: SourceOK}, nil
+	case errors.Is(err, domain.ErrValidation):
+		// The request is at fault, not the backend.
+		return Source{Backend: backend, Status: SourceFailed}, err
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		slog.ErrorContext(ctx, "Timed out getting products", "backend", backend, "error", err)
+		return Source{Backend: backend, Status: SourceTimeout},
+			domain.NewError(domain.ErrUnavailable, "A product backend timed out", err)
+	case errors.Is(ctx.Err(), context.Canceled):
+		// The other slot failed first, or the client went away.
+		return Source{Backend: backend, Status: SourceFailed}, err
+	}
+	slog.ErrorContext(ctx, "Error getting products", "backend", backend, "error", err)
+	return Source{Backend: backend, Status: SourceFailed}, err
 }
-func (s *ProductService) GetProductById(id string) (*domain.Product, error) {
+
+// Position is where a product page ends: the backend of its last product
+// and that product's ID there.
+type Position struct {
+	Backend string
+	ID      string
+}
+
+// ProductPage is a window of the products ListProducts would return.
+type ProductPage struct {
+	Products []SourcedProduct
+	// Total counts the products of the paged backends.
+	Total   int
+	HasNext bool
+	// Sources reports how each backend answered when both were paged.
+	Sources []Source
+	// Partial is set when a backend failed and its products are missing.
+	Partial bool
+}
+
+// PageProducts returns up to limit products after the position after, in
+// the order of ListProducts: the primary's products by ID, then the
+// secondary's. backend restricts the page to the slot holding that backend;
+// empty pages both. Each backend is searched for one page and counted
+// rather than listed, and a failing backend is handled as in ListProducts.
+func (s *ProductService) PageProducts(ctx context.Context, backend string, after Position, limit int) (_ *ProductPage, err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.PageProducts", trace.WithAttributes(attribute.String("product.backend", backend)))
+	defer func() { tracing.End(span, err) }()
+
+	var slots []int
+	for i, name := range s.names {
+		if name != config.BackendNone && (backend == "" || backend == name) {
+			slots = append(slots, i)
+		}
+	}
+	if len(slots) == 0 {
+		return nil, domain.NewError(domain.ErrValidation, "Product source "+backend+" is not configured", nil)
+	}
+	start := 0
+	if after.Backend != "" {
+		start = slices.IndexFunc(slots, func(i int) bool { return s.names[i] == after.Backend })
+		if start < 0 {
+			return nil, domain.InvalidCursor(nil)
+		}
+	}
+
+	page := &ProductPage{}
+	var firstErr error
+	answered := 0
+	// One product more than asked for tells whether there is a next page.
+	need := limit + 1
+	for n, i := range slots {
+		afterID, take := "", need
+		switch {
+		case n < start:
+			take = 0
+		case n == start:
+			afterID = after.ID
+		}
+		source, products, err := s.pageSlot(ctx, i, afterID, take)
+		if err != nil && (!s.list.AllowPartial || errors.Is(err, domain.ErrValidation)) {
+			return nil, err
+		}
+		page.Sources = append(page.Sources, source)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		answered++
+		page.Total += source.Count
+		need -= len(products)
+		for j := range products {
+			page.Products = append(page.Products, SourcedProduct{Product: &products[j], Backend: s.names[i]})
+		}
+	}
+	if answered == 0 {
+		return nil, firstErr
+	}
+	if len(page.Products) > limit {
+		page.Products, page.HasNext = page.Products[:limit], true
+	}
+	if backend != "" {
+		page.Sources = nil
+	}
+	page.Partial = firstErr != nil
+	span.SetAttributes(attribute.Int("product.count", len(page.Products)), attribute.Bool("product.partial", page.Partial))
+	if page.Partial {
+		slog.WarnContext(ctx, "Answering with a partial product page", "sources", page.Sources)
+	}
+	return page, nil
+}
+
+// pageSlot counts the products of slot i and searches up to limit of them
+// after the ID after, within the backend timeout. A zero limit only counts.
+func (s *ProductService) pageSlot(ctx context.Context, i int, after string, limit int) (Source, []domain.Product, error) {
+	repo := [2]domain.ProductRepository{s.mysqlRepo, s.mongoRepo}[i]
+	searcher, canSearch := repo.(domain.ProductSearcher)
+	counter, canCount := repo.(domain.ProductCounter)
+	if !canSearch || !canCount {
+		return Source{Backend: s.names[i], Status: SourceFailed}, nil, errors.New("repository " + s.names[i] + " cannot page products")
+	}
+	var (
+		stats    domain.ProductStats
+		products []domain.Product
+	)
+	source, err := s.inSlot(ctx, s.names[i], func(ctx context.Context) (err error) {
+		if stats, err = counter.CountProducts(ctx); err != nil || limit == 0 {
+			return err
+		}
+		products, err = searcher.SearchProducts(ctx, domain.ProductFilter{}, domain.Page{After: after, Limit: limit})
+		return err
+	})
+	source.Count = stats.Count
+	return source, products, err
+}
+
+func (s *ProductService) GetProductById(ctx context.Context, id string) (_ *domain.Product, err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetProductById", trace.WithAttributes(attribute.String("product.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	routed := s.router != nil && s.router.Secondary(ctx, cutover.OpGetByID, id)
+	if routed {
+		span.SetAttributes(attribute.Bool("product.routed", true))
+		if copied := s.routedCopy(ctx, id); copied != nil {
+			return copied, nil
+		}
+	}
+
 	// Coba ambil dari MySQL
-	product, err := s.mysqlRepo.GetProductById(id)
-	if err == nil { // Jika berhasil, kembalikan produk
+	product, primaryErr := s.mysqlRepo.GetProductById(ctx, id)
+	if primaryErr == nil && product != nil { // Jika berhasil, kembalikan produk
+		if s.shadow != nil && !routed {
+			s.shadow.Product(ctx, id, product)
+		}
 		return product, nil
 	}
 
 	// Jika tidak ditemukan di MySQL, coba ambil dari MongoDB
-	product, err = s.mongoRepo.GetProductById(id)
+	span.AddEvent("falling back to MongoDB")
+	slog.DebugContext(ctx, "Product not found in MySQL, trying MongoDB", "product_id", id, "error", primaryErr)
+	product, err = s.mongoRepo.GetProductById(ctx, id)
+	if err == nil && product == nil {
+		err = domain.ProductNotFound(nil)
+	}
 	if err != nil {
+		// A failing MySQL says more than MongoDB not having the product.
+		if primaryErr != nil && !errors.Is(primaryErr, domain.ErrNotFound) && errors.Is(err, domain.ErrNotFound) {
+			return nil, primaryErr
+		}
 		return nil, err // Jika masih tidak ditemukan, kembalikan error
 	}
 	return product, nil
 }
 
-func (s *ProductService) UpdateProduct(id string, product *domain.Product) error {
-	err := s.mysqlRepo.UpdateProduct(id, product) // Update in MySQL
+// SourcedProduct is a product and the backend it was read from.
+type SourcedProduct struct {
+	Product *domain.Product
+	Backend string
+}
+
+// GetProductsByIds looks up many products with one query per backend; see
+// LookupProducts.
+func (s *ProductService) GetProductsByIds(ctx context.Context, ids []string) (map[string]*domain.Product, error) {
+	sourced, err := s.LookupProducts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]*domain.Product, len(sourced))
+	for id, p := range sourced {
+		found[id] = p.Product
+	}
+	return found, nil
+}
+
+// LookupProducts looks up many products with one query per backend. IDs
+// missing from MySQL are looked up in MongoDB; products are keyed by the
+// requested ID and IDs found in neither backend are absent from the map.
+// Documents are requested by ObjectID, rows by their ID. With a router, the
+// products it routes are read from MongoDB's copies first; see
+// routedCopies.
+func (s *ProductService) LookupProducts(ctx context.Context, ids []string) (_ map[string]SourcedProduct, err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetProductsByIds", trace.WithAttributes(attribute.Int("product.id_count", len(ids))))
+	defer func() { tracing.End(span, err) }()
+
+	found := make(map[string]SourcedProduct, len(ids))
+	if s.router != nil {
+		var routed []string
+		for _, id := range ids {
+			if s.router.Secondary(ctx, cutover.OpGetByIDs, id) {
+				routed = append(routed, id)
+			}
+		}
+		span.SetAttributes(attribute.Int("product.routed_count", len(routed)))
+		for id, copied := range s.routedCopies(ctx, routed) {
+			found[id] = SourcedProduct{Product: copied, Backend: s.names[1]}
+		}
+	}
+
+	primaryIDs := ids
+	if len(found) > 0 {
+		primaryIDs = missingFrom(found, ids)
+	}
+	if len(primaryIDs) > 0 {
+		mysqlProducts, err := s.mysqlRepo.GetProductsByIds(ctx, primaryIDs)
+		if err != nil {
+			return nil, err
+		}
+		for i := range mysqlProducts {
+			found[mysqlProducts[i].Key()] = SourcedProduct{Product: &mysqlProducts[i], Backend: s.names[0]}
+		}
+		if s.shadow != nil {
+			s.shadow.Products(ctx, mysqlProducts)
+		}
+	}
+
+	missing := missingFrom(found, ids)
+	if len(missing) == 0 {
+		return found, nil
+	}
+	mongoProducts, err := s.mongoRepo.GetProductsByIds(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for i := range mongoProducts {
+		found[mongoProducts[i].Key()] = SourcedProduct{Product: &mongoProducts[i], Backend: s.names[1]}
+	}
+	return found, nil
+}
+
+// missingFrom returns the ids absent from found.
+func missingFrom(found map[string]SourcedProduct, ids []string) []string {
+	var missing []string
+	for _, id := range ids {
+		if _, ok := found[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// recordedCopies returns the IDs of the secondary's copies of routed
+// products. Products without a recorded copy are served by the primary and
+// not observed, since the secondary may hold them under an ID nobody
+// recorded.
+func (s *ProductService) recordedCopies(ctx context.Context, operation string, ids []string) map[string]string {
+	if s.ids == nil || len(ids) == 0 {
+		return nil
+	}
+	recorded, err := s.ids.Lookup(ctx, ids)
+	if err != nil {
+		slog.WarnContext(ctx, "Routed reads served by MySQL; the copies are unknown", "operation", operation, "error", err)
+		return nil
+	}
+	return recorded
+}
+
+// routedCopy returns the secondary's copy of the routed product id, read by
+// its recorded ID, and reports the outcome to the router. It returns nil
+// when the caller should be served the primary's copy instead.
+func (s *ProductService) routedCopy(ctx context.Context, id string) *domain.Product {
+	secondaryID, ok := s.recordedCopies(ctx, cutover.OpGetByID, []string{id})[id]
+	if !ok {
+		return nil
+	}
+	copied, err := s.mongoRepo.GetProductById(ctx, secondaryID)
+	if errors.Is(err, domain.ErrNotFound) || err == nil && copied == nil {
+		err = errNoCopy
+	}
+	s.router.Observe(ctx, cutover.OpGetByID, err)
+	if err != nil {
+		slog.WarnContext(ctx, "Routed read served by MySQL", "product_id", id, "operation", cutover.OpGetByID, "error", err)
+		return nil
+	}
+	return copied
+}
+
+// routedCopies returns the secondary's copies of the routed products ids
+// keyed by the requested ID, read by their recorded IDs with one query, and
+// reports each outcome to the router. Products it has no copy for are left
+// to the primary.
+func (s *ProductService) routedCopies(ctx context.Context, ids []string) map[string]*domain.Product {
+	recorded := s.recordedCopies(ctx, cutover.OpGetByIDs, ids)
+	if len(recorded) == 0 {
+		return nil
+	}
+	secondaryIDs := make([]string, 0, len(recorded))
+	for _, secondaryID := range recorded {
+		secondaryIDs = append(secondaryIDs, secondaryID)
+	}
+	copies, readErr := s.mongoRepo.GetProductsByIds(ctx, secondaryIDs)
+	bySecondaryID := make(map[string]*domain.Product, len(copies))
+	for i := range copies {
+		bySecondaryID[copies[i].Key()] = &copies[i]
+	}
+
+	found := make(map[string]*domain.Product, len(recorded))
+	for id, secondaryID := range recorded {
+		err := readErr
+		if err == nil {
+			if copied, ok := bySecondaryID[secondaryID]; ok {
+				found[id] = copied
+			} else {
+				err = errNoCopy
+			}
+		}
+		s.router.Observe(ctx, cutover.OpGetByIDs, err)
+		if err != nil {
+			slog.WarnContext(ctx, "Routed read served by MySQL", "product_id", id, "operation", cutover.OpGetByIDs, "error", err)
+		}
+	}
+	return found
+}
+
+func (s *ProductService) UpdateProduct(ctx context.Context, id string, product *domain.Product) (err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.UpdateProduct", trace.WithAttributes(attribute.String("product.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if err = product.Validate(); err != nil {
+		return err
+	}
+	before, inPrimary, err := s.stored(ctx, id)
 	if err != nil {
 		return err
 	}
-	return s.mongoRepo.UpdateProduct(id, product) // Update in MongoDB
+	if inPrimary {
+		err = s.mysqlRepo.UpdateProduct(ctx, id, product) // Update in MySQL
+		if err == nil {
+			err = s.replicate(ctx, replication.OpUpdate, id, before.Name, product)
+		}
+	} else {
+		// Products only MongoDB has are updated there alone.
+		err = s.mongoRepo.UpdateProduct(ctx, id, product)
+	}
+	if err != nil {
+		return err
+	}
+	s.audit(ctx, "updated", id)
+	after := *product
+	after.ID, after.MongoID, after.TenantID = before.ID, before.MongoID, before.TenantID
+	s.publish(ctx, events.ProductUpdated, id, before, &after)
+	return nil
 }
 
-func (s *ProductService) DeleteProduct(id string) error {
-	// Hapus dari MySQL
-	err := s.mysqlRepo.DeleteProduct(id)
+func (s *ProductService) DeleteProduct(ctx context.Context, id string) (err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.DeleteProduct", trace.WithAttributes(attribute.String("product.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	before, inPrimary, err := s.stored(ctx, id)
 	if err != nil {
 		return err
 	}
-	// Hapus dari MongoDB
-	return s.mongoRepo.DeleteProduct(id)
+	if inPrimary {
+		// Hapus dari MySQL, lalu salinannya di MongoDB
+		err = s.mysqlRepo.DeleteProduct(ctx, id)
+		if err == nil {
+			err = s.replicate(ctx, replication.OpDelete, id, before.Name, nil)
+		}
+	} else {
+		// Produk yang hanya ada di MongoDB dihapus di sana saja
+		err = s.mongoRepo.DeleteProduct(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+	s.audit(ctx, "deleted", id)
+	s.publish(ctx, events.ProductDeleted, id, before, nil)
+	return nil
 }
-func (s *ProductService) GetMySQLProducts() ([]domain.Product, error) {
-	return s.mysqlRepo.GetAllProducts()
+func (s *ProductService) GetMySQLProducts(ctx context.Context) (_ []domain.Product, err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetMySQLProducts")
+	defer func() { tracing.End(span, err) }()
+
+	return s.mysqlRepo.GetAllProducts(ctx)
 }
 
-func (s *ProductService) GetMongoDBProducts() ([]domain.Product, error) {
-	return s.mongoRepo.GetAllProducts()
+func (s *ProductService) GetMongoDBProducts(ctx context.Context) (_ []domain.Product, err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetMongoDBProducts")
+	defer func() { tracing.End(span, err) }()
+
+	return s.mongoRepo.GetAllProducts(ctx)
 }
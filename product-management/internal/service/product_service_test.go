@@ -1,142 +1,455 @@
 package service_test
 
 import (
+	"context"
+	"errors"
+	"product-management/internal/config"
 	"product-management/internal/domain"
+	"product-management/internal/events"
+	"product-management/internal/idmap"
+	"product-management/internal/repository/memory"
 	"product-management/internal/service"
+	"product-management/internal/tenant"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
 )
 
-// Mock untuk MySQL dan MongoDB Repository
-type MockProductRepository struct {
-	mock.Mock
+// newService returns a service over two empty memory repositories and a
+// context of one tenant.
+func newService(t *testing.T) (*service.ProductService, *memory.MemoryProductRepository, *memory.MemoryProductRepository, context.Context) {
+	primary, secondary := memory.NewMemoryProductRepository(), memory.NewMemoryProductRepository()
+	productService, err := service.NewProductService(primary, secondary)
+	assert.NoError(t, err)
+	return productService, primary, secondary, tenant.WithTenant(context.Background(), "shop-a")
 }
 
-func (m *MockProductRepository) Create(product *domain.Product) error {
-	args := m.Called(product)
-	return args.Error(0)
+// stored returns the product called name in repo, or nil.
+func stored(t *testing.T, ctx context.Context, repo *memory.MemoryProductRepository, name string) *domain.Product {
+	product, err := domain.FindByName(ctx, repo, name)
+	assert.NoError(t, err)
+	return product
 }
 
-func (m *MockProductRepository) GetAllProducts() ([]domain.Product, error) {
-	args := m.Called()
-	return args.Get(0).([]domain.Product), args.Error(1)
+func TestCreateProduct(t *testing.T) {
+	productService, primary, secondary, ctx := newService(t)
+
+	product := &domain.Product{
+		Name:        "Test Product",
+		Description: "Test Description",
+		Price:       100.0,
+		Stock:       10,
+	}
+	err := productService.CreateProduct(ctx, product)
+
+	assert.NoError(t, err)
+	for _, repo := range []*memory.MemoryProductRepository{primary, secondary} {
+		if copied := stored(t, ctx, repo, "Test Product"); assert.NotNil(t, copied) {
+			assert.Equal(t, 10, copied.Stock)
+		}
+	}
 }
 
-func (m *MockProductRepository) GetProductById(id string) (*domain.Product, error) {
-	args := m.Called(id)
-	return args.Get(0).(*domain.Product), args.Error(1)
+func TestGetAllProducts(t *testing.T) {
+	productService, primary, secondary, ctx := newService(t)
+	assert.NoError(t, primary.Create(ctx, &domain.Product{Name: "Product 1", Description: "Desc 1", Price: 10.0, Stock: 10}))
+	assert.NoError(t, secondary.Create(ctx, &domain.Product{Name: "Product 2", Description: "Desc 2", Price: 20.0, Stock: 5}))
+
+	result, err := productService.GetAllProducts(ctx)
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
 }
 
-func (m *MockProductRepository) UpdateProduct(id string, product *domain.Product) error {
-	args := m.Called(id, product)
-	return args.Error(0)
+func TestGetProductById(t *testing.T) {
+	productService, primary, _, ctx := newService(t)
+	product := &domain.Product{Name: "Test Product", Description: "Test Description", Price: 100.0, Stock: 10}
+	assert.NoError(t, primary.Create(ctx, product))
+
+	result, err := productService.GetProductById(ctx, product.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, product, result)
 }
 
-func (m *MockProductRepository) DeleteProduct(id string) error {
-	args := m.Called(id)
-	return args.Error(0)
+func TestGetProductsByIdsFallsBackToMongoDB(t *testing.T) {
+	productService, primary, secondary, ctx := newService(t)
+	assert.NoError(t, primary.Create(ctx, &domain.Product{Name: "kecap", Description: "d", Price: 1}))
+	// The secondary's IDs run ahead, so its own product is not shadowed.
+	assert.NoError(t, secondary.Create(ctx, &domain.Product{Name: "garam", Description: "d", Price: 1}))
+	assert.NoError(t, secondary.Create(ctx, &domain.Product{Name: "sambal", Description: "d", Price: 1}))
+
+	result, err := productService.GetProductsByIds(ctx, []string{"1", "2", "9"})
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "kecap", result["1"].Name)
+	assert.Equal(t, "sambal", result["2"].Name)
 }
 
-func TestCreateProduct(t *testing.T) {
-	mockMySQLRepo := new(MockProductRepository)
-	mockMongoRepo := new(MockProductRepository)
-	productService := service.NewProductService(mockMySQLRepo, mockMongoRepo)
+func TestUpdateProduct(t *testing.T) {
+	productService, primary, secondary, ctx := newService(t)
+	product := &domain.Product{Name: "Product", Description: "Description", Price: 100.0, Stock: 10}
+	assert.NoError(t, productService.CreateProduct(ctx, product))
 
-	product := &domain.Product{
-		Name:        "Test Product",
-		Description: "Test Description",
-		Price:       100.0,
-		Stock:       10,
+	update := &domain.Product{Name: "Updated Product", Description: "Updated Description", Price: 150.0, Stock: 8}
+	err := productService.UpdateProduct(ctx, product.ID, update)
+
+	assert.NoError(t, err)
+	for _, repo := range []*memory.MemoryProductRepository{primary, secondary} {
+		if updated := stored(t, ctx, repo, "Updated Product"); assert.NotNil(t, updated) {
+			assert.Equal(t, 8, updated.Stock)
+		}
 	}
+}
 
-	// Setup mocks untuk mengembalikan tidak ada error saat Create dipanggil
-	mockMySQLRepo.On("Create", product).Return(nil)
-	mockMongoRepo.On("Create", product).Return(nil)
+func TestDeleteProduct(t *testing.T) {
+	productService, primary, secondary, ctx := newService(t)
+	product := &domain.Product{Name: "Product", Description: "Description", Price: 100.0, Stock: 10}
+	assert.NoError(t, productService.CreateProduct(ctx, product))
 
-	err := productService.CreateProduct(product)
+	err := productService.DeleteProduct(ctx, product.ID)
 
-	// Verifikasi hasil
 	assert.NoError(t, err)
-	mockMySQLRepo.AssertExpectations(t)
-	mockMongoRepo.AssertExpectations(t)
+	assert.Nil(t, stored(t, ctx, primary, "Product"))
+	assert.Nil(t, stored(t, ctx, secondary, "Product"))
 }
 
-func TestGetAllProducts(t *testing.T) {
-	mockMySQLRepo := new(MockProductRepository)
-	mockMongoRepo := new(MockProductRepository)
-	productService := service.NewProductService(mockMySQLRepo, mockMongoRepo)
+func TestWritesFollowTheRecordedCopy(t *testing.T) {
+	productService, primary, secondary, ctx := newService(t)
+	productService.SetIDMap(idmap.NewMemoryStore())
+	// The secondary's IDs run ahead, so the copy gets an ID of its own.
+	assert.NoError(t, secondary.Create(ctx, &domain.Product{Name: "garam", Description: "d", Price: 1}))
+
+	product := &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 3}
+	assert.NoError(t, productService.CreateProduct(ctx, product))
+	assert.Equal(t, "1", product.ID, "the primary's ID is returned")
+	copied := stored(t, ctx, secondary, "kecap")
+	if !assert.NotNil(t, copied) {
+		return
+	}
+	assert.Equal(t, "2", copied.ID)
+
+	// The recorded ID finds the copy even once its name no longer matches.
+	assert.NoError(t, secondary.UpdateProduct(ctx, "2", &domain.Product{Name: "kecap lama", Description: "manis", Price: 12000, Stock: 3}))
+	assert.NoError(t, productService.UpdateProduct(ctx, "1", &domain.Product{Name: "kecap asin", Description: "asin", Price: 9000, Stock: 5}))
+	updated, err := secondary.GetProductById(ctx, "2")
+	assert.NoError(t, err)
+	assert.Equal(t, "kecap asin", updated.Name)
+	assert.Equal(t, 5, updated.Stock)
+
+	assert.NoError(t, productService.DeleteProduct(ctx, "1"))
+	_, err = secondary.GetProductById(ctx, "2")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = primary.GetProductById(ctx, "1")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.NotNil(t, stored(t, ctx, secondary, "garam"))
+}
+
+func TestNewProductServiceRejectsNilRepository(t *testing.T) {
+	_, err := service.NewProductService(nil, memory.NewMemoryProductRepository())
+	assert.Error(t, err)
 
-	mysqlProducts := []domain.Product{
-		{Name: "Product 1", Description: "Desc 1", Price: 10.0, Stock: 10},
+	_, err = service.NewProductService(memory.NewMemoryProductRepository(), nil)
+	assert.Error(t, err)
+}
+
+func TestReadsFallBackToSecondaryWithMemoryRepositories(t *testing.T) {
+	productService, primary, secondary, ctx := newService(t)
+	productService.SetIDMap(idmap.NewMemoryStore())
+	// The secondary's IDs run ahead, so each copy has an ID of its own.
+	assert.NoError(t, secondary.Create(ctx, &domain.Product{Name: "garam", Description: "halus", Price: 3000, Stock: 9}))
+
+	kecap := &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 3}
+	assert.NoError(t, productService.CreateProduct(ctx, kecap))
+	assert.Equal(t, "1", kecap.ID, "the primary's ID is returned")
+	all, err := productService.GetAllProducts(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	assert.NoError(t, productService.UpdateProduct(ctx, kecap.ID, &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 5}))
+	for _, repo := range []*memory.MemoryProductRepository{primary, secondary} {
+		if updated := stored(t, ctx, repo, "kecap"); assert.NotNil(t, updated) {
+			assert.Equal(t, 5, updated.Stock)
+		}
 	}
-	mongoProducts := []domain.Product{
-		{Name: "Product 2", Description: "Desc 2", Price: 20.0, Stock: 5},
+	assert.Equal(t, "2", stored(t, ctx, secondary, "kecap").ID)
+
+	// Lose the primary copy of sambal; the secondary still answers under
+	// its own ID.
+	sambal := &domain.Product{Name: "sambal", Description: "pedas", Price: 9000, Stock: 4}
+	assert.NoError(t, productService.CreateProduct(ctx, sambal))
+	assert.NoError(t, primary.DeleteProduct(ctx, sambal.ID))
+	found, err := productService.GetProductById(ctx, "3")
+	assert.NoError(t, err)
+	assert.Equal(t, "sambal", found.Name)
+	assert.Equal(t, "3", found.ID)
+
+	assert.NoError(t, productService.DeleteProduct(ctx, kecap.ID))
+	assert.Nil(t, stored(t, ctx, primary, "kecap"))
+	assert.Nil(t, stored(t, ctx, secondary, "kecap"))
+	assert.NotNil(t, stored(t, ctx, secondary, "garam"), "only the copy is deleted")
+	_, err = productService.GetProductById(ctx, "2")
+	assert.ErrorIs(t, err, memory.ErrNotFound)
+}
+
+type recordingPublisher struct {
+	events []events.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event events.Event) {
+	p.events = append(p.events, event)
+}
+
+func TestMutationsPublishBeforeAndAfterState(t *testing.T) {
+	productService, _, _, ctx := newService(t)
+	product := &domain.Product{Name: "kecap", Description: "asin", Price: 10000, Stock: 10}
+	assert.NoError(t, productService.CreateProduct(ctx, product))
+	publisher := &recordingPublisher{}
+	productService.AddPublisher(publisher)
+
+	update := &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 3}
+	assert.NoError(t, productService.UpdateProduct(ctx, product.ID, update))
+	assert.NoError(t, productService.DeleteProduct(ctx, product.ID))
+
+	if assert.Len(t, publisher.events, 2) {
+		updated := publisher.events[0]
+		assert.Equal(t, events.ProductUpdated, updated.Type)
+		assert.Equal(t, "shop-a", updated.TenantID)
+		assert.Equal(t, 10, updated.Before.Stock)
+		assert.Equal(t, 3, updated.After.Stock)
+		assert.Equal(t, product.ID, updated.After.ID)
+
+		deleted := publisher.events[1]
+		assert.Equal(t, events.ProductDeleted, deleted.Type)
+		assert.NotNil(t, deleted.Before)
+		assert.Nil(t, deleted.After)
 	}
+}
+
+// countingRepo counts lookups by ID.
+type countingRepo struct {
+	*memory.MemoryProductRepository
+	lookups int
+}
+
+func (r *countingRepo) GetProductById(ctx context.Context, id string) (*domain.Product, error) {
+	r.lookups++
+	return r.MemoryProductRepository.GetProductById(ctx, id)
+}
+
+// routeAll routes every read to the secondary and counts what it observes.
+type routeAll struct{ observed int }
+
+func (r *routeAll) Secondary(context.Context, string, string) bool { return true }
+func (r *routeAll) Observe(context.Context, string, error)         { r.observed++ }
 
-	// Setup mocks
-	mockMySQLRepo.On("GetAllProducts").Return(mysqlProducts, nil)
-	mockMongoRepo.On("GetAllProducts").Return(mongoProducts, nil)
+// countingShadow counts the reads it is asked to repeat.
+type countingShadow struct{ reads int }
 
-	result, err := productService.GetAllProducts()
+func (r *countingShadow) Product(context.Context, string, *domain.Product) { r.reads++ }
+func (r *countingShadow) Products(context.Context, []domain.Product)       { r.reads++ }
 
-	// Verifikasi hasil
+func TestWritesReadTheProductOnceAndBypassReadRouting(t *testing.T) {
+	primary := &countingRepo{MemoryProductRepository: memory.NewMemoryProductRepository()}
+	productService, err := service.NewProductService(primary, memory.NewMemoryProductRepository())
 	assert.NoError(t, err)
-	assert.Len(t, result, 2)
-	mockMySQLRepo.AssertExpectations(t)
-	mockMongoRepo.AssertExpectations(t)
+	router, shadow := &routeAll{}, &countingShadow{}
+	productService.SetRouter(router)
+	productService.SetShadowReader(shadow)
+	productService.AddPublisher(&recordingPublisher{})
+	ctx := tenant.WithTenant(context.Background(), "shop-a")
+
+	product := &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 10}
+	assert.NoError(t, productService.CreateProduct(ctx, product))
+	assert.NoError(t, productService.UpdateProduct(ctx, product.ID, &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 3}))
+	assert.Equal(t, 1, primary.lookups)
+	assert.NoError(t, productService.DeleteProduct(ctx, product.ID))
+	assert.Equal(t, 2, primary.lookups)
+	assert.Zero(t, router.observed)
+	assert.Zero(t, shadow.reads)
 }
 
-func TestGetProductById(t *testing.T) {
-	mockMySQLRepo := new(MockProductRepository)
-	mockMongoRepo := new(MockProductRepository)
-	productService := service.NewProductService(mockMySQLRepo, mockMongoRepo)
+func TestRoutedReadsGoStraightToTheRecordedCopy(t *testing.T) {
+	primary := &countingRepo{MemoryProductRepository: memory.NewMemoryProductRepository()}
+	secondary := memory.NewMemoryProductRepository()
+	productService, err := service.NewProductService(primary, secondary)
+	assert.NoError(t, err)
+	productService.SetIDMap(idmap.NewMemoryStore())
+	ctx := tenant.WithTenant(context.Background(), "shop-a")
+	// The secondary's IDs run ahead, so the copy gets an ID of its own.
+	assert.NoError(t, secondary.Create(ctx, &domain.Product{Name: "garam", Description: "d", Price: 1}))
+	kecap := &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 10}
+	assert.NoError(t, productService.CreateProduct(ctx, kecap))
+	// Made before copies were recorded, so it is served by the primary.
+	sambal := &domain.Product{Name: "sambal", Description: "pedas", Price: 9000, Stock: 4}
+	assert.NoError(t, primary.Create(ctx, sambal))
+
+	router := &routeAll{}
+	productService.SetRouter(router)
+	copied, err := productService.GetProductById(ctx, kecap.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", copied.ID, "the secondary's copy answers")
+	assert.Zero(t, primary.lookups)
+	assert.Equal(t, 1, router.observed)
 
-	product := &domain.Product{Name: "Test Product", Description: "Test Description", Price: 100.0, Stock: 10}
+	found, err := productService.GetProductsByIds(ctx, []string{kecap.ID, sambal.ID})
+	assert.NoError(t, err)
+	if assert.Len(t, found, 2) {
+		assert.Equal(t, "2", found[kecap.ID].ID)
+		assert.Equal(t, sambal.ID, found[sambal.ID].ID)
+	}
+	assert.Equal(t, 2, router.observed, "unrecorded copies are not observed")
+}
+
+func TestMutationsRejectInvalidProducts(t *testing.T) {
+	productService, primary, _, ctx := newService(t)
+	product := &domain.Product{Name: "kecap", Description: "asin", Price: 10000, Stock: 10}
+	assert.NoError(t, productService.CreateProduct(ctx, product))
+
+	invalid := &domain.Product{Name: "sambal", Price: -1, Stock: -2}
+	err := productService.CreateProduct(ctx, invalid)
+	assert.ErrorIs(t, err, domain.ErrValidation)
+	var domainErr *domain.Error
+	if assert.ErrorAs(t, err, &domainErr) {
+		assert.Equal(t, []domain.FieldError{
+			{Field: "description", Reason: "is required"},
+			{Field: "price", Reason: "must be greater than 0"},
+			{Field: "stock", Reason: "must not be negative"},
+		}, domainErr.Fields)
+	}
+	assert.ErrorIs(t, productService.UpdateProduct(ctx, product.ID, invalid), domain.ErrValidation)
+	assert.Nil(t, stored(t, ctx, primary, "sambal"))
+	assert.Equal(t, 10, stored(t, ctx, primary, "kecap").Stock)
+}
 
-	// Test case untuk produk yang ditemukan di MySQL
-	mockMySQLRepo.On("GetProductById", "1").Return(product, nil)
+// failingRepo fails lookups by ID with err.
+type failingRepo struct {
+	*memory.MemoryProductRepository
+	err error
+}
 
-	result, err := productService.GetProductById("1")
+func (r *failingRepo) GetProductById(ctx context.Context, id string) (*domain.Product, error) {
+	return nil, r.err
+}
 
+func TestGetProductByIdPrefersPrimaryFailureOverSecondaryNotFound(t *testing.T) {
+	down := domain.NewError(domain.ErrUnavailable, "MySQL is unavailable", errors.New("connection refused"))
+	productService, err := service.NewProductService(&failingRepo{memory.NewMemoryProductRepository(), down}, memory.NewMemoryProductRepository())
 	assert.NoError(t, err)
-	assert.Equal(t, product, result)
-	mockMySQLRepo.AssertExpectations(t)
+
+	_, err = productService.GetProductById(tenant.WithTenant(context.Background(), "shop-a"), "1")
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
 }
 
-func TestUpdateProduct(t *testing.T) {
-	mockMySQLRepo := new(MockProductRepository)
-	mockMongoRepo := new(MockProductRepository)
-	productService := service.NewProductService(mockMySQLRepo, mockMongoRepo)
+// listingRepo answers GetAllProducts with list.
+type listingRepo struct {
+	*memory.MemoryProductRepository
+	list func(ctx context.Context) ([]domain.Product, error)
+}
 
-	product := &domain.Product{Name: "Updated Product", Description: "Updated Description", Price: 150.0, Stock: 8}
+func (r *listingRepo) GetAllProducts(ctx context.Context) ([]domain.Product, error) {
+	return r.list(ctx)
+}
 
-	// Setup mocks
-	mockMySQLRepo.On("UpdateProduct", "1", product).Return(nil)
-	mockMongoRepo.On("UpdateProduct", "1", product).Return(nil)
+func listing(list func(ctx context.Context) ([]domain.Product, error)) *listingRepo {
+	return &listingRepo{MemoryProductRepository: memory.NewMemoryProductRepository(), list: list}
+}
 
-	err := productService.UpdateProduct("1", product)
+func answer(names ...string) *listingRepo {
+	return listing(func(context.Context) ([]domain.Product, error) {
+		products := make([]domain.Product, len(names))
+		for i, name := range names {
+			products[i].Name = name
+		}
+		return products, nil
+	})
+}
+
+// hang blocks until its context ends.
+var hang = listing(func(ctx context.Context) ([]domain.Product, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+})
+
+func TestListProductsQueriesBothBackendsAtOnce(t *testing.T) {
+	// Each backend only answers once the other has been queried.
+	var started sync.WaitGroup
+	started.Add(2)
+	both := func(name string) *listingRepo {
+		return listing(func(ctx context.Context) ([]domain.Product, error) {
+			started.Done()
+			started.Wait()
+			return []domain.Product{{Name: name}}, nil
+		})
+	}
+	productService, err := service.NewProductService(both("kecap"), both("sambal"))
+	assert.NoError(t, err)
 
-	// Verifikasi hasil
+	list, err := productService.ListProducts(context.Background())
 	assert.NoError(t, err)
-	mockMySQLRepo.AssertExpectations(t)
-	mockMongoRepo.AssertExpectations(t)
+	assert.Equal(t, []domain.Product{{Name: "kecap"}, {Name: "sambal"}}, list.Products)
+	assert.False(t, list.Partial)
+	assert.Equal(t, []service.Source{
+		{Backend: "mysql", Status: service.SourceOK, Count: 1},
+		{Backend: "mongodb", Status: service.SourceOK, Count: 1},
+	}, list.Sources)
 }
 
-func TestDeleteProduct(t *testing.T) {
-	mockMySQLRepo := new(MockProductRepository)
-	mockMongoRepo := new(MockProductRepository)
-	productService := service.NewProductService(mockMySQLRepo, mockMongoRepo)
+func TestListProductsFailsWithEitherBackendByDefault(t *testing.T) {
+	broken := listing(func(context.Context) ([]domain.Product, error) {
+		return nil, domain.NewError(domain.ErrUnavailable, "MongoDB is unavailable", errors.New("server selection timeout"))
+	})
+	productService, err := service.NewProductService(hang, broken)
+	assert.NoError(t, err)
 
-	// Setup mocks
-	mockMySQLRepo.On("DeleteProduct", "1").Return(nil)
-	mockMongoRepo.On("DeleteProduct", "1").Return(nil)
+	// The failure cancels the query still running on the primary.
+	_, err = productService.ListProducts(context.Background())
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+	assert.ErrorContains(t, err, "MongoDB is unavailable")
+}
+
+func TestListProductsTimesOutEachBackend(t *testing.T) {
+	productService, err := service.NewProductService(answer("kecap"), hang)
+	assert.NoError(t, err)
+	productService.SetListConfig(config.ListConfig{BackendTimeout: 20 * time.Millisecond}, "postgres", "mongodb")
+
+	_, err = productService.ListProducts(context.Background())
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestListProductsAnswersPartiallyWhenAllowed(t *testing.T) {
+	productService, err := service.NewProductService(answer("kecap", "sambal"), hang)
+	assert.NoError(t, err)
+	productService.SetListConfig(config.ListConfig{BackendTimeout: 20 * time.Millisecond, AllowPartial: true}, "postgres", "mongodb")
+
+	list, err := productService.ListProducts(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, list.Products, 2)
+	assert.True(t, list.Partial)
+	assert.Equal(t, []service.Source{
+		{Backend: "postgres", Status: service.SourceOK, Count: 2},
+		{Backend: "mongodb", Status: service.SourceTimeout},
+	}, list.Sources)
+
+	// With neither backend answering there is nothing to return.
+	productService, err = service.NewProductService(hang, hang)
+	assert.NoError(t, err)
+	productService.SetListConfig(config.ListConfig{BackendTimeout: 20 * time.Millisecond, AllowPartial: true}, "mysql", "mongodb")
+	_, err = productService.ListProducts(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
 
-	err := productService.DeleteProduct("1")
+func TestListProductsSkipsAnEmptySlot(t *testing.T) {
+	productService, err := service.NewProductService(answer("kecap"), hang)
+	assert.NoError(t, err)
+	productService.SetListConfig(config.ListConfig{}, "sqlite", config.BackendNone)
 
-	// Verifikasi hasil
+	list, err := productService.ListProducts(context.Background())
 	assert.NoError(t, err)
-	mockMySQLRepo.AssertExpectations(t)
-	mockMongoRepo.AssertExpectations(t)
+	assert.Len(t, list.Products, 1)
+	assert.Equal(t, []service.Source{{Backend: "sqlite", Status: service.SourceOK, Count: 1}}, list.Sources)
 }
@@ -0,0 +1,192 @@
+// internal/shadow/shadow.go
+package shadow
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+
+	"product-management/internal/config"
+	"product-management/internal/cutover"
+	"product-management/internal/domain"
+)
+
+// Results of a shadow read.
+const (
+	ResultMatch    = "match"
+	ResultMismatch = "mismatch"
+	// ResultMissing means the secondary does not have the product.
+	ResultMissing = "missing"
+	ResultError   = "error"
+	// ResultSkipped means MaxInFlight shadow reads were already running.
+	ResultSkipped = "skipped"
+)
+
+// Secondary is the repository shadow reads go to. Products are looked up
+// by ID and, since backends generate their own IDs, then by tenant and
+// name.
+type Secondary interface {
+	domain.ProductRepository
+	domain.ProductSearcher
+}
+
+// Recorder counts shadow reads. *metrics.Metrics implements it.
+type Recorder interface {
+	ObserveShadowRead(operation, result string, fields []string)
+}
+
+// Reader repeats reads served by the primary against the secondary in the
+// background and records where the answers differ. The caller never waits
+// for the secondary.
+type Reader struct {
+	secondary Secondary
+	cfg       config.ShadowReadConfig
+	recorder  Recorder
+	// sample returns a number in [0, 1) to compare with the sample rates.
+	sample func() float64
+
+	sem chan struct{}
+	wg  sync.WaitGroup
+}
+
+// New creates a Reader that compares against secondary.
+func New(secondary Secondary, cfg config.ShadowReadConfig, recorder Recorder) *Reader {
+	return &Reader{
+		secondary: secondary,
+		cfg:       cfg,
+		recorder:  recorder,
+		sample:    rand.Float64,
+		sem:       make(chan struct{}, max(cfg.MaxInFlight, 1)),
+	}
+}
+
+// Product compares the primary's answer to a lookup of id.
+func (r *Reader) Product(ctx context.Context, id string, primary *domain.Product) {
+	p := *primary
+	r.spawn(ctx, cutover.OpGetByID, func(ctx context.Context) {
+		r.compare(ctx, cutover.OpGetByID, id, &p)
+	})
+}
+
+// Products compares each product the primary returned for a batch lookup.
+func (r *Reader) Products(ctx context.Context, primary []domain.Product) {
+	if len(primary) == 0 {
+		return
+	}
+	products := append([]domain.Product{}, primary...)
+	r.spawn(ctx, cutover.OpGetByIDs, func(ctx context.Context) {
+		for i := range products {
+			r.compare(ctx, cutover.OpGetByIDs, products[i].Key(), &products[i])
+		}
+	})
+}
+
+// Stop waits for running shadow reads, or for ctx.
+func (r *Reader) Stop(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// spawn runs compare in the background for a sample of the reads.
+func (r *Reader) spawn(ctx context.Context, operation string, compare func(ctx context.Context)) {
+	if r.sample() >= r.cfg.SampleRate {
+		return
+	}
+	select {
+	case r.sem <- struct{}{}:
+	default:
+		r.recorder.ObserveShadowRead(operation, ResultSkipped, nil)
+		return
+	}
+	r.wg.Add(1)
+	// The request may finish first; its tenant and request ID are kept.
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer func() { <-r.sem; r.wg.Done() }()
+		ctx, cancel := context.WithTimeout(ctx, r.cfg.Timeout)
+		defer cancel()
+		compare(ctx)
+	}()
+}
+
+// compare looks the product up in the secondary, counts the result and
+// logs a sample of the differences with both payloads.
+func (r *Reader) compare(ctx context.Context, operation, id string, primary *domain.Product) {
+	secondary, err := r.lookup(ctx, id, primary)
+	var fields []string
+	result := ResultMatch
+	switch {
+	case err != nil:
+		result = ResultError
+	case secondary == nil:
+		result = ResultMissing
+	default:
+		if fields = Diff(primary, secondary); len(fields) > 0 {
+			result = ResultMismatch
+		}
+	}
+	r.recorder.ObserveShadowRead(operation, result, fields)
+
+	if result == ResultMatch || r.sample() >= r.cfg.LogSampleRate {
+		return
+	}
+	attrs := []any{"operation", operation, "product_id", id, "result", result, "primary", primary, "secondary", secondary}
+	if len(fields) > 0 {
+		attrs = append(attrs, "fields", fields)
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	slog.WarnContext(ctx, "Shadow read differs from primary", attrs...)
+}
+
+// lookup finds the secondary's copy of a product, or nil if it has none.
+func (r *Reader) lookup(ctx context.Context, id string, primary *domain.Product) (*domain.Product, error) {
+	found, err := r.secondary.GetProductById(ctx, id)
+	if err == nil && found != nil && found.Name == primary.Name {
+		return found, nil
+	}
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+	named, err := domain.FindByName(ctx, r.secondary, primary.Name)
+	if err != nil || named != nil {
+		return named, err
+	}
+	// A product with the same ID but another name is a mismatch, not a
+	// missing product.
+	return found, nil
+}
+
+// Diff returns the JSON names of the fields that differ between two copies
+// of a product. IDs are not compared since each backend generates its own.
+func Diff(primary, secondary *domain.Product) []string {
+	var fields []string
+	if primary.TenantID != secondary.TenantID {
+		fields = append(fields, "tenant_id")
+	}
+	if primary.Name != secondary.Name {
+		fields = append(fields, "name")
+	}
+	if primary.Description != secondary.Description {
+		fields = append(fields, "description")
+	}
+	if primary.Price != secondary.Price {
+		fields = append(fields, "price")
+	}
+	if primary.Stock != secondary.Stock {
+		fields = append(fields, "stock")
+	}
+	return fields
+}
@@ -0,0 +1,156 @@
+package shadow_test
+
+import (
+	"context"
+	"errors"
+	"product-management/internal/config"
+	"product-management/internal/domain"
+	"product-management/internal/repository/memory"
+	"product-management/internal/service"
+	"product-management/internal/shadow"
+	"product-management/internal/tenant"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = config.ShadowReadConfig{
+	Enabled:       true,
+	SampleRate:    1,
+	LogSampleRate: 1,
+	Timeout:       time.Second,
+	MaxInFlight:   4,
+}
+
+// recorder keeps the observed results as "operation result fields".
+type recorder struct {
+	mu      sync.Mutex
+	results []string
+}
+
+func (r *recorder) ObserveShadowRead(operation, result string, fields []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry := operation + " " + result
+	for _, f := range fields {
+		entry += " " + f
+	}
+	r.results = append(r.results, entry)
+}
+
+func (r *recorder) observed() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.results...)
+}
+
+// blockingRepo holds lookups until release is closed, or fails them.
+type blockingRepo struct {
+	*memory.MemoryProductRepository
+	release chan struct{}
+	err     error
+}
+
+func (r *blockingRepo) GetProductById(ctx context.Context, id string) (*domain.Product, error) {
+	if r.release != nil {
+		<-r.release
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.MemoryProductRepository.GetProductById(ctx, id)
+}
+
+func setup(t *testing.T, secondary shadow.Secondary) (*service.ProductService, *shadow.Reader, *recorder, *memory.MemoryProductRepository, context.Context) {
+	primary := memory.NewMemoryProductRepository()
+	productService, err := service.NewProductService(primary, secondary)
+	require.NoError(t, err)
+	rec := &recorder{}
+	reader := shadow.New(secondary, testConfig, rec)
+	productService.SetShadowReader(reader)
+	return productService, reader, rec, primary, tenant.WithTenant(context.Background(), "shop-a")
+}
+
+func stop(t *testing.T, reader *shadow.Reader) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, reader.Stop(ctx))
+}
+
+func TestShadowReadsCompareFields(t *testing.T) {
+	secondary := memory.NewMemoryProductRepository()
+	productService, reader, rec, primary, ctx := setup(t, secondary)
+
+	kecap := &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 10}
+	sambal := &domain.Product{Name: "sambal", Description: "pedas", Price: 9000, Stock: 4}
+	tempe := &domain.Product{Name: "tempe", Description: "goreng", Price: 5000, Stock: 7}
+	for _, p := range []*domain.Product{kecap, sambal, tempe} {
+		require.NoError(t, primary.Create(ctx, p))
+	}
+	// The secondary generates its own IDs; products are matched by name.
+	require.NoError(t, secondary.Create(ctx, &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 10}))
+	require.NoError(t, secondary.Create(ctx, &domain.Product{Name: "sambal", Description: "pedas sekali", Price: 9000, Stock: 2}))
+
+	for _, p := range []*domain.Product{kecap, sambal, tempe} {
+		got, err := productService.GetProductById(ctx, p.ID)
+		require.NoError(t, err)
+		assert.Equal(t, p.Name, got.Name)
+		stop(t, reader)
+	}
+	_, err := productService.GetProductsByIds(ctx, []string{kecap.ID, sambal.ID})
+	require.NoError(t, err)
+	stop(t, reader)
+
+	assert.Equal(t, []string{
+		"get_by_id match",
+		"get_by_id mismatch description stock",
+		"get_by_id missing",
+		"get_by_ids match",
+		"get_by_ids mismatch description stock",
+	}, rec.observed())
+}
+
+func TestShadowReadsDoNotDelayTheCaller(t *testing.T) {
+	secondary := &blockingRepo{MemoryProductRepository: memory.NewMemoryProductRepository(), release: make(chan struct{})}
+	productService, reader, rec, primary, ctx := setup(t, secondary)
+	kecap := &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 10}
+	require.NoError(t, primary.Create(ctx, kecap))
+
+	// Reads return while the secondary hangs; once MaxInFlight are waiting
+	// the rest are skipped.
+	for range testConfig.MaxInFlight + 2 {
+		_, err := productService.GetProductById(ctx, kecap.ID)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"get_by_id skipped", "get_by_id skipped"}, rec.observed())
+
+	close(secondary.release)
+	stop(t, reader)
+	assert.Len(t, rec.observed(), testConfig.MaxInFlight+2)
+	assert.Equal(t, "get_by_id missing", rec.observed()[2])
+}
+
+func TestShadowReadErrorsAreCounted(t *testing.T) {
+	secondary := &blockingRepo{MemoryProductRepository: memory.NewMemoryProductRepository(), err: errors.New("server selection timeout")}
+	productService, reader, rec, primary, ctx := setup(t, secondary)
+	kecap := &domain.Product{Name: "kecap", Description: "manis", Price: 12000, Stock: 10}
+	require.NoError(t, primary.Create(ctx, kecap))
+
+	got, err := productService.GetProductById(ctx, kecap.ID)
+	require.NoError(t, err, "the secondary's failure does not reach the caller")
+	assert.Equal(t, "kecap", got.Name)
+	stop(t, reader)
+	assert.Equal(t, []string{"get_by_id error"}, rec.observed())
+}
+
+func TestDiff(t *testing.T) {
+	p := &domain.Product{ID: "1", TenantID: "shop-a", Name: "kecap", Description: "manis", Price: 12000, Stock: 10}
+	q := *p
+	q.ID = "652f1c"
+	assert.Empty(t, shadow.Diff(p, &q), "IDs are not compared")
+	q.TenantID, q.Price = "shop-b", 11000
+	assert.Equal(t, []string{"tenant_id", "price"}, shadow.Diff(p, &q))
+}
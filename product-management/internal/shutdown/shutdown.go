@@ -0,0 +1,121 @@
+// internal/shutdown/shutdown.go
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Phase orders shutdown hooks. Phases run one after another; a later phase
+// starts only when every hook of the previous one has returned.
+type Phase int
+
+const (
+	// PhaseServer stops accepting connections and drains in-flight requests.
+	PhaseServer Phase = iota
+	// PhaseWorkers flushes background workers (outboxes, schedulers,
+	// webhook dispatchers) that may still be writing.
+	PhaseWorkers
+	// PhaseConnections closes database clients and flushes telemetry.
+	PhaseConnections
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseServer:
+		return "server"
+	case PhaseWorkers:
+		return "workers"
+	case PhaseConnections:
+		return "connections"
+	}
+	return fmt.Sprintf("phase(%d)", int(p))
+}
+
+var phases = []Phase{PhaseServer, PhaseWorkers, PhaseConnections}
+
+// Hook releases a resource. It must return once ctx is done.
+type Hook func(ctx context.Context) error
+
+type namedHook struct {
+	name string
+	hook Hook
+}
+
+// Manager collects hooks and runs them in phase order under one deadline,
+// and optionally a deadline per phase.
+type Manager struct {
+	mu       sync.Mutex
+	hooks    map[Phase][]namedHook
+	timeouts map[Phase]time.Duration
+	done     bool
+}
+
+// New creates an empty Manager.
+func New() *Manager {
+	return &Manager{hooks: make(map[Phase][]namedHook), timeouts: make(map[Phase]time.Duration)}
+}
+
+// SetTimeout bounds a phase, so hooks that hang in it do not use up the
+// time of the phases after it. Zero leaves only the Shutdown deadline.
+func (m *Manager) SetTimeout(phase Phase, timeout time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.timeouts[phase] = timeout
+}
+
+// Register adds a hook to a phase. Hooks within a phase run concurrently.
+func (m *Manager) Register(phase Phase, name string, hook Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks[phase] = append(m.hooks[phase], namedHook{name: name, hook: hook})
+}
+
+// Shutdown runs all phases. It keeps going after a failed hook so that
+// connections are still closed, and returns every error joined together.
+// Calling it more than once is a no-op.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if m.done {
+		m.mu.Unlock()
+		return nil
+	}
+	m.done = true
+	hooks, timeouts := m.hooks, m.timeouts
+	m.mu.Unlock()
+
+	var errs []error
+	for _, phase := range phases {
+		start := time.Now()
+		if err := runPhase(ctx, hooks[phase], timeouts[phase]); err != nil {
+			errs = append(errs, fmt.Errorf("%s phase: %w", phase, err))
+		}
+		slog.InfoContext(ctx, "Shutdown phase finished", "phase", phase.String(), "duration", time.Since(start))
+	}
+	return errors.Join(errs...)
+}
+
+func runPhase(ctx context.Context, hooks []namedHook, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	errs := make([]error, len(hooks))
+	var wg sync.WaitGroup
+	for i, h := range hooks {
+		wg.Add(1)
+		go func(i int, h namedHook) {
+			defer wg.Done()
+			if err := h.hook(ctx); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", h.name, err)
+			}
+		}(i, h)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,87 @@
+package shutdown_test
+
+import (
+	"context"
+	"errors"
+	"product-management/internal/shutdown"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdownRunsPhasesInOrder(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) shutdown.Hook {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	m := shutdown.New()
+	m.Register(shutdown.PhaseConnections, "mysql", record("mysql"))
+	m.Register(shutdown.PhaseWorkers, "outbox", record("outbox"))
+	m.Register(shutdown.PhaseServer, "http", record("http"))
+
+	assert.NoError(t, m.Shutdown(context.Background()))
+	assert.Equal(t, []string{"http", "outbox", "mysql"}, order)
+}
+
+func TestShutdownContinuesAfterFailure(t *testing.T) {
+	closed := false
+	m := shutdown.New()
+	m.Register(shutdown.PhaseServer, "http", func(context.Context) error {
+		return errors.New("drain timed out")
+	})
+	m.Register(shutdown.PhaseConnections, "mysql", func(context.Context) error {
+		closed = true
+		return nil
+	})
+
+	err := m.Shutdown(context.Background())
+	assert.ErrorContains(t, err, "server phase: http: drain timed out")
+	assert.True(t, closed)
+	assert.NoError(t, m.Shutdown(context.Background()))
+}
+
+func TestShutdownHonoursDeadline(t *testing.T) {
+	m := shutdown.New()
+	m.Register(shutdown.PhaseWorkers, "webhooks", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := m.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestPhaseTimeoutLeavesTimeForLaterPhases(t *testing.T) {
+	m := shutdown.New()
+	m.SetTimeout(shutdown.PhaseWorkers, 20*time.Millisecond)
+	m.Register(shutdown.PhaseWorkers, "webhooks", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	var closeErr error
+	m.Register(shutdown.PhaseConnections, "mysql", func(ctx context.Context) error {
+		closeErr = ctx.Err()
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	start := time.Now()
+	err := m.Shutdown(ctx)
+	assert.ErrorContains(t, err, "workers phase: webhooks")
+	assert.NoError(t, closeErr, "a hung phase must not use up the next one's time")
+	assert.Less(t, time.Since(start), time.Second)
+}
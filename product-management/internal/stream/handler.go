@@ -0,0 +1,119 @@
+// internal/stream/handler.go
+package stream
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"product-management/internal/events"
+	"product-management/internal/problem"
+	"product-management/internal/tenant"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LastEventIDHeader is sent by EventSource clients when they reconnect.
+const LastEventIDHeader = "Last-Event-ID"
+
+// retryMillis tells EventSource clients how long to wait before reconnecting.
+const retryMillis = 3000
+
+var streamTypes = map[string]bool{
+	events.ProductCreated: true,
+	events.ProductUpdated: true,
+	events.ProductDeleted: true,
+	events.StockChanged:   true,
+}
+
+// Handler serves GET /products/stream. Clients may filter with
+// ?product_id=1,2 and ?type=product.updated,stock.changed, and resume with
+// the Last-Event-ID header or ?last_event_id=. It must run after the tenant
+// middleware; clients only see events of their tenant.
+func Handler(hub *Hub, heartbeat time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tenantID, ok := tenant.FromContext(c.UserContext())
+		if !ok {
+			return problem.Respond(c, fiber.StatusBadRequest, "Missing "+tenant.Header+" header")
+		}
+		if c.Query("category") != "" {
+			return problem.Respond(c, fiber.StatusBadRequest, "Products have no categories; filter by product_id instead")
+		}
+
+		filter := Filter{TenantID: tenantID, ProductIDs: list(c.Query("product_id")), Types: list(c.Query("type"))}
+		for t := range filter.Types {
+			if !streamTypes[t] {
+				return problem.Respond(c, fiber.StatusBadRequest, "Unknown event type "+t)
+			}
+		}
+
+		lastID := c.Get(LastEventIDHeader, c.Query("last_event_id"))
+		var lastSeq uint64
+		if lastID != "" {
+			var err error
+			if lastSeq, err = strconv.ParseUint(lastID, 10, 64); err != nil {
+				return problem.Respond(c, fiber.StatusBadRequest, "Invalid "+LastEventIDHeader)
+			}
+		}
+
+		sub, replay, complete := hub.Subscribe(filter, lastSeq, lastID != "")
+
+		c.Set(fiber.HeaderContentType, "text/event-stream")
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		c.Set(fiber.HeaderConnection, "keep-alive")
+		c.Set("X-Accel-Buffering", "no")
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer sub.Close()
+			fmt.Fprintf(w, "retry: %d\n\n", retryMillis)
+			if !complete {
+				// Events were missed; the client must reload its view.
+				fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+			}
+			for i := range replay {
+				write(w, &replay[i])
+			}
+			if w.Flush() != nil {
+				return
+			}
+
+			ticker := time.NewTicker(heartbeat)
+			defer ticker.Stop()
+			for {
+				select {
+				case m, ok := <-sub.C():
+					if !ok {
+						return
+					}
+					write(w, &m)
+				case <-ticker.C:
+					fmt.Fprint(w, ": keep-alive\n\n")
+				}
+				// A failed flush means the client went away.
+				if w.Flush() != nil {
+					return
+				}
+			}
+		})
+		return nil
+	}
+}
+
+func write(w *bufio.Writer, m *Message) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", m.Seq, m.Type, m.Data)
+}
+
+// list parses a comma-separated query value into a set.
+func list(value string) map[string]bool {
+	if value == "" {
+		return nil
+	}
+	set := make(map[string]bool)
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			set[v] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,189 @@
+// internal/stream/hub.go
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+
+	"product-management/internal/events"
+)
+
+// Message is one event as sent to stream clients. Seq is the SSE event ID;
+// it increases by one per message for the lifetime of the process.
+type Message struct {
+	Seq       uint64
+	Type      string
+	TenantID  string
+	ProductID string
+	Data      []byte
+}
+
+// Filter selects the messages a client receives. Empty sets match all.
+type Filter struct {
+	TenantID   string
+	ProductIDs map[string]bool
+	Types      map[string]bool
+}
+
+func (f *Filter) matches(m *Message) bool {
+	if m.TenantID != f.TenantID {
+		return false
+	}
+	if len(f.ProductIDs) > 0 && !f.ProductIDs[m.ProductID] {
+		return false
+	}
+	return len(f.Types) == 0 || f.Types[m.Type]
+}
+
+// Hub fans product events out to connected clients and keeps a bounded
+// replay buffer for clients that reconnect.
+type Hub struct {
+	clientBuffer int
+
+	mu      sync.Mutex
+	seq     uint64
+	replay  []Message // ring buffer of the newest messages
+	next    int       // ring position of the next write
+	clients map[*Subscription]struct{}
+	closed  bool
+}
+
+// NewHub creates a Hub keeping replaySize messages for resumption and
+// queueing up to clientBuffer messages per client.
+func NewHub(replaySize, clientBuffer int) *Hub {
+	return &Hub{
+		clientBuffer: clientBuffer,
+		replay:       make([]Message, 0, max(replaySize, 1)),
+		clients:      make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscription is one connected client.
+type Subscription struct {
+	hub    *Hub
+	filter Filter
+	c      chan Message
+	once   sync.Once
+}
+
+// C delivers matching messages. It is closed when the client is dropped
+// for falling behind, when the hub closes, or after Close.
+func (s *Subscription) C() <-chan Message {
+	return s.c
+}
+
+// Close unsubscribes the client.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.removeLocked(s)
+}
+
+func (h *Hub) removeLocked(s *Subscription) {
+	delete(h.clients, s)
+	s.once.Do(func() { close(s.c) })
+}
+
+// Publish implements events.Publisher. Updates that change stock also
+// produce a stock.changed message.
+func (h *Hub) Publish(ctx context.Context, event events.Event) {
+	types := []string{event.Type}
+	if event.StockDiffers() {
+		types = append(types, events.StockChanged)
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to encode stream event", "event_id", event.ID, "error", err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	for _, eventType := range types {
+		h.seq++
+		m := Message{Seq: h.seq, Type: eventType, TenantID: event.TenantID, ProductID: event.ProductID, Data: data}
+		h.remember(m)
+		for s := range h.clients {
+			if !s.filter.matches(&m) {
+				continue
+			}
+			select {
+			case s.c <- m:
+			default:
+				// Never block committing requests on a slow reader; the
+				// client reconnects and resumes from the replay buffer.
+				slog.WarnContext(ctx, "Dropping slow stream client", "tenant_id", s.filter.TenantID)
+				h.removeLocked(s)
+			}
+		}
+	}
+}
+
+func (h *Hub) remember(m Message) {
+	if len(h.replay) < cap(h.replay) {
+		h.replay = append(h.replay, m)
+		return
+	}
+	h.replay[h.next] = m
+	h.next = (h.next + 1) % len(h.replay)
+}
+
+// Subscribe registers a client. With resume set, it also returns the
+// buffered messages after lastSeq that match filter, and complete=false
+// when some of them have already left the buffer, in which case the client
+// should reload its state.
+func (h *Hub) Subscribe(filter Filter, lastSeq uint64, resume bool) (sub *Subscription, replay []Message, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub = &Subscription{hub: h, filter: filter, c: make(chan Message, h.clientBuffer)}
+	if h.closed {
+		close(sub.c)
+		return sub, nil, true
+	}
+	h.clients[sub] = struct{}{}
+	if !resume {
+		return sub, nil, true
+	}
+
+	// Oldest buffered message first.
+	n := len(h.replay)
+	oldest := h.seq + 1
+	for i := 0; i < n; i++ {
+		m := h.replay[(h.next+i)%n]
+		if i == 0 {
+			oldest = m.Seq
+		}
+		if m.Seq > lastSeq && filter.matches(&m) {
+			replay = append(replay, m)
+		}
+	}
+	// A sequence from the future belongs to an earlier process.
+	complete = lastSeq <= h.seq && lastSeq+1 >= oldest
+	return sub, replay, complete
+}
+
+// Clients returns the number of connected clients.
+func (h *Hub) Clients() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// Close disconnects every client and ignores later events, so open streams
+// end when the server shuts down.
+func (h *Hub) Close(context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for s := range h.clients {
+		h.removeLocked(s)
+	}
+	return nil
+}
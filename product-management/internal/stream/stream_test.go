@@ -0,0 +1,160 @@
+package stream_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"product-management/internal/domain"
+	"product-management/internal/events"
+	"product-management/internal/stream"
+	"product-management/internal/tenant"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func event(eventType, tenantID, productID string, before, after int) events.Event {
+	e := events.Event{ID: productID, Type: eventType, TenantID: tenantID, ProductID: productID}
+	if eventType != events.ProductCreated {
+		e.Before = &domain.Product{ID: productID, Stock: before}
+	}
+	if eventType != events.ProductDeleted {
+		e.After = &domain.Product{ID: productID, Stock: after}
+	}
+	return e
+}
+
+func receive(t *testing.T, sub *stream.Subscription) stream.Message {
+	select {
+	case m, ok := <-sub.C():
+		require.True(t, ok, "subscription closed")
+		return m
+	case <-time.After(time.Second):
+		t.Fatal("no message")
+	}
+	return stream.Message{}
+}
+
+func TestHubFiltersByTenantProductAndType(t *testing.T) {
+	hub := stream.NewHub(10, 10)
+	all, _, _ := hub.Subscribe(stream.Filter{TenantID: "shop-a"}, 0, false)
+	one, _, _ := hub.Subscribe(stream.Filter{TenantID: "shop-a", ProductIDs: map[string]bool{"2": true}}, 0, false)
+	stock, _, _ := hub.Subscribe(stream.Filter{TenantID: "shop-a", Types: map[string]bool{events.StockChanged: true}}, 0, false)
+
+	hub.Publish(context.Background(), event(events.ProductUpdated, "shop-b", "1", 5, 4))
+	hub.Publish(context.Background(), event(events.ProductUpdated, "shop-a", "1", 5, 5))
+	hub.Publish(context.Background(), event(events.ProductUpdated, "shop-a", "2", 5, 4))
+
+	assert.Equal(t, events.ProductUpdated, receive(t, all).Type)
+	m := receive(t, all)
+	assert.Equal(t, "2", m.ProductID)
+	assert.Equal(t, events.ProductUpdated, m.Type)
+	assert.Equal(t, events.StockChanged, receive(t, all).Type)
+
+	assert.Equal(t, "2", receive(t, one).ProductID)
+	m = receive(t, stock)
+	assert.Equal(t, events.StockChanged, m.Type)
+	assert.Equal(t, "2", m.ProductID)
+	assert.Len(t, stock.C(), 0)
+}
+
+func TestReplayFromLastEventID(t *testing.T) {
+	hub := stream.NewHub(3, 10)
+	for i := 0; i < 5; i++ {
+		hub.Publish(context.Background(), event(events.ProductCreated, "shop-a", "1", 0, i))
+	}
+	filter := stream.Filter{TenantID: "shop-a"}
+
+	_, replay, complete := hub.Subscribe(filter, 3, true)
+	assert.True(t, complete)
+	require.Len(t, replay, 2)
+	assert.Equal(t, uint64(4), replay[0].Seq)
+	assert.Equal(t, uint64(5), replay[1].Seq)
+
+	// Events 2 and 3 have left the buffer.
+	_, replay, complete = hub.Subscribe(filter, 1, true)
+	assert.False(t, complete)
+	assert.Len(t, replay, 3)
+
+	// An ID from a previous process cannot be resumed.
+	_, _, complete = hub.Subscribe(filter, 99, true)
+	assert.False(t, complete)
+}
+
+func TestSlowClientIsDropped(t *testing.T) {
+	hub := stream.NewHub(10, 1)
+	sub, _, _ := hub.Subscribe(stream.Filter{TenantID: "shop-a"}, 0, false)
+
+	hub.Publish(context.Background(), event(events.ProductCreated, "shop-a", "1", 0, 1))
+	hub.Publish(context.Background(), event(events.ProductCreated, "shop-a", "2", 0, 1))
+
+	receive(t, sub)
+	_, ok := <-sub.C()
+	assert.False(t, ok)
+	assert.Equal(t, 0, hub.Clients())
+}
+
+func TestHandlerStreamsEvents(t *testing.T) {
+	hub := stream.NewHub(10, 10)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.SetUserContext(tenant.WithTenant(c.UserContext(), "shop-a"))
+		return c.Next()
+	})
+	app.Get("/products/stream", stream.Handler(hub, time.Hour))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go app.Listener(ln)
+	// A client of its own, so its idle keep-alive connections can be closed
+	// before the server waits for them on shutdown.
+	client := &http.Client{}
+	t.Cleanup(func() {
+		client.CloseIdleConnections()
+		app.ShutdownWithTimeout(5 * time.Second)
+	})
+	base := "http://" + ln.Addr().String()
+
+	resp, err := client.Get(base + "/products/stream?category=shoes")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	hub.Publish(context.Background(), event(events.ProductCreated, "shop-a", "1", 0, 3))
+
+	req, _ := http.NewRequest("GET", base+"/products/stream?product_id=1", nil)
+	req.Header.Set(stream.LastEventIDHeader, "0")
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	require.Eventually(t, func() bool { return hub.Clients() == 1 }, time.Second, 10*time.Millisecond)
+	hub.Publish(context.Background(), event(events.ProductDeleted, "shop-a", "1", 3, 0))
+
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 6 {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "retry:") {
+			lines = append(lines, line)
+		}
+	}
+	assert.Equal(t, "id: 1", lines[0])
+	assert.Equal(t, "event: product.created", lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "data: {"))
+	assert.Equal(t, "id: 2", lines[3])
+	assert.Equal(t, "event: product.deleted", lines[4])
+
+	// Closing the hub ends open streams.
+	require.NoError(t, hub.Close(context.Background()))
+	_, err = io.ReadAll(reader)
+	assert.NoError(t, err)
+}
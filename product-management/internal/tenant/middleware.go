@@ -0,0 +1,65 @@
+// internal/tenant/middleware.go
+package tenant
+
+import (
+	"errors"
+	"product-management/internal/auth"
+	"product-management/internal/problem"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Header names the tenant for admins that are not bound to one.
+const Header = "X-Tenant-ID"
+
+// Middleware resolves the tenant of a request with Resolve and stores it
+// in the user context. It must run after the authentication middleware.
+func Middleware(defaultTenant string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := Resolve(auth.PrincipalFromContext(c.UserContext()), c.Get(Header), defaultTenant)
+		switch {
+		case errors.Is(err, ErrForbiddenTenant):
+			return problem.Respond(c, fiber.StatusForbidden, "Access to the requested tenant is not allowed")
+		case errors.Is(err, ErrMissingTenant):
+			return problem.Respond(c, fiber.StatusBadRequest, "Missing "+Header+" header")
+		case err != nil:
+			return problem.Respond(c, fiber.StatusBadRequest, "Invalid tenant ID")
+		}
+
+		c.Locals("tenant", id)
+		c.SetUserContext(WithTenant(c.UserContext(), id))
+		return c.Next()
+	}
+}
+
+// Resolve picks the tenant of a call. A tenant carried by the principal
+// (JWT tenant_id claim or the API key's tenant) always wins; requesting a
+// different one fails with ErrForbiddenTenant. Admins without a tenant may
+// pick any tenant, and so may every caller while authentication is
+// disabled, as they are all the anonymous admin. Other principals without
+// a tenant only get defaultTenant. Either way the tenant falls back to
+// defaultTenant when it is set.
+func Resolve(principal *auth.Principal, requested, defaultTenant string) (string, error) {
+	id := requested
+	switch {
+	case principal != nil && principal.TenantID != "":
+		if requested != "" && requested != principal.TenantID {
+			return "", ErrForbiddenTenant
+		}
+		id = principal.TenantID
+	case principal == nil || !principal.Role.Allows(auth.RoleAdmin):
+		if requested != "" && requested != defaultTenant {
+			return "", ErrForbiddenTenant
+		}
+	}
+	if id == "" {
+		id = defaultTenant
+	}
+	if id == "" {
+		return "", ErrMissingTenant
+	}
+	if err := Validate(id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
@@ -0,0 +1,74 @@
+// internal/tenant/tenant.go
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+)
+
+// ErrMissingTenant is returned by repositories when a context carries no
+// tenant scope. Every query must be scoped, so this is never defaulted.
+var ErrMissingTenant = errors.New("tenant: no tenant in context")
+
+// ErrInvalidTenant is returned for tenant IDs that fail validation.
+var ErrInvalidTenant = errors.New("tenant: invalid tenant ID")
+
+// ErrForbiddenTenant is returned when a principal asks for a tenant it may
+// not pick: another tenant than its own, or than the default one for
+// principals that are neither bound to a tenant nor admins.
+var ErrForbiddenTenant = errors.New("tenant: access to tenant not allowed")
+
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Validate checks that id is a well-formed tenant ID.
+func Validate(id string) error {
+	if !validID.MatchString(id) {
+		return ErrInvalidTenant
+	}
+	return nil
+}
+
+type scope struct {
+	id  string
+	all bool
+}
+
+type scopeKey struct{}
+
+// WithTenant returns a copy of ctx scoped to a single tenant.
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope{id: id})
+}
+
+// WithAllTenants returns a copy of ctx that repositories treat as spanning
+// every tenant. It is for trusted background work such as metrics and
+// backfills and must never be derived from request input.
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope{all: true})
+}
+
+// FromContext returns the tenant ID of a single-tenant context.
+func FromContext(ctx context.Context) (string, bool) {
+	s, ok := ctx.Value(scopeKey{}).(scope)
+	if !ok || s.all {
+		return "", false
+	}
+	return s.id, true
+}
+
+// IsAllTenants reports whether ctx was created with WithAllTenants.
+func IsAllTenants(ctx context.Context) bool {
+	s, ok := ctx.Value(scopeKey{}).(scope)
+	return ok && s.all
+}
+
+// Scope resolves the tenant scope for a repository call. It returns the
+// tenant ID, or all=true for unscoped background work, or ErrMissingTenant.
+func Scope(ctx context.Context) (id string, all bool, err error) {
+	s, ok := ctx.Value(scopeKey{}).(scope)
+	if !ok {
+		return "", false, ErrMissingTenant
+	}
+	return s.id, s.all, nil
+}
@@ -0,0 +1,78 @@
+package tenant_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"product-management/internal/auth"
+	"product-management/internal/tenant"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newApp(principal *auth.Principal, defaultTenant string) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if principal != nil {
+			c.SetUserContext(auth.WithPrincipal(c.UserContext(), principal))
+		}
+		return c.Next()
+	})
+	app.Use(tenant.Middleware(defaultTenant))
+	app.Get("/", func(c *fiber.Ctx) error {
+		id, _ := tenant.FromContext(c.UserContext())
+		return c.SendString(id)
+	})
+	return app
+}
+
+func resolve(t *testing.T, app *fiber.App, header string) (int, string) {
+	req := httptest.NewRequest("GET", "/", nil)
+	if header != "" {
+		req.Header.Set(tenant.Header, header)
+	}
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestMiddlewareResolvesTenant(t *testing.T) {
+	bound := &auth.Principal{Subject: "u", Role: auth.RoleEditor, TenantID: "shop-a"}
+	unbound := &auth.Principal{Subject: "ops", Role: auth.RoleAdmin}
+	viewer := &auth.Principal{Subject: "reports", Role: auth.RoleViewer}
+
+	tests := []struct {
+		name          string
+		principal     *auth.Principal
+		defaultTenant string
+		header        string
+		wantCode      int
+		wantTenant    string
+	}{
+		{"token tenant", bound, "", "", fiber.StatusOK, "shop-a"},
+		{"matching header", bound, "", "shop-a", fiber.StatusOK, "shop-a"},
+		{"other tenant in header", bound, "", "shop-b", fiber.StatusForbidden, ""},
+		{"unbound admin picks tenant", unbound, "", "shop-b", fiber.StatusOK, "shop-b"},
+		{"unbound viewer gets default tenant", viewer, "main", "", fiber.StatusOK, "main"},
+		{"unbound viewer names default tenant", viewer, "main", "main", fiber.StatusOK, "main"},
+		{"unbound viewer picks tenant", viewer, "main", "shop-b", fiber.StatusForbidden, ""},
+		{"unbound viewer without default", viewer, "", "shop-b", fiber.StatusForbidden, ""},
+		{"default tenant", unbound, "main", "", fiber.StatusOK, "main"},
+		{"no tenant", unbound, "", "", fiber.StatusBadRequest, ""},
+		{"invalid tenant", unbound, "", "../etc", fiber.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := resolve(t, newApp(tt.principal, tt.defaultTenant), tt.header)
+			assert.Equal(t, tt.wantCode, code)
+			if tt.wantCode == fiber.StatusOK {
+				assert.Equal(t, tt.wantTenant, body)
+			} else if tt.header != "" {
+				assert.NotContains(t, body, tt.header)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// internal/tracing/middleware.go
+package tracing
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "product-management/internal/tracing"
+
+// Middleware starts a server span for every request, continuing any trace
+// passed in the W3C traceparent header. The span context is stored in the
+// request's user context so handlers can hand it down with c.UserContext().
+func Middleware() fiber.Handler {
+	tracer := otel.Tracer(instrumentationName)
+
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{c})
+		ctx, span := tracer.Start(ctx, c.Method()+" "+c.Path(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Method()),
+				attribute.String("url.path", c.Path()),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+		err := c.Next()
+
+		// The route is only known once routing has happened.
+		route := c.Route().Path
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(attribute.String("http.route", route))
+
+		// Errors are rendered further down the chain, so the response holds
+		// the status sent.
+		if err != nil {
+			span.RecordError(err)
+		}
+		status := c.Response().StatusCode()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+		}
+
+		otel.GetTextMapPropagator().Inject(ctx, responseCarrier{c})
+		return err
+	}
+}
+
+// headerCarrier adapts the request headers to propagation.TextMapCarrier.
+type headerCarrier struct{ c *fiber.Ctx }
+
+var _ propagation.TextMapCarrier = headerCarrier{}
+
+func (h headerCarrier) Get(key string) string { return h.c.Get(key) }
+func (h headerCarrier) Set(key, value string) { h.c.Request().Header.Set(key, value) }
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0)
+	h.c.Request().Header.VisitAll(func(k, _ []byte) {
+		keys = append(keys, string(k))
+	})
+	return keys
+}
+
+// responseCarrier writes the trace context back so callers can correlate
+// their request with the server-side trace.
+type responseCarrier struct{ c *fiber.Ctx }
+
+func (r responseCarrier) Get(key string) string { return r.c.GetRespHeader(key) }
+func (r responseCarrier) Set(key, value string) { r.c.Set(key, value) }
+func (r responseCarrier) Keys() []string        { return nil }
@@ -0,0 +1,228 @@
+// internal/webhook/dispatcher.go
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"product-management/internal/config"
+	"product-management/internal/domain"
+	"product-management/internal/durable"
+	"product-management/internal/events"
+	"product-management/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// batchSize bounds how many due deliveries one poll picks up.
+	batchSize = 50
+	// concurrency bounds parallel requests so one slow receiver does not
+	// hold up the others.
+	concurrency = 8
+)
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	TenantID   string    `json:"tenant_id"`
+	ProductID  string    `json:"product_id"`
+	Actor      string    `json:"actor"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       struct {
+		Before *domain.Product `json:"before"`
+		After  *domain.Product `json:"after"`
+	} `json:"data"`
+}
+
+// Dispatcher turns product events into queued deliveries and sends them in
+// the background, retrying failures with exponential backoff.
+type Dispatcher struct {
+	store  Store
+	cfg    config.WebhookConfig
+	client *http.Client
+	worker *durable.Worker
+}
+
+// NewDispatcher creates a Dispatcher. Call Start to begin sending.
+func NewDispatcher(store Store, cfg config.WebhookConfig) *Dispatcher {
+	d := &Dispatcher{store: store, cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}
+	d.worker = durable.NewWorker(cfg.PollInterval, d.Poll)
+	return d
+}
+
+// Publish implements events.Publisher. It queues one delivery per matching
+// subscription, plus stock.low deliveries when the event crossed the low
+// stock threshold, and wakes the sender.
+func (d *Dispatcher) Publish(ctx context.Context, event events.Event) {
+	// The request may finish before the deliveries are queued.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), d.cfg.Timeout)
+	defer cancel()
+
+	types := []string{event.Type}
+	if event.Type != events.ProductDeleted && event.CrossedBelow(d.cfg.LowStockThreshold) {
+		types = append(types, events.StockLow)
+	}
+
+	subs, err := d.store.ActiveSubscriptions(ctx, event.TenantID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load webhook subscriptions", "event_id", event.ID, "error", err)
+		return
+	}
+
+	var deliveries []Delivery
+	created := durable.Now()
+	for _, eventType := range types {
+		body, eventID, err := payload(event, eventType)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to encode webhook payload", "event_id", event.ID, "error", err)
+			return
+		}
+		for _, sub := range subs {
+			if !sub.Wants(eventType) {
+				continue
+			}
+			deliveries = append(deliveries, Delivery{
+				ID:             uuid.NewString(),
+				SubscriptionID: sub.ID,
+				TenantID:       sub.TenantID,
+				EventID:        eventID,
+				EventType:      eventType,
+				Payload:        body,
+				Status:         StatusPending,
+				NextAttemptAt:  created,
+				CreatedAt:      created,
+				UpdatedAt:      created,
+			})
+		}
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+	if err := d.store.CreateDeliveries(ctx, deliveries); err != nil {
+		slog.ErrorContext(ctx, "Failed to queue webhook deliveries", "event_id", event.ID, "error", err)
+		return
+	}
+	d.Wake()
+}
+
+// payload encodes the body for one event type. Derived events get their
+// own ID so receivers can deduplicate per type.
+func payload(event events.Event, eventType string) ([]byte, string, error) {
+	p := Payload{
+		ID:         event.ID,
+		Type:       eventType,
+		TenantID:   event.TenantID,
+		ProductID:  event.ProductID,
+		Actor:      event.Actor,
+		OccurredAt: event.OccurredAt,
+	}
+	if eventType != event.Type {
+		p.ID = uuid.NewSHA1(uuid.NameSpaceOID, []byte(event.ID+"/"+eventType)).String()
+	}
+	p.Data.Before, p.Data.After = event.Before, event.After
+	body, err := json.Marshal(p)
+	return body, p.ID, err
+}
+
+// Wake asks the sender to poll now instead of at the next interval.
+func (d *Dispatcher) Wake() {
+	d.worker.Wake()
+}
+
+// Start runs the sender until Stop is called.
+func (d *Dispatcher) Start() {
+	d.worker.Start()
+}
+
+// Stop stops polling and waits for in-flight deliveries, or for ctx.
+// Unfinished deliveries are retried after their lease by the next process.
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	return d.worker.Stop(ctx)
+}
+
+// Poll sends every due delivery once and returns when they are done.
+func (d *Dispatcher) Poll(ctx context.Context) {
+	ctx = tenant.WithAllTenants(ctx)
+	due, err := d.store.DueDeliveries(ctx, durable.Now(), batchSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load due webhook deliveries", "error", err)
+		return
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range due {
+		delivery := &due[i]
+		claimed, err := d.store.ClaimDelivery(ctx, delivery, durable.LeaseUntil(d.cfg.Timeout))
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to claim webhook delivery", "delivery_id", delivery.ID, "error", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			d.attempt(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+}
+
+// attempt sends a delivery once and records the outcome.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *Delivery) {
+	delivery.Attempts++
+	code, err := d.send(ctx, delivery)
+	delivery.ResponseCode = code
+
+	switch {
+	case err == nil:
+		delivery.Status, delivery.LastError = StatusSucceeded, ""
+	case delivery.Attempts >= d.cfg.MaxAttempts:
+		delivery.Status, delivery.LastError = StatusFailed, err.Error()
+	default:
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = durable.Now().Add(durable.Backoff(delivery.Attempts, d.cfg.BackoffBase, d.cfg.BackoffMax))
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Webhook delivery failed", "delivery_id", delivery.ID, "event_type", delivery.EventType,
+			"attempts", delivery.Attempts, "status", delivery.Status, "error", err)
+	}
+	if err := d.store.RecordAttempt(ctx, delivery); err != nil {
+		slog.ErrorContext(ctx, "Failed to record webhook attempt", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "product-management-webhooks/1")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(delivery.secret, time.Now(), delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
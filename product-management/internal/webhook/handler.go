@@ -0,0 +1,150 @@
+// internal/webhook/handler.go
+package webhook
+
+import (
+	"errors"
+	"net/url"
+	"product-management/internal/domain"
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxDeliveries bounds the delivery log returned per request.
+const maxDeliveries = 100
+
+// Handler serves the webhook admin API for the tenant of the request.
+type Handler struct {
+	store      Store
+	dispatcher *Dispatcher
+}
+
+// NewHandler creates a Handler. dispatcher is woken after redeliveries.
+func NewHandler(store Store, dispatcher *Dispatcher) *Handler {
+	return &Handler{store: store, dispatcher: dispatcher}
+}
+
+type subscriptionInput struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+// CreateSubscription registers a URL for the given events. The signing
+// secret is generated unless supplied and is only returned here.
+func (h *Handler) CreateSubscription(c *fiber.Ctx) error {
+	var input subscriptionInput
+	if err := c.BodyParser(&input); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input")
+	}
+	if err := validate(input); err != nil {
+		return err
+	}
+
+	sub := &Subscription{URL: input.URL, Events: input.Events, Secret: input.Secret}
+	if sub.Secret == "" {
+		secret, err := GenerateSecret()
+		if err != nil {
+			return err
+		}
+		sub.Secret = secret
+	}
+	if err := h.store.CreateSubscription(c.UserContext(), sub); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(sub)
+}
+
+func validate(input subscriptionInput) error {
+	var fields []domain.FieldError
+	u, err := url.Parse(input.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		fields = append(fields, domain.FieldError{Field: "url", Reason: "must be an absolute http or https URL"})
+	}
+	if len(input.Events) == 0 {
+		fields = append(fields, domain.FieldError{Field: "events", Reason: "must not be empty"})
+	}
+	for _, e := range input.Events {
+		if !slices.Contains(EventTypes, e) {
+			fields = append(fields, domain.FieldError{Field: "events", Reason: "has unknown event type " + e})
+		}
+	}
+	if input.Secret != "" && len(input.Secret) < 16 {
+		fields = append(fields, domain.FieldError{Field: "secret", Reason: "must be at least 16 characters"})
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return &domain.Error{Kind: domain.ErrValidation, Message: "Invalid webhook", Fields: fields}
+}
+
+// ListSubscriptions returns the tenant's subscriptions without secrets.
+func (h *Handler) ListSubscriptions(c *fiber.Ctx) error {
+	subs, err := h.store.ListSubscriptions(c.UserContext())
+	if err != nil {
+		return err
+	}
+	if subs == nil {
+		subs = []Subscription{}
+	}
+	return c.JSON(subs)
+}
+
+// GetSubscription returns one subscription without its secret.
+func (h *Handler) GetSubscription(c *fiber.Ctx) error {
+	sub, err := h.store.GetSubscription(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return notFound(c, "Webhook", err)
+	}
+	return c.JSON(sub)
+}
+
+// DeleteSubscription removes a subscription and its delivery log.
+func (h *Handler) DeleteSubscription(c *fiber.Ctx) error {
+	if err := h.store.DeleteSubscription(c.UserContext(), c.Params("id")); err != nil {
+		return notFound(c, "Webhook", err)
+	}
+	return c.JSON(fiber.Map{
+		"message": "Webhook successfully deleted",
+	})
+}
+
+// ListDeliveries returns the newest deliveries of a subscription.
+func (h *Handler) ListDeliveries(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := h.store.GetSubscription(c.UserContext(), id); err != nil {
+		return notFound(c, "Webhook", err)
+	}
+	limit := c.QueryInt("limit", 50)
+	if limit < 1 || limit > maxDeliveries {
+		limit = maxDeliveries
+	}
+	deliveries, err := h.store.ListDeliveries(c.UserContext(), id, limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(deliveries)
+}
+
+// Redeliver queues a delivery again, e.g. after a receiver outage that
+// exhausted its retries.
+func (h *Handler) Redeliver(c *fiber.Ctx) error {
+	if err := h.store.Redeliver(c.UserContext(), c.Params("id"), time.Now()); err != nil {
+		return notFound(c, "Delivery", err)
+	}
+	if h.dispatcher != nil {
+		h.dispatcher.Wake()
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Delivery queued",
+	})
+}
+
+// notFound turns ErrNotFound into a domain error naming what was missing.
+func notFound(c *fiber.Ctx, what string, err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return domain.NewError(domain.ErrNotFound, what+" "+c.Params("id")+" not found", err)
+	}
+	return err
+}
@@ -0,0 +1,335 @@
+// internal/webhook/sql_store.go
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"product-management/internal/durable"
+
+	"github.com/google/uuid"
+)
+
+var schema = durable.Schema{
+	MySQL: []string{`
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+	id CHAR(36) PRIMARY KEY,
+	tenant_id VARCHAR(64) NOT NULL,
+	url VARCHAR(2048) NOT NULL,
+	secret VARCHAR(128) NOT NULL,
+	events VARCHAR(255) NOT NULL,
+	active BOOLEAN NOT NULL,
+	created_at DATETIME(6) NOT NULL,
+	KEY idx_webhook_subscriptions_tenant (tenant_id)
+)`, `
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id CHAR(36) PRIMARY KEY,
+	subscription_id CHAR(36) NOT NULL,
+	tenant_id VARCHAR(64) NOT NULL,
+	event_id CHAR(36) NOT NULL,
+	event_type VARCHAR(32) NOT NULL,
+	payload MEDIUMTEXT NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	attempts INT NOT NULL,
+	response_code INT NOT NULL,
+	last_error VARCHAR(1024) NOT NULL,
+	next_attempt_at DATETIME(6) NOT NULL,
+	created_at DATETIME(6) NOT NULL,
+	updated_at DATETIME(6) NOT NULL,
+	KEY idx_webhook_deliveries_due (status, next_attempt_at),
+	KEY idx_webhook_deliveries_subscription (subscription_id, created_at)
+)`},
+	SQLite: []string{`
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+	id TEXT PRIMARY KEY,
+	tenant_id TEXT NOT NULL,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	events TEXT NOT NULL,
+	active BOOLEAN NOT NULL,
+	created_at DATETIME NOT NULL
+)`,
+		"CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_tenant ON webhook_subscriptions (tenant_id)", `
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id TEXT PRIMARY KEY,
+	subscription_id TEXT NOT NULL,
+	tenant_id TEXT NOT NULL,
+	event_id TEXT NOT NULL,
+	event_type TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	status TEXT NOT NULL,
+	attempts INTEGER NOT NULL,
+	response_code INTEGER NOT NULL,
+	last_error TEXT NOT NULL,
+	next_attempt_at DATETIME NOT NULL,
+	created_at DATETIME NOT NULL,
+	updated_at DATETIME NOT NULL
+)`,
+		"CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at)",
+		"CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries (subscription_id, created_at)",
+	},
+}
+
+// SQLStore keeps subscriptions and deliveries in MySQL next to the
+// products or, in the single-file embedded mode, SQLite, so the delivery
+// log survives restarts.
+type SQLStore struct {
+	db durable.DB
+}
+
+// NewMySQLStore creates a store backed by a MySQL db.
+func NewMySQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: durable.MySQL(db, schema)}
+}
+
+// NewSQLiteStore creates a store backed by a SQLite db.
+func NewSQLiteStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: durable.SQLite(db, schema)}
+}
+
+// EnsureSchema creates the webhook tables if they do not exist.
+func (s *SQLStore) EnsureSchema(ctx context.Context) error {
+	return s.db.EnsureSchema(ctx)
+}
+
+// CreateSubscription stores sub for the tenant in ctx and fills in its ID,
+// tenant and creation time.
+func (s *SQLStore) CreateSubscription(ctx context.Context, sub *Subscription) error {
+	id, err := durable.SingleTenant(ctx)
+	if err != nil {
+		return err
+	}
+	sub.ID, sub.TenantID, sub.Active, sub.CreatedAt = uuid.NewString(), id, true, durable.Now()
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO webhook_subscriptions (id, tenant_id, url, secret, events, active, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		sub.ID, sub.TenantID, sub.URL, sub.Secret, strings.Join(sub.Events, ","), sub.Active, sub.CreatedAt)
+	return err
+}
+
+const subscriptionColumns = "id, tenant_id, url, events, active, created_at"
+
+func scanSubscription(row interface{ Scan(...any) error }) (*Subscription, error) {
+	var sub Subscription
+	var eventList string
+	if err := row.Scan(&sub.ID, &sub.TenantID, &sub.URL, &eventList, &sub.Active, &sub.CreatedAt); err != nil {
+		return nil, err
+	}
+	sub.Events = strings.Split(eventList, ",")
+	return &sub, nil
+}
+
+// ListSubscriptions returns the subscriptions of the tenant in ctx.
+func (s *SQLStore) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	id, err := durable.SingleTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE tenant_id = ? ORDER BY created_at", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, *sub)
+	}
+	return subs, rows.Err()
+}
+
+// GetSubscription returns one subscription of the tenant in ctx.
+func (s *SQLStore) GetSubscription(ctx context.Context, subID string) (*Subscription, error) {
+	id, err := durable.SingleTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := scanSubscription(s.db.QueryRowContext(ctx,
+		"SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE id = ? AND tenant_id = ?", subID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return sub, err
+}
+
+// DeleteSubscription removes a subscription of the tenant in ctx together
+// with its delivery log.
+func (s *SQLStore) DeleteSubscription(ctx context.Context, subID string) error {
+	id, err := durable.SingleTenant(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, "DELETE FROM webhook_subscriptions WHERE id = ? AND tenant_id = ?", subID, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	_, err = s.db.ExecContext(ctx, "DELETE FROM webhook_deliveries WHERE subscription_id = ?", subID)
+	return err
+}
+
+const deliveryColumns = "d.id, d.subscription_id, d.tenant_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, " +
+	"d.response_code, d.last_error, d.next_attempt_at, d.created_at, d.updated_at"
+
+func scanDelivery(row interface{ Scan(...any) error }, extra ...any) (*Delivery, error) {
+	var d Delivery
+	dest := append([]any{&d.ID, &d.SubscriptionID, &d.TenantID, &d.EventID, &d.EventType, &d.Payload, &d.Status,
+		&d.Attempts, &d.ResponseCode, &d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.UpdatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ListDeliveries returns the newest deliveries of a subscription of the
+// tenant in ctx.
+func (s *SQLStore) ListDeliveries(ctx context.Context, subID string, limit int) ([]Delivery, error) {
+	id, err := durable.SingleTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries d WHERE d.subscription_id = ? AND d.tenant_id = ? ORDER BY d.created_at DESC LIMIT ?",
+		subID, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []Delivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+// Redeliver queues a delivery of the tenant in ctx again, with a fresh
+// attempt budget, regardless of its current status.
+func (s *SQLStore) Redeliver(ctx context.Context, deliveryID string, at time.Time) error {
+	id, err := durable.SingleTenant(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries SET status = ?, attempts = 0, next_attempt_at = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ?`,
+		StatusPending, at.UTC(), durable.Now(), deliveryID, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ActiveSubscriptions returns the active subscriptions of tenantID.
+func (s *SQLStore) ActiveSubscriptions(ctx context.Context, tenantID string) ([]Subscription, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE tenant_id = ? AND active = TRUE", tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, *sub)
+	}
+	return subs, rows.Err()
+}
+
+// CreateDeliveries queues deliveries in one transaction.
+func (s *SQLStore) CreateDeliveries(ctx context.Context, deliveries []Delivery) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, d := range deliveries {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO webhook_deliveries (id, subscription_id, tenant_id, event_id, event_type, payload, status,
+				attempts, response_code, last_error, next_attempt_at, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			d.ID, d.SubscriptionID, d.TenantID, d.EventID, d.EventType, string(d.Payload), d.Status,
+			d.Attempts, d.ResponseCode, d.LastError, d.NextAttemptAt.UTC(), d.CreatedAt.UTC(), d.UpdatedAt.UTC())
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DueDeliveries returns pending deliveries whose next attempt is due,
+// across all tenants, with the URL and secret of their subscription.
+func (s *SQLStore) DueDeliveries(ctx context.Context, at time.Time, limit int) ([]Delivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+deliveryColumns+`, s.url, s.secret
+		FROM webhook_deliveries d JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		WHERE d.status = ? AND d.next_attempt_at <= ?
+		ORDER BY d.next_attempt_at LIMIT ?`,
+		StatusPending, at.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		var url, secret string
+		d, err := scanDelivery(rows, &url, &secret)
+		if err != nil {
+			return nil, err
+		}
+		d.url, d.secret = url, secret
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+// ClaimDelivery implements Store with a compare-and-set on next_attempt_at.
+func (s *SQLStore) ClaimDelivery(ctx context.Context, d *Delivery, leaseUntil time.Time) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries SET next_attempt_at = ?
+		WHERE id = ? AND status = ? AND next_attempt_at = ?`,
+		leaseUntil.UTC(), d.ID, StatusPending, d.NextAttemptAt.UTC())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 {
+		return false, err
+	}
+	d.NextAttemptAt = leaseUntil
+	return true, nil
+}
+
+// RecordAttempt stores the outcome of an attempt.
+func (s *SQLStore) RecordAttempt(ctx context.Context, d *Delivery) error {
+	d.LastError = durable.TruncateError(d.LastError)
+	d.UpdatedAt = durable.Now()
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, response_code = ?, last_error = ?, next_attempt_at = ?, updated_at = ?
+		WHERE id = ?`,
+		d.Status, d.Attempts, d.ResponseCode, d.LastError, d.NextAttemptAt.UTC(), d.UpdatedAt, d.ID)
+	return err
+}
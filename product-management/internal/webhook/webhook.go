@@ -0,0 +1,154 @@
+// internal/webhook/webhook.go
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"product-management/internal/events"
+)
+
+// ErrNotFound is returned for unknown subscriptions and deliveries, and for
+// those of another tenant.
+var ErrNotFound = errors.New("webhook: not found")
+
+// Headers sent with every delivery.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// EventTypes lists the events a subscription can filter on.
+var EventTypes = []string{events.ProductCreated, events.ProductUpdated, events.ProductDeleted, events.StockLow}
+
+// Delivery statuses.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Subscription sends the selected events of one tenant to URL.
+type Subscription struct {
+	ID       string   `json:"id"`
+	TenantID string   `json:"tenant_id"`
+	URL      string   `json:"url"`
+	Events   []string `json:"events"`
+	// Secret signs deliveries. It is only returned when the subscription
+	// is created.
+	Secret    string    `json:"secret,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Wants reports whether the subscription filters in eventType.
+func (s *Subscription) Wants(eventType string) bool {
+	for _, e := range s.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery is one event sent, or to be sent, to one subscription.
+type Delivery struct {
+	ID             string    `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	TenantID       string    `json:"tenant_id"`
+	EventID        string    `json:"event_id"`
+	EventType      string    `json:"event_type"`
+	Payload        []byte    `json:"-"`
+	Status         string    `json:"status"`
+	Attempts       int       `json:"attempts"`
+	ResponseCode   int       `json:"response_code"`
+	LastError      string    `json:"last_error"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Filled in for the dispatcher from the subscription.
+	url    string
+	secret string
+}
+
+// Store persists subscriptions and the delivery log. Methods used by the
+// admin API are scoped to the tenant in the context; the dispatcher calls
+// the others with explicit tenants or across all tenants.
+type Store interface {
+	CreateSubscription(ctx context.Context, sub *Subscription) error
+	ListSubscriptions(ctx context.Context) ([]Subscription, error)
+	GetSubscription(ctx context.Context, id string) (*Subscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]Delivery, error)
+	Redeliver(ctx context.Context, deliveryID string, at time.Time) error
+
+	ActiveSubscriptions(ctx context.Context, tenantID string) ([]Subscription, error)
+	CreateDeliveries(ctx context.Context, deliveries []Delivery) error
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]Delivery, error)
+	// ClaimDelivery moves a due delivery's next attempt to leaseUntil so
+	// other dispatchers skip it while it is being sent. It reports false
+	// when someone else claimed it first.
+	ClaimDelivery(ctx context.Context, d *Delivery, leaseUntil time.Time) (bool, error)
+	RecordAttempt(ctx context.Context, d *Delivery) error
+}
+
+// GenerateSecret returns a new random signing secret.
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign returns the X-Webhook-Signature value for body sent at timestamp:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">". Including the
+// timestamp lets receivers reject replayed deliveries.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + mac(secret, t, body)
+}
+
+// Verify checks a signature header produced by Sign and rejects it when
+// the timestamp is further than tolerance from now.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var t, v1 string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			v1 = value
+		}
+	}
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || v1 == "" {
+		return errors.New("webhook: malformed signature")
+	}
+	if d := now.Sub(time.Unix(unix, 0)); d > tolerance || d < -tolerance {
+		return fmt.Errorf("webhook: signature timestamp outside tolerance")
+	}
+	if !hmac.Equal([]byte(v1), []byte(mac(secret, t, body))) {
+		return errors.New("webhook: signature mismatch")
+	}
+	return nil
+}
+
+func mac(secret, timestamp string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
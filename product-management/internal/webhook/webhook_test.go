@@ -0,0 +1,237 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"product-management/internal/config"
+	"product-management/internal/domain"
+	"product-management/internal/events"
+	"product-management/internal/handler"
+	"product-management/internal/repository/mysql/mysqltest"
+	"product-management/internal/repository/sqlite"
+	"product-management/internal/tenant"
+	"product-management/internal/webhook"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = config.WebhookConfig{
+	PollInterval:      time.Hour,
+	Timeout:           5 * time.Second,
+	MaxAttempts:       3,
+	LowStockThreshold: 5,
+}
+
+// receiver records signed requests and answers with the queued statuses,
+// then 200.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	bodies   []webhook.Payload
+	secret   string
+	t        *testing.T
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	assert.NoError(r.t, webhook.Verify(r.secret, req.Header.Get(webhook.SignatureHeader), body, time.Minute, time.Now()))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var p webhook.Payload
+	assert.NoError(r.t, json.Unmarshal(body, &p))
+	assert.Equal(r.t, p.Type, req.Header.Get(webhook.EventHeader))
+	r.bodies = append(r.bodies, p)
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+// stores open an empty store of each kind.
+var stores = map[string]func(t *testing.T) webhook.Store{
+	"mysql": func(t *testing.T) webhook.Store {
+		store := webhook.NewMySQLStore(mysqltest.New(t))
+		require.NoError(t, store.EnsureSchema(context.Background()))
+		return store
+	},
+	"sqlite": func(t *testing.T) webhook.Store {
+		db, err := sqlite.Open(filepath.Join(t.TempDir(), "produk.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		store := webhook.NewSQLiteStore(db)
+		require.NoError(t, store.EnsureSchema(context.Background()))
+		return store
+	},
+}
+
+func setup(t *testing.T, newStore func(t *testing.T) webhook.Store, eventTypes []string, statuses ...int) (webhook.Store, *webhook.Dispatcher, *receiver, context.Context) {
+	store := newStore(t)
+
+	recv := &receiver{statuses: statuses, secret: "whsec_test_secret_value", t: t}
+	srv := httptest.NewServer(recv)
+	t.Cleanup(srv.Close)
+
+	ctx := tenant.WithTenant(context.Background(), "shop-a")
+	require.NoError(t, store.CreateSubscription(ctx, &webhook.Subscription{URL: srv.URL, Events: eventTypes, Secret: recv.secret}))
+	return store, webhook.NewDispatcher(store, testConfig), recv, ctx
+}
+
+func updated(before, after int) events.Event {
+	return events.Event{
+		ID:         "9f0c4c1e-0000-4000-8000-000000000001",
+		Type:       events.ProductUpdated,
+		TenantID:   "shop-a",
+		ProductID:  "1",
+		OccurredAt: time.Now(),
+		Before:     &domain.Product{ID: "1", Name: "kecap", Stock: before},
+		After:      &domain.Product{ID: "1", Name: "kecap", Stock: after},
+	}
+}
+
+func TestSignature(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	now := time.Now()
+	header := webhook.Sign("secret", now, body)
+
+	assert.NoError(t, webhook.Verify("secret", header, body, time.Minute, now))
+	assert.Error(t, webhook.Verify("other", header, body, time.Minute, now))
+	assert.Error(t, webhook.Verify("secret", header, []byte(`{"id":"2"}`), time.Minute, now))
+	assert.Error(t, webhook.Verify("secret", header, body, time.Minute, now.Add(time.Hour)))
+}
+
+func TestDeliveryIsRetriedUntilItSucceeds(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store, dispatcher, recv, ctx := setup(t, newStore, []string{events.ProductUpdated}, http.StatusServiceUnavailable)
+
+			dispatcher.Publish(ctx, updated(10, 9))
+			dispatcher.Poll(context.Background())
+
+			subs, err := store.ListSubscriptions(ctx)
+			require.NoError(t, err)
+			deliveries, err := store.ListDeliveries(ctx, subs[0].ID, 10)
+			require.NoError(t, err)
+			require.Len(t, deliveries, 1)
+			assert.Equal(t, webhook.StatusPending, deliveries[0].Status)
+			assert.Equal(t, 1, deliveries[0].Attempts)
+			assert.Equal(t, http.StatusServiceUnavailable, deliveries[0].ResponseCode)
+
+			// BackoffBase is zero, so the retry is due immediately.
+			dispatcher.Poll(context.Background())
+			deliveries, err = store.ListDeliveries(ctx, subs[0].ID, 10)
+			require.NoError(t, err)
+			assert.Equal(t, webhook.StatusSucceeded, deliveries[0].Status)
+			assert.Equal(t, 2, deliveries[0].Attempts)
+
+			require.Len(t, recv.bodies, 2)
+			assert.Equal(t, 10, recv.bodies[1].Data.Before.Stock)
+			assert.Equal(t, 9, recv.bodies[1].Data.After.Stock)
+		})
+	}
+}
+
+func TestDeliveryFailsAfterMaxAttemptsAndCanBeRedelivered(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store, dispatcher, _, ctx := setup(t, newStore, []string{events.ProductUpdated}, 500, 500, 500)
+
+			dispatcher.Publish(ctx, updated(10, 9))
+			for i := 0; i < testConfig.MaxAttempts+1; i++ {
+				dispatcher.Poll(context.Background())
+			}
+
+			subs, _ := store.ListSubscriptions(ctx)
+			deliveries, err := store.ListDeliveries(ctx, subs[0].ID, 10)
+			require.NoError(t, err)
+			assert.Equal(t, webhook.StatusFailed, deliveries[0].Status)
+			assert.Equal(t, testConfig.MaxAttempts, deliveries[0].Attempts)
+
+			require.NoError(t, store.Redeliver(ctx, deliveries[0].ID, time.Now()))
+			assert.ErrorIs(t, store.Redeliver(tenant.WithTenant(context.Background(), "shop-b"), deliveries[0].ID, time.Now()), webhook.ErrNotFound)
+			dispatcher.Poll(context.Background())
+
+			deliveries, _ = store.ListDeliveries(ctx, subs[0].ID, 10)
+			assert.Equal(t, webhook.StatusSucceeded, deliveries[0].Status)
+		})
+	}
+}
+
+func TestEventFilterAndLowStock(t *testing.T) {
+	_, dispatcher, recv, ctx := setup(t, stores["mysql"], []string{events.StockLow})
+
+	dispatcher.Publish(ctx, updated(10, 8)) // above the threshold
+	dispatcher.Publish(ctx, updated(8, 5))  // crosses it
+	dispatcher.Publish(ctx, updated(5, 4))  // already below
+	other := updated(10, 1)
+	other.TenantID = "shop-b"
+	dispatcher.Publish(ctx, other)
+	dispatcher.Poll(context.Background())
+
+	require.Len(t, recv.bodies, 1)
+	assert.Equal(t, events.StockLow, recv.bodies[0].Type)
+	assert.Equal(t, 5, recv.bodies[0].Data.After.Stock)
+}
+
+func TestAdminAPI(t *testing.T) {
+	store := webhook.NewMySQLStore(mysqltest.New(t))
+	require.NoError(t, store.EnsureSchema(context.Background()))
+	h := webhook.NewHandler(store, nil)
+
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		c.SetUserContext(tenant.WithTenant(c.UserContext(), c.Get(tenant.Header)))
+		return c.Next()
+	})
+	app.Post("/webhooks", h.CreateSubscription)
+	app.Get("/webhooks", h.ListSubscriptions)
+	app.Get("/webhooks/:id", h.GetSubscription)
+	app.Delete("/webhooks/:id", h.DeleteSubscription)
+
+	send := func(method, path, tenantID, body string) (int, string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(tenant.Header, tenantID)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		raw, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(raw)
+	}
+
+	code, _ := send("POST", "/webhooks", "shop-a", `{"url":"ftp://pos.local","events":["product.created"]}`)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, code)
+	code, body := send("POST", "/webhooks", "shop-a", `{"url":"https://pos.local/hook","events":["product.renamed"]}`)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, code)
+	assert.Contains(t, body, "unknown event type product.renamed")
+	code, _ = send("POST", "/webhooks", "shop-a", `{"url":`)
+	assert.Equal(t, fiber.StatusBadRequest, code)
+
+	code, body = send("POST", "/webhooks", "shop-a", `{"url":"https://pos.local/hook","events":["product.created","stock.low"]}`)
+	require.Equal(t, fiber.StatusCreated, code)
+	var created webhook.Subscription
+	require.NoError(t, json.Unmarshal([]byte(body), &created))
+	assert.True(t, strings.HasPrefix(created.Secret, "whsec_"))
+
+	code, body = send("GET", "/webhooks", "shop-a", "")
+	assert.Equal(t, fiber.StatusOK, code)
+	assert.Contains(t, body, created.ID)
+	assert.NotContains(t, body, created.Secret)
+
+	code, _ = send("GET", "/webhooks/"+created.ID, "shop-b", "")
+	assert.Equal(t, fiber.StatusNotFound, code)
+	code, _ = send("DELETE", "/webhooks/"+created.ID, "shop-b", "")
+	assert.Equal(t, fiber.StatusNotFound, code)
+	code, _ = send("DELETE", "/webhooks/"+created.ID, "shop-a", "")
+	assert.Equal(t, fiber.StatusOK, code)
+}